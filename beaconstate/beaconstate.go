@@ -0,0 +1,216 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+
+// Package beaconstate extracts single fields from SSZ-encoded BeaconStates without decoding the whole state.
+// Fields are referenced by their consensus spec names (e.g. "validators"). The package holds a registry of the
+// BeaconState layouts of all forks, so it does not depend on any consensus type library. The fork of a state is
+// detected from the encoded data itself.
+package beaconstate
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"strings"
+
+	dynssz "github.com/pk910/dynamic-ssz"
+)
+
+type fork int
+
+const (
+	phase0 fork = iota
+	altair
+	bellatrix
+	capella
+	deneb
+	electra
+	fulu
+)
+
+var forkNames = []string{"phase0", "altair", "bellatrix", "capella", "deneb", "electra", "fulu"}
+
+// stateField describes a field of the BeaconState. Static fields have their mainnet preset size in 'sszSize' and
+// a spec expression for the size in 'dynSize' if it depends on the preset, dynamic fields have no size.
+// The field exists from fork 'since' up to the fork 'until' that removed it (0 if it has not been removed).
+type stateField struct {
+	name    string
+	sszSize string
+	dynSize string
+	since   fork
+	until   fork
+}
+
+// stateFields is the registry of all BeaconState fields in their order within the container.
+var stateFields = []stateField{
+	{name: "genesis_time", sszSize: "8"},
+	{name: "genesis_validators_root", sszSize: "32"},
+	{name: "slot", sszSize: "8"},
+	{name: "fork", sszSize: "16"},
+	{name: "latest_block_header", sszSize: "112"},
+	{name: "block_roots", sszSize: "262144", dynSize: "SLOTS_PER_HISTORICAL_ROOT*32"},
+	{name: "state_roots", sszSize: "262144", dynSize: "SLOTS_PER_HISTORICAL_ROOT*32"},
+	{name: "historical_roots"},
+	{name: "eth1_data", sszSize: "72"},
+	{name: "eth1_data_votes"},
+	{name: "eth1_deposit_index", sszSize: "8"},
+	{name: "validators"},
+	{name: "balances"},
+	{name: "randao_mixes", sszSize: "2097152", dynSize: "EPOCHS_PER_HISTORICAL_VECTOR*32"},
+	{name: "slashings", sszSize: "65536", dynSize: "EPOCHS_PER_SLASHINGS_VECTOR*8"},
+	{name: "previous_epoch_attestations", until: altair},
+	{name: "current_epoch_attestations", until: altair},
+	{name: "previous_epoch_participation", since: altair},
+	{name: "current_epoch_participation", since: altair},
+	{name: "justification_bits", sszSize: "1"},
+	{name: "previous_justified_checkpoint", sszSize: "40"},
+	{name: "current_justified_checkpoint", sszSize: "40"},
+	{name: "finalized_checkpoint", sszSize: "40"},
+	{name: "inactivity_scores", since: altair},
+	{name: "current_sync_committee", sszSize: "24624", dynSize: "SYNC_COMMITTEE_SIZE*48+48", since: altair},
+	{name: "next_sync_committee", sszSize: "24624", dynSize: "SYNC_COMMITTEE_SIZE*48+48", since: altair},
+	{name: "latest_execution_payload_header", since: bellatrix},
+	{name: "next_withdrawal_index", sszSize: "8", since: capella},
+	{name: "next_withdrawal_validator_index", sszSize: "8", since: capella},
+	{name: "historical_summaries", since: capella},
+	{name: "deposit_requests_start_index", sszSize: "8", since: electra},
+	{name: "deposit_balance_to_consume", sszSize: "8", since: electra},
+	{name: "exit_balance_to_consume", sszSize: "8", since: electra},
+	{name: "earliest_exit_epoch", sszSize: "8", since: electra},
+	{name: "consolidation_balance_to_consume", sszSize: "8", since: electra},
+	{name: "earliest_consolidation_epoch", sszSize: "8", since: electra},
+	{name: "pending_deposits", since: electra},
+	{name: "pending_partial_withdrawals", since: electra},
+	{name: "pending_consolidations", since: electra},
+	{name: "proposer_lookahead", sszSize: "512", dynSize: "(MIN_SEED_LOOKAHEAD+1)*SLOTS_PER_EPOCH*8", since: fulu},
+}
+
+// stateLayout is the BeaconState layout of one or more forks, as struct type with one byte vector or byte list
+// field per state field.
+type stateLayout struct {
+	forks     []string
+	stateType reflect.Type
+	fields    map[string]string // spec name -> struct field name
+}
+
+// stateLayouts holds the distinct BeaconState layouts, oldest fork first. Forks that did not change the fields of the
+// BeaconState share their layout with the previous fork.
+var stateLayouts = buildStateLayouts()
+
+func buildStateLayouts() []*stateLayout {
+	layouts := []*stateLayout{}
+	for f := range forkNames {
+		structFields := []reflect.StructField{}
+		fieldNames := map[string]string{}
+		for _, field := range stateFields {
+			if fork(f) < field.since || (field.until > 0 && fork(f) >= field.until) {
+				continue
+			}
+
+			tag := ""
+			if field.sszSize != "" {
+				tag = fmt.Sprintf(`ssz-size:"%v"`, field.sszSize)
+				if field.dynSize != "" {
+					tag += fmt.Sprintf(` dynssz-size:"%v"`, field.dynSize)
+				}
+			}
+
+			fieldName := getGoFieldName(field.name)
+			structFields = append(structFields, reflect.StructField{
+				Name: fieldName,
+				Type: reflect.TypeOf([]byte{}),
+				Tag:  reflect.StructTag(tag),
+			})
+			fieldNames[field.name] = fieldName
+		}
+
+		stateType := reflect.StructOf(structFields)
+		if len(layouts) > 0 && layouts[len(layouts)-1].stateType == stateType {
+			layouts[len(layouts)-1].forks = append(layouts[len(layouts)-1].forks, forkNames[f])
+			continue
+		}
+
+		layouts = append(layouts, &stateLayout{
+			forks:     []string{forkNames[f]},
+			stateType: stateType,
+			fields:    fieldNames,
+		})
+	}
+	return layouts
+}
+
+// getGoFieldName converts a spec field name to an exported Go field name (e.g. "eth1_data" becomes "Eth1Data").
+func getGoFieldName(name string) string {
+	parts := strings.Split(name, "_")
+	for i, part := range parts {
+		parts[i] = strings.ToUpper(part[:1]) + part[1:]
+	}
+	return strings.Join(parts, "")
+}
+
+// Extract decodes a single field of an SSZ-encoded BeaconState into the target, e.g.
+// Extract(ds, ssz, "validators", &validators). Only the fixed size part of the state and the data of the requested
+// field are decoded, so this is much cheaper than decoding the full state.
+// The 'field' parameter is the spec name of the field, the 'target' parameter must be a pointer to a value of the
+// field type. Preset dependent sizes are resolved with the spec values of the given DynSsz instance.
+// Returns an error if the data does not match any known BeaconState layout, the field does not exist in the fork of
+// the state, or decoding fails.
+func Extract(ds *dynssz.DynSsz, ssz []byte, field string, target any) error {
+	layout, err := getStateLayout(ds, ssz)
+	if err != nil {
+		return err
+	}
+
+	fieldName, found := layout.fields[field]
+	if !found {
+		for _, stateField := range stateFields {
+			if stateField.name == field {
+				return fmt.Errorf("field %v is not part of %v states", field, strings.Join(layout.forks, "/"))
+			}
+		}
+		return fmt.Errorf("unknown BeaconState field %v", field)
+	}
+
+	state := reflect.New(layout.stateType)
+	if err := ds.UnmarshalSSZProjection(state.Interface(), ssz, fieldName); err != nil {
+		return fmt.Errorf("failed decoding BeaconState field %v: %v", field, err)
+	}
+
+	return ds.UnmarshalSSZ(target, state.Elem().FieldByName(fieldName).Bytes())
+}
+
+// Fork returns the names of the forks whose BeaconState layout matches the SSZ-encoded state. Forks that did not
+// change the fields of the BeaconState can not be told apart, so multiple names may be returned (e.g. "capella" and
+// "deneb").
+func Fork(ds *dynssz.DynSsz, ssz []byte) ([]string, error) {
+	layout, err := getStateLayout(ds, ssz)
+	if err != nil {
+		return nil, err
+	}
+	return layout.forks, nil
+}
+
+// getStateLayout detects the layout of an SSZ-encoded BeaconState. The offset of 'historical_roots', the first
+// dynamic field of all forks, points to the end of the fixed size part, whose size differs between the layouts.
+func getStateLayout(ds *dynssz.DynSsz, ssz []byte) (*stateLayout, error) {
+	for i := len(stateLayouts) - 1; i >= 0; i-- {
+		layout := stateLayouts[i]
+
+		offset, _, _, err := ds.FieldOffset(layout.stateType, "HistoricalRoots")
+		if err != nil {
+			return nil, err
+		}
+		lastField := layout.stateType.Field(layout.stateType.NumField() - 1)
+		lastOffset, lastSize, _, err := ds.FieldOffset(layout.stateType, lastField.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(ssz) >= offset+4 && uint64(binary.LittleEndian.Uint32(ssz[offset:offset+4])) == uint64(lastOffset+lastSize) {
+			return layout, nil
+		}
+	}
+
+	return nil, fmt.Errorf("ssz data does not match the BeaconState layout of any known fork")
+}
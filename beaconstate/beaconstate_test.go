@@ -0,0 +1,107 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package beaconstate_test
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	dynssz "github.com/pk910/dynamic-ssz"
+	"github.com/pk910/dynamic-ssz/beaconstate"
+)
+
+type slug_Validator struct {
+	Pubkey                     []byte `ssz-size:"48"`
+	WithdrawalCredentials      []byte `ssz-size:"32"`
+	EffectiveBalance           uint64
+	Slashed                    bool
+	ActivationEligibilityEpoch uint64
+	ActivationEpoch            uint64
+	ExitEpoch                  uint64
+	WithdrawableEpoch          uint64
+}
+
+type slug_SyncCommittee struct {
+	Pubkeys         [][]byte `ssz-size:"512,48" dynssz-size:"SYNC_COMMITTEE_SIZE,48"`
+	AggregatePubkey []byte   `ssz-size:"48"`
+}
+
+func TestExtract(t *testing.T) {
+	minimalSpecs := map[string]any{
+		"SYNC_COMMITTEE_SIZE":          uint64(32),
+		"EPOCHS_PER_HISTORICAL_VECTOR": uint64(64),
+		"EPOCHS_PER_SLASHINGS_VECTOR":  uint64(64),
+		"SLOTS_PER_HISTORICAL_ROOT":    uint64(64),
+	}
+
+	testCases := []struct {
+		file          string
+		specs         map[string]any
+		syncCommittee int
+	}{
+		{"../test/state-mainnet.ssz", nil, 512},
+		{"../test/state-minimal.ssz", minimalSpecs, 32},
+	}
+
+	for _, tc := range testCases {
+		ssz, err := os.ReadFile(tc.file)
+		if err != nil {
+			t.Fatalf("failed reading %v: %v", tc.file, err)
+		}
+		ds := dynssz.NewDynSsz(tc.specs)
+
+		forks, err := beaconstate.Fork(ds, ssz)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", tc.file, err)
+		}
+		if !reflect.DeepEqual(forks, []string{"capella", "deneb"}) {
+			t.Errorf("%v: unexpected forks: %v", tc.file, forks)
+		}
+
+		var validators []*slug_Validator
+		if err := beaconstate.Extract(ds, ssz, "validators", &validators); err != nil {
+			t.Fatalf("%v: unexpected error: %v", tc.file, err)
+		}
+		var balances []uint64
+		if err := beaconstate.Extract(ds, ssz, "balances", &balances); err != nil {
+			t.Fatalf("%v: unexpected error: %v", tc.file, err)
+		}
+		if len(validators) == 0 || len(validators) != len(balances) {
+			t.Errorf("%v: unexpected validator count: %v (balances: %v)", tc.file, len(validators), len(balances))
+		}
+
+		var slot uint64
+		if err := beaconstate.Extract(ds, ssz, "slot", &slot); err != nil {
+			t.Fatalf("%v: unexpected error: %v", tc.file, err)
+		}
+		if slot == 0 {
+			t.Errorf("%v: unexpected slot 0", tc.file)
+		}
+
+		syncCommittee := slug_SyncCommittee{}
+		if err := beaconstate.Extract(ds, ssz, "current_sync_committee", &syncCommittee); err != nil {
+			t.Fatalf("%v: unexpected error: %v", tc.file, err)
+		}
+		if len(syncCommittee.Pubkeys) != tc.syncCommittee {
+			t.Errorf("%v: unexpected sync committee size: %v", tc.file, len(syncCommittee.Pubkeys))
+		}
+
+		if err := beaconstate.Extract(ds, ssz, "pending_deposits", &[]byte{}); err == nil {
+			t.Errorf("%v: expected error for field of later fork, got none", tc.file)
+		}
+		if err := beaconstate.Extract(ds, ssz, "unknown_field", &[]byte{}); err == nil {
+			t.Errorf("%v: expected error for unknown field, got none", tc.file)
+		}
+	}
+}
+
+func TestExtractInvalidState(t *testing.T) {
+	ds := dynssz.NewDynSsz(nil)
+
+	var slot uint64
+	if err := beaconstate.Extract(ds, make([]byte, 1024), "slot", &slot); err == nil {
+		t.Errorf("expected error for invalid state, got none")
+	}
+}
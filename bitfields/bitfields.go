@@ -0,0 +1,127 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+
+// Package bitfields provides helpers for SSZ bitvectors and bitlists represented as byte slices.
+// Bits are stored in little-endian bit order: bit i is stored in byte i/8 at position i%8.
+// Bitlists additionally contain a delimiter bit directly after the last bit of the list, which determines the list
+// length. Bitvectors have a fixed length and must have all padding bits in the last byte set to zero.
+package bitfields
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+var (
+	ErrEmptyBitlist   = fmt.Errorf("bitlist is empty")
+	ErrMissingDelim   = fmt.Errorf("bitlist is missing the delimiter bit")
+	ErrLengthMismatch = fmt.Errorf("bitfield length mismatch")
+)
+
+// NewBitvector creates a zeroed bitvector with the given number of bits.
+func NewBitvector(bitLen uint64) []byte {
+	return make([]byte, (bitLen+7)/8)
+}
+
+// NewBitlist creates a bitlist with the given number of zero bits and the delimiter bit set.
+func NewBitlist(bitLen uint64) []byte {
+	bitlist := make([]byte, bitLen/8+1)
+	bitlist[bitLen/8] = 1 << (bitLen % 8)
+	return bitlist
+}
+
+// GetBit returns the bit at the given index. Indexes beyond the byte length of the bitfield return false.
+// For bitlists, callers are responsible to check the index against BitlistLen.
+func GetBit(bitfield []byte, index uint64) bool {
+	if index/8 >= uint64(len(bitfield)) {
+		return false
+	}
+	return bitfield[index/8]&(1<<(index%8)) != 0
+}
+
+// SetBit sets the bit at the given index to the given value. Indexes beyond the byte length of the bitfield are ignored.
+// For bitlists, callers are responsible to check the index against BitlistLen.
+func SetBit(bitfield []byte, index uint64, value bool) {
+	if index/8 >= uint64(len(bitfield)) {
+		return
+	}
+	if value {
+		bitfield[index/8] |= 1 << (index % 8)
+	} else {
+		bitfield[index/8] &^= 1 << (index % 8)
+	}
+}
+
+// CountSetBits returns the number of set bits in a bitvector.
+func CountSetBits(bitvector []byte) uint64 {
+	count := 0
+	for _, b := range bitvector {
+		count += bits.OnesCount8(b)
+	}
+	return uint64(count)
+}
+
+// CountSetBitlistBits returns the number of set bits in a bitlist, not counting the delimiter bit.
+func CountSetBitlistBits(bitlist []byte) (uint64, error) {
+	if _, err := BitlistLen(bitlist); err != nil {
+		return 0, err
+	}
+	return CountSetBits(bitlist) - 1, nil
+}
+
+// Intersection returns a new bitfield with the bits set that are set in both given bitfields.
+// Both bitfields must have the same byte length. For bitlists of the same length the delimiter bit is preserved.
+func Intersection(a []byte, b []byte) ([]byte, error) {
+	if len(a) != len(b) {
+		return nil, ErrLengthMismatch
+	}
+
+	result := make([]byte, len(a))
+	for i := range a {
+		result[i] = a[i] & b[i]
+	}
+	return result, nil
+}
+
+// BitlistLen returns the number of bits in a bitlist, derived from the position of the delimiter bit.
+func BitlistLen(bitlist []byte) (uint64, error) {
+	if len(bitlist) == 0 {
+		return 0, ErrEmptyBitlist
+	}
+
+	lastByte := bitlist[len(bitlist)-1]
+	if lastByte == 0 {
+		return 0, ErrMissingDelim
+	}
+
+	return uint64(len(bitlist)-1)*8 + uint64(bits.Len8(lastByte)) - 1, nil
+}
+
+// ValidateBitvector checks that the bitvector has the byte length required for 'bitLen' bits and that all padding
+// bits in the last byte are zero.
+func ValidateBitvector(bitvector []byte, bitLen uint64) error {
+	if uint64(len(bitvector)) != (bitLen+7)/8 {
+		return fmt.Errorf("%w: bitvector of %v bits requires %v bytes, got %v", ErrLengthMismatch, bitLen, (bitLen+7)/8, len(bitvector))
+	}
+
+	if bitLen%8 != 0 && bitvector[len(bitvector)-1]>>(bitLen%8) != 0 {
+		return fmt.Errorf("bitvector has non-zero padding bits")
+	}
+
+	return nil
+}
+
+// ValidateBitlist checks that the bitlist has a delimiter bit in its last byte and does not exceed 'maxLen' bits.
+func ValidateBitlist(bitlist []byte, maxLen uint64) error {
+	bitLen, err := BitlistLen(bitlist)
+	if err != nil {
+		return err
+	}
+
+	if bitLen > maxLen {
+		return fmt.Errorf("bitlist length %v exceeds maximum length %v", bitLen, maxLen)
+	}
+
+	return nil
+}
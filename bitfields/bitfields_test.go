@@ -0,0 +1,84 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package bitfields_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/pk910/dynamic-ssz/bitfields"
+)
+
+func TestBitvector(t *testing.T) {
+	bitvector := bitfields.NewBitvector(12)
+	if len(bitvector) != 2 {
+		t.Fatalf("unexpected bitvector length: %v, wanted 2", len(bitvector))
+	}
+
+	bitfields.SetBit(bitvector, 0, true)
+	bitfields.SetBit(bitvector, 9, true)
+	bitfields.SetBit(bitvector, 11, true)
+	bitfields.SetBit(bitvector, 11, false)
+	bitfields.SetBit(bitvector, 100, true)
+	if !bytes.Equal(bitvector, []byte{0x01, 0x02}) {
+		t.Errorf("unexpected bitvector: %x", bitvector)
+	}
+	if !bitfields.GetBit(bitvector, 9) || bitfields.GetBit(bitvector, 8) || bitfields.GetBit(bitvector, 100) {
+		t.Errorf("unexpected GetBit results")
+	}
+	if c := bitfields.CountSetBits(bitvector); c != 2 {
+		t.Errorf("CountSetBits = %v, wanted 2", c)
+	}
+
+	intersection, err := bitfields.Intersection(bitvector, []byte{0xff, 0x00})
+	if err != nil || !bytes.Equal(intersection, []byte{0x01, 0x00}) {
+		t.Errorf("unexpected intersection: %x (%v)", intersection, err)
+	}
+	if _, err := bitfields.Intersection(bitvector, []byte{0xff}); !errors.Is(err, bitfields.ErrLengthMismatch) {
+		t.Errorf("expected ErrLengthMismatch, got %v", err)
+	}
+
+	if err := bitfields.ValidateBitvector(bitvector, 12); err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+	if err := bitfields.ValidateBitvector(bitvector, 9); err == nil {
+		t.Errorf("expected error for non-zero padding bits")
+	}
+	if err := bitfields.ValidateBitvector(bitvector, 20); !errors.Is(err, bitfields.ErrLengthMismatch) {
+		t.Errorf("expected ErrLengthMismatch, got %v", err)
+	}
+}
+
+func TestBitlist(t *testing.T) {
+	bitlist := bitfields.NewBitlist(10)
+	if !bytes.Equal(bitlist, []byte{0x00, 0x04}) {
+		t.Fatalf("unexpected bitlist: %x", bitlist)
+	}
+	if l, err := bitfields.BitlistLen(bitlist); err != nil || l != 10 {
+		t.Errorf("BitlistLen = %v (%v), wanted 10", l, err)
+	}
+	if l, err := bitfields.BitlistLen(bitfields.NewBitlist(8)); err != nil || l != 8 {
+		t.Errorf("BitlistLen = %v (%v), wanted 8", l, err)
+	}
+
+	bitfields.SetBit(bitlist, 3, true)
+	bitfields.SetBit(bitlist, 9, true)
+	if c, err := bitfields.CountSetBitlistBits(bitlist); err != nil || c != 2 {
+		t.Errorf("CountSetBitlistBits = %v (%v), wanted 2", c, err)
+	}
+
+	if err := bitfields.ValidateBitlist(bitlist, 10); err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+	if err := bitfields.ValidateBitlist(bitlist, 9); err == nil {
+		t.Errorf("expected error for bitlist exceeding max length")
+	}
+	if err := bitfields.ValidateBitlist([]byte{}, 9); err != bitfields.ErrEmptyBitlist {
+		t.Errorf("expected ErrEmptyBitlist, got %v", err)
+	}
+	if err := bitfields.ValidateBitlist([]byte{0x01, 0x00}, 9); err != bitfields.ErrMissingDelim {
+		t.Errorf("expected ErrMissingDelim, got %v", err)
+	}
+}
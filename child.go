@@ -0,0 +1,146 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Child creates a new DynSsz instance that inherits the spec values and settings of the parent instance, with the
+// given 'overrides' applied on top. Cached type information of the parent is copied over for all types and spec
+// expressions that do not reference any of the overridden spec values, so only the affected types need to be
+// re-evaluated by the child. The parent instance is not modified.
+func (d *DynSsz) Child(overrides map[string]any) *DynSsz {
+	specs := make(map[string]any, len(d.specValues)+len(overrides))
+	for name, value := range d.specValues {
+		specs[name] = value
+	}
+	for name, value := range overrides {
+		specs[name] = value
+	}
+
+	child := NewDynSsz(specs)
+	d.inheritSettings(child)
+
+	d.specValueMutex.RLock()
+	specValueCache := make(map[string]*cachedSpecValue, len(d.specValueCache))
+	for expression, cachedValue := range d.specValueCache {
+		specValueCache[expression] = cachedValue
+	}
+	d.specValueMutex.RUnlock()
+
+	for expression, cachedValue := range specValueCache {
+		if !hasOverriddenSpecRef(getSpecExpressionRefs(expression), overrides) {
+			child.specValueCache[expression] = cachedValue
+		}
+	}
+
+	d.typeSizeMutex.RLock()
+	for targetType, cachedSize := range d.typeSizeCache {
+		if !hasOverriddenSpecRef(d.getTypeSpecRefs(targetType, nil), overrides) {
+			child.typeSizeCache[targetType] = cachedSize
+		}
+	}
+	d.typeSizeMutex.RUnlock()
+
+	d.fastsszCompatMutex.Lock()
+	for targetType, compatibility := range d.fastsszCompatCache {
+		if !hasOverriddenSpecRef(d.getTypeSpecRefs(targetType, nil), overrides) {
+			child.fastsszCompatCache[targetType] = compatibility
+			child.processedTypes[targetType] = struct{}{}
+		}
+	}
+	d.fastsszCompatMutex.Unlock()
+
+	return child
+}
+
+// inheritSettings copies the settings, field overrides and fastssz compatibility flags of the instance to a newly
+// created instance.
+func (d *DynSsz) inheritSettings(child *DynSsz) {
+	child.NoFastSsz = d.NoFastSsz
+	child.Verbose = d.Verbose
+	child.logger = d.logger
+	child.RequireSpecValues = d.RequireSpecValues
+	child.StrictVectorLength = d.StrictVectorLength
+	child.ValidateAfterDecode = d.ValidateAfterDecode
+	child.ZeroCopyDecode = d.ZeroCopyDecode
+	child.DetectConcurrentModification = d.DetectConcurrentModification
+	child.VerifyRoundTrip = d.VerifyRoundTrip
+	child.DecodeGuards = d.DecodeGuards
+	child.StreamBufferSize = d.StreamBufferSize
+	child.fieldOverrides = d.fieldOverrides
+
+	d.fastsszCompatMutex.Lock()
+	for targetType, flags := range d.compatFlags {
+		child.compatFlags[targetType] = flags
+	}
+	child.typeProcessedCallbacks = append(child.typeProcessedCallbacks, d.typeProcessedCallbacks...)
+	d.fastsszCompatMutex.Unlock()
+}
+
+// getTypeSpecRefs collects the names of all spec values referenced by 'dynssz-size' tags within the given type
+// and all types nested in it.
+func (d *DynSsz) getTypeSpecRefs(targetType reflect.Type, visited map[reflect.Type]bool) map[string]bool {
+	if visited == nil {
+		visited = map[reflect.Type]bool{}
+	}
+	refs := map[string]bool{}
+
+	for targetType.Kind() == reflect.Ptr || targetType.Kind() == reflect.Array || targetType.Kind() == reflect.Slice {
+		targetType = targetType.Elem()
+	}
+	if targetType.Kind() != reflect.Struct || visited[targetType] {
+		return refs
+	}
+	visited[targetType] = true
+
+	for i := 0; i < targetType.NumField(); i++ {
+		field := d.getStructField(targetType, i)
+
+		if fieldDynSszSizeStr, fieldHasDynSszSize := field.Tag.Lookup("dynssz-size"); fieldHasDynSszSize {
+			for _, sszSizeStr := range strings.Split(fieldDynSszSizeStr, ",") {
+				if sszSizeStr == "?" {
+					continue
+				}
+				if _, err := strconv.ParseUint(sszSizeStr, 10, 32); err == nil {
+					continue
+				}
+				for name := range getSpecExpressionRefs(sszSizeStr) {
+					refs[name] = true
+				}
+			}
+		}
+
+		for name := range d.getTypeSpecRefs(field.Type, visited) {
+			refs[name] = true
+		}
+	}
+
+	return refs
+}
+
+// getSpecExpressionRefs returns the names of all spec values referenced by a dynamic spec expression.
+func getSpecExpressionRefs(name string) map[string]bool {
+	refs := map[string]bool{}
+	expression, err := newSpecExpression(name, &specParameters{})
+	if err != nil {
+		return refs
+	}
+	for _, ref := range expression.Vars() {
+		refs[ref] = true
+	}
+	return refs
+}
+
+func hasOverriddenSpecRef(refs map[string]bool, overrides map[string]any) bool {
+	for name := range refs {
+		if _, overridden := overrides[name]; overridden {
+			return true
+		}
+	}
+	return false
+}
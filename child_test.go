@@ -0,0 +1,74 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+func TestChild(t *testing.T) {
+	payload := struct {
+		F1 []uint8 `ssz-size:"4" dynssz-size:"SPEC_A"`
+		F2 []uint8 `ssz-size:"2" dynssz-size:"SPEC_B*2"`
+	}{[]uint8{1}, []uint8{2}}
+
+	parent := NewDynSsz(map[string]any{"SPEC_A": uint64(2), "SPEC_B": uint64(1)})
+	parent.NoFastSsz = true
+
+	size, err := parent.SizeSSZ(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if size != 4 {
+		t.Errorf("unexpected parent size: %v, wanted 4", size)
+	}
+
+	child := parent.Child(map[string]any{"SPEC_A": uint64(8)})
+	if !child.NoFastSsz {
+		t.Errorf("expected child to inherit settings")
+	}
+
+	size, err = child.SizeSSZ(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if size != 10 {
+		t.Errorf("unexpected child size: %v, wanted 10", size)
+	}
+
+	size, err = parent.SizeSSZ(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if size != 4 {
+		t.Errorf("parent size changed after creating child: %v, wanted 4", size)
+	}
+}
+
+func TestChildConcurrent(t *testing.T) {
+	parent := NewDynSsz(map[string]any{"SPEC_A": uint64(2), "SPEC_B": uint64(1)})
+
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			if _, err := parent.ResolveExpression(fmt.Sprintf("SPEC_A+%v", i)); err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			parent.Child(map[string]any{"SPEC_B": uint64(i)})
+		}
+	}()
+	wg.Wait()
+}
@@ -0,0 +1,158 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ListMixin describes a list within the chunk sequence returned by MarshalChunksWithMixins. Path is the field path of
+// the list (e.g. "Body.Deposits", empty for the root object), Start the index of the first chunk of the list in the
+// chunk sequence, Count the number of chunks of the list including its items, and Length the number of items that is
+// mixed into the root of the list.
+type ListMixin struct {
+	Path   string
+	Start  int
+	Count  int
+	Length uint64
+}
+
+// MarshalChunks returns the leaf chunks of the merkle tree of the given source in tree order, so databases that
+// store SSZ objects as flat chunk arrays can be populated directly from Go objects.
+// Basic values, vectors and lists of basic values are packed into 32 byte chunks, containers and vectors or lists of
+// composite types contribute the chunks of their fields or items. The zero chunks that pad subtrees to a power of two
+// are not included. Byte lists are packed like all basic lists, bitlists are not distinguished.
+// Use MarshalChunksWithMixins to get the length mixins of the contained lists.
+func (d *DynSsz) MarshalChunks(source any) ([][32]byte, error) {
+	chunks, _, err := d.MarshalChunksWithMixins(source)
+	return chunks, err
+}
+
+// MarshalChunksWithMixins returns the leaf chunks of the given source like MarshalChunks, together with the length
+// mixins of all lists within the source in tree order.
+func (d *DynSsz) MarshalChunksWithMixins(source any) ([][32]byte, []ListMixin, error) {
+	sourceType := reflect.TypeOf(source)
+	if sourceType == nil {
+		return nil, nil, fmt.Errorf("can not marshal chunks of nil value")
+	}
+
+	writer := &chunkWriter{}
+	if err := d.writeChunks(writer, sourceType, reflect.ValueOf(source), []sszSizeHint{}, ""); err != nil {
+		return nil, nil, err
+	}
+
+	return writer.chunks, writer.mixins, nil
+}
+
+// chunkWriter collects the chunks and list mixins of MarshalChunksWithMixins.
+type chunkWriter struct {
+	chunks [][32]byte
+	mixins []ListMixin
+}
+
+// writeChunks appends the leaf chunks of the given value to the chunk writer.
+func (d *DynSsz) writeChunks(writer *chunkWriter, sourceType reflect.Type, sourceValue reflect.Value, sizeHints []sszSizeHint, path string) error {
+	for sourceType.Kind() == reflect.Ptr {
+		sourceType = sourceType.Elem()
+		if sourceValue.IsNil() {
+			sourceValue = reflect.New(sourceType)
+		}
+		sourceValue = sourceValue.Elem()
+	}
+
+	if getBasicTypeSize(sourceType) > 0 {
+		return d.writePackedChunks(writer, sourceType, sourceValue, sizeHints)
+	}
+
+	switch sourceType.Kind() {
+	case reflect.Struct:
+		for i := 0; i < sourceType.NumField(); i++ {
+			field := d.getStructField(sourceType, i)
+			fieldSizeHints, err := d.getSszSizeTag(&field)
+			if err != nil {
+				return err
+			}
+
+			fieldPath := field.Name
+			if path != "" {
+				fieldPath = path + "." + field.Name
+			}
+			if err := d.writeChunks(writer, field.Type, sourceValue.Field(i), fieldSizeHints, fieldPath); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Array, reflect.Slice:
+		itemType := sourceType.Elem()
+		for itemType.Kind() == reflect.Ptr {
+			itemType = itemType.Elem()
+		}
+
+		isList := sourceType.Kind() == reflect.Slice && (len(sizeHints) == 0 || sizeHints[0].dynamic)
+		start := len(writer.chunks)
+
+		if getBasicTypeSize(itemType) > 0 {
+			// basic items are packed into chunks, vectors are padded to their length by the encoding
+			if err := d.writePackedChunks(writer, sourceType, sourceValue, sizeHints); err != nil {
+				return err
+			}
+		} else {
+			childSizeHints := []sszSizeHint{}
+			if len(sizeHints) > 1 {
+				childSizeHints = sizeHints[1:]
+			}
+
+			itemCount := sourceValue.Len()
+			if !isList && sourceType.Kind() == reflect.Slice {
+				vectorLen := int(sizeHints[0].size)
+				if itemCount > vectorLen {
+					return fmt.Errorf("%w: vector %v expects %v items, got %v", ErrVectorLength, path, vectorLen, itemCount)
+				}
+				itemCount = vectorLen
+			}
+
+			for i := 0; i < itemCount; i++ {
+				itemValue := reflect.Zero(sourceType.Elem())
+				if i < sourceValue.Len() {
+					itemValue = sourceValue.Index(i)
+				}
+				if err := d.writeChunks(writer, sourceType.Elem(), itemValue, childSizeHints, fmt.Sprintf("%v[%v]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+
+		if isList {
+			writer.mixins = append(writer.mixins, ListMixin{
+				Path:   path,
+				Start:  start,
+				Count:  len(writer.chunks) - start,
+				Length: uint64(sourceValue.Len()),
+			})
+		}
+
+	default:
+		return fmt.Errorf("unknown type: %v", sourceType)
+	}
+
+	return nil
+}
+
+// writePackedChunks appends the encoding of a basic value, vector or list of basic values as packed chunks, with the
+// last chunk padded with zero bytes.
+func (d *DynSsz) writePackedChunks(writer *chunkWriter, sourceType reflect.Type, sourceValue reflect.Value, sizeHints []sszSizeHint) error {
+	packed, err := d.marshalType(sourceType, sourceValue, nil, sizeHints, 0)
+	if err != nil {
+		return err
+	}
+
+	for offset := 0; offset < len(packed); offset += 32 {
+		var chunk [32]byte
+		copy(chunk[:], packed[offset:])
+		writer.chunks = append(writer.chunks, chunk)
+	}
+
+	return nil
+}
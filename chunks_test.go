@@ -0,0 +1,74 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_ChunksStruct1 struct {
+	F1 uint64
+	F2 [32]byte
+	F3 []uint16              `ssz-max:"64"`
+	F4 []*slug_ChunksStruct2 `ssz-max:"4"`
+	F5 []slug_ChunksStruct2  `ssz-size:"2"`
+}
+
+type slug_ChunksStruct2 struct {
+	F1 uint32
+	F2 []byte `ssz-size:"40"`
+}
+
+func TestMarshalChunks(t *testing.T) {
+	source := &slug_ChunksStruct1{
+		F1: 0x0102,
+		F2: [32]byte{0xaa},
+		F3: []uint16{1, 2, 3},
+		F4: []*slug_ChunksStruct2{{F1: 7, F2: make([]byte, 40)}, nil},
+		F5: []slug_ChunksStruct2{{F1: 9}},
+	}
+
+	chunks, mixins, err := NewDynSsz(nil).MarshalChunksWithMixins(source)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// F1, F2, F3, F4 (2 items with 3 chunks each), F5 (2 items with 3 chunks each)
+	if len(chunks) != 15 {
+		t.Fatalf("unexpected chunk count: %v, wanted 15", len(chunks))
+	}
+
+	expected := map[int][32]byte{
+		0:  {0x02, 0x01},
+		1:  {0xaa},
+		2:  {1, 0, 2, 0, 3, 0},
+		3:  {7},
+		9:  {9},
+		12: {},
+	}
+	for idx, chunk := range expected {
+		if chunks[idx] != chunk {
+			t.Errorf("unexpected chunk %v: %x, wanted %x", idx, chunks[idx], chunk)
+		}
+	}
+
+	expectedMixins := []ListMixin{
+		{Path: "F3", Start: 2, Count: 1, Length: 3},
+		{Path: "F4", Start: 3, Count: 6, Length: 2},
+	}
+	if !reflect.DeepEqual(mixins, expectedMixins) {
+		t.Errorf("unexpected mixins: %+v, wanted %+v", mixins, expectedMixins)
+	}
+
+	plainChunks, err := NewDynSsz(nil).MarshalChunks(source)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(plainChunks, chunks) {
+		t.Errorf("MarshalChunks differs from MarshalChunksWithMixins")
+	}
+}
@@ -0,0 +1,81 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+
+// Command dynssz-migrate adds dynamic-ssz annotations to Go types that were written for fastssz (sszgen).
+// For each struct field with 'ssz-size' or 'ssz-max' tags, it adds the corresponding 'dynssz-size' and 'dynssz-max'
+// tags with the spec value names from a JSON mapping file:
+//
+//	{
+//	  "fields": {"BeaconState.BlockRoots": {"dynssz-size": "SLOTS_PER_HISTORICAL_ROOT,32"}},
+//	  "sizes": {"8192": "SLOTS_PER_HISTORICAL_ROOT"},
+//	  "limits": {"1099511627776": "VALIDATOR_REGISTRY_LIMIT"}
+//	}
+//
+// Usage:
+//
+//	dynssz-migrate -mapping mapping.json [-w] file.go...
+//
+// The rewritten sources are written to stdout, or back to the files with -w.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	mappingFile := flag.String("mapping", "", "path to the JSON mapping file")
+	write := flag.Bool("w", false, "write the result back to the source files instead of stdout")
+	flag.Parse()
+
+	if *mappingFile == "" || flag.NArg() == 0 {
+		fmt.Fprintf(os.Stderr, "usage: dynssz-migrate -mapping mapping.json [-w] file.go...\n")
+		os.Exit(2)
+	}
+
+	if err := run(*mappingFile, flag.Args(), *write); err != nil {
+		fmt.Fprintf(os.Stderr, "dynssz-migrate: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(mappingFile string, files []string, write bool) error {
+	mappingJson, err := os.ReadFile(mappingFile)
+	if err != nil {
+		return fmt.Errorf("failed reading mapping file: %v", err)
+	}
+
+	mapping := &Mapping{}
+	if err := json.Unmarshal(mappingJson, mapping); err != nil {
+		return fmt.Errorf("failed parsing mapping file: %v", err)
+	}
+
+	for _, filename := range files {
+		src, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+
+		result, changes, err := migrateSource(filename, src, mapping)
+		if err != nil {
+			return err
+		}
+
+		if !write {
+			os.Stdout.Write(result)
+			continue
+		}
+
+		if changes > 0 {
+			if err := os.WriteFile(filename, result, 0o644); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(os.Stderr, "%v: %v fields migrated\n", filename, changes)
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Mapping describes how the static sizes of fastssz tags are translated to spec values.
+type Mapping struct {
+	// Fields holds explicit annotations per field, referenced as "Type.Field" or by field name for all types.
+	// The keys of the inner map are the tag names ("dynssz-size" or "dynssz-max").
+	Fields map[string]map[string]string `json:"fields"`
+
+	// Sizes maps the dimensions of 'ssz-size' tags to spec value names (e.g. "8192": "SLOTS_PER_HISTORICAL_ROOT").
+	Sizes map[string]string `json:"sizes"`
+
+	// Limits maps the dimensions of 'ssz-max' tags to spec value names.
+	Limits map[string]string `json:"limits"`
+}
+
+// migrateTags pairs the fastssz tags with the dynssz tags that are derived from them.
+var migrateTags = []struct {
+	sszTag    string
+	dynSszTag string
+}{
+	{"ssz-size", "dynssz-size"},
+	{"ssz-max", "dynssz-max"},
+}
+
+// migrateSource adds 'dynssz-size' and 'dynssz-max' tags to the struct fields of a Go source file, derived from their
+// 'ssz-size' and 'ssz-max' tags and the given mapping. Fields that already have a dynssz tag are left untouched.
+// Returns the formatted source and the number of changed fields.
+func migrateSource(filename string, src []byte, mapping *Mapping) ([]byte, int, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	changes := 0
+	ast.Inspect(file, func(node ast.Node) bool {
+		typeSpec, ok := node.(*ast.TypeSpec)
+		if !ok {
+			return true
+		}
+		structType, ok := typeSpec.Type.(*ast.StructType)
+		if !ok {
+			return true
+		}
+
+		for _, field := range structType.Fields.List {
+			if field.Tag == nil || len(field.Names) == 0 {
+				continue
+			}
+			tag, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				continue
+			}
+
+			// fields declared together share their tag, so the first name is used for the mapping
+			newTag := migrateFieldTag(typeSpec.Name.Name, field.Names[0].Name, tag, mapping)
+			if newTag != tag {
+				field.Tag.Value = quoteTag(newTag)
+				changes++
+			}
+		}
+		return true
+	})
+
+	buf := bytes.Buffer{}
+	if err := format.Node(&buf, fset, file); err != nil {
+		return nil, 0, err
+	}
+
+	return buf.Bytes(), changes, nil
+}
+
+// migrateFieldTag returns the tag of a struct field with the derived dynssz tags appended.
+func migrateFieldTag(typeName string, fieldName string, tag string, mapping *Mapping) string {
+	structTag := reflect.StructTag(tag)
+
+	for _, migrateTag := range migrateTags {
+		if _, hasDynTag := structTag.Lookup(migrateTag.dynSszTag); hasDynTag {
+			continue
+		}
+
+		dynValue := ""
+		if fieldTags, ok := mapping.Fields[typeName+"."+fieldName]; ok && fieldTags[migrateTag.dynSszTag] != "" {
+			dynValue = fieldTags[migrateTag.dynSszTag]
+		} else if fieldTags, ok := mapping.Fields[fieldName]; ok && fieldTags[migrateTag.dynSszTag] != "" {
+			dynValue = fieldTags[migrateTag.dynSszTag]
+		} else if sszValue, hasSszTag := structTag.Lookup(migrateTag.sszTag); hasSszTag {
+			dimensionMap := mapping.Sizes
+			if migrateTag.sszTag == "ssz-max" {
+				dimensionMap = mapping.Limits
+			}
+			dynValue = mapDimensions(sszValue, dimensionMap)
+		}
+
+		if dynValue != "" {
+			tag = fmt.Sprintf("%v %v:%q", strings.TrimSpace(tag), migrateTag.dynSszTag, dynValue)
+		}
+	}
+
+	return tag
+}
+
+// mapDimensions maps the comma separated dimensions of a fastssz tag to spec value names. Returns an empty string if
+// none of the dimensions is mapped, as the tag would not differ from the fastssz tag then.
+func mapDimensions(sszValue string, dimensionMap map[string]string) string {
+	dimensions := strings.Split(sszValue, ",")
+	mapped := false
+	for i, dimension := range dimensions {
+		if specName, ok := dimensionMap[dimension]; ok {
+			dimensions[i] = specName
+			mapped = true
+		}
+	}
+
+	if !mapped {
+		return ""
+	}
+	return strings.Join(dimensions, ",")
+}
+
+// quoteTag formats a struct tag as raw string literal, falling back to an interpreted string literal for tags that
+// contain backquotes.
+func quoteTag(tag string) string {
+	if strings.Contains(tag, "`") {
+		return strconv.Quote(tag)
+	}
+	return "`" + tag + "`"
+}
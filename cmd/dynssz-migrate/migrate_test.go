@@ -0,0 +1,58 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package main
+
+import (
+	"testing"
+)
+
+func TestMigrateSource(t *testing.T) {
+	mapping := &Mapping{
+		Fields: map[string]map[string]string{
+			"BeaconState.BlockRoots": {"dynssz-size": "SLOTS_PER_HISTORICAL_ROOT,32"},
+		},
+		Sizes: map[string]string{
+			"8192": "SLOTS_PER_HISTORICAL_ROOT",
+			"512":  "SYNC_COMMITTEE_SIZE",
+		},
+		Limits: map[string]string{
+			"1099511627776": "VALIDATOR_REGISTRY_LIMIT",
+		},
+	}
+
+	src := "package test\n\n" +
+		"type BeaconState struct {\n" +
+		"\tBlockRoots [][]byte `ssz-size:\"8192,32\"`\n" +
+		"\tStateRoots [][]byte `ssz-size:\"8192,32\"`\n" +
+		"\tBalances []uint64 `json:\"balances\" ssz-max:\"1099511627776\"`\n" +
+		"\tPubkeys [][]byte `ssz-size:\"512,48\" dynssz-size:\"SYNC_SIZE,48\"`\n" +
+		"\tRoot []byte `ssz-size:\"32\"`\n" +
+		"\tSlot uint64\n" +
+		"}\n"
+
+	expected := "package test\n\n" +
+		"type BeaconState struct {\n" +
+		"\tBlockRoots [][]byte `ssz-size:\"8192,32\" dynssz-size:\"SLOTS_PER_HISTORICAL_ROOT,32\"`\n" +
+		"\tStateRoots [][]byte `ssz-size:\"8192,32\" dynssz-size:\"SLOTS_PER_HISTORICAL_ROOT,32\"`\n" +
+		"\tBalances   []uint64 `json:\"balances\" ssz-max:\"1099511627776\" dynssz-max:\"VALIDATOR_REGISTRY_LIMIT\"`\n" +
+		"\tPubkeys    [][]byte `ssz-size:\"512,48\" dynssz-size:\"SYNC_SIZE,48\"`\n" +
+		"\tRoot       []byte   `ssz-size:\"32\"`\n" +
+		"\tSlot       uint64\n" +
+		"}\n"
+
+	result, changes, err := migrateSource("test.go", []byte(src), mapping)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if changes != 3 {
+		t.Errorf("unexpected number of changes: %v", changes)
+	}
+	if string(result) != expected {
+		t.Errorf("unexpected result:\n%v", string(result))
+	}
+
+	if _, _, err := migrateSource("test.go", []byte("package test\ntype X struct {"), mapping); err == nil {
+		t.Errorf("expected error for invalid source")
+	}
+}
@@ -0,0 +1,200 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// Codec is a typed SSZ encoder/decoder for a single type T.
+// The size and fastssz compatibility of T are resolved once when creating the codec, so services that handle one or
+// two types heavily avoid the per call type lookups and interface conversions of the generic DynSsz methods.
+// A Codec is safe for concurrent use.
+type Codec[T any] struct {
+	dynssz        *DynSsz
+	sszType       reflect.Type
+	staticSize    int
+	fastsszCompat *fastsszCompatibility
+}
+
+// NewCodec creates a new Codec for type T using the spec values and settings of the given DynSsz instance.
+// Returns an error if the size of T can not be resolved, e.g. because of invalid size annotations.
+func NewCodec[T any](ds *DynSsz) (*Codec[T], error) {
+	sszType := reflect.TypeOf((*T)(nil)).Elem()
+
+	staticSize, _, err := ds.getSszSize(sszType, []sszSizeHint{})
+	if err != nil {
+		return nil, fmt.Errorf("failed resolving size of type %v: %v", sszType, err)
+	}
+
+	fastsszCompat, err := ds.getFastsszCompatibility(sszType, []sszSizeHint{})
+	if err != nil {
+		return nil, fmt.Errorf("failed checking fastssz compatibility: %v", err)
+	}
+
+	return &Codec[T]{
+		dynssz:        ds,
+		sszType:       sszType,
+		staticSize:    staticSize,
+		fastsszCompat: fastsszCompat,
+	}, nil
+}
+
+// Size returns the SSZ encoded size of the given value.
+func (c *Codec[T]) Size(source *T) (int, error) {
+	if c.staticSize >= 0 {
+		return c.staticSize, nil
+	}
+
+	return c.dynssz.getSszValueSize(reflect.PointerTo(c.sszType), reflect.ValueOf(source), []sszSizeHint{})
+}
+
+// Marshal serializes the given value into its SSZ representation.
+func (c *Codec[T]) Marshal(source *T) ([]byte, error) {
+	if err := c.dynssz.runMarshalHooks(reflect.PointerTo(c.sszType), reflect.ValueOf(source)); err != nil {
+		return nil, err
+	}
+
+	size, err := c.Size(source)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.marshalTo(source, make([]byte, 0, size))
+}
+
+// MarshalTo serializes the given value into its SSZ representation and appends it to buf.
+// Returns the updated buffer containing the serialized data.
+func (c *Codec[T]) MarshalTo(source *T, buf []byte) ([]byte, error) {
+	if err := c.dynssz.runMarshalHooks(reflect.PointerTo(c.sszType), reflect.ValueOf(source)); err != nil {
+		return nil, err
+	}
+
+	return c.marshalTo(source, buf)
+}
+
+// marshalTo serializes the given value and appends it to buf, after the marshal hooks have been called.
+func (c *Codec[T]) marshalTo(source *T, buf []byte) ([]byte, error) {
+	sourceType := reflect.PointerTo(c.sszType)
+	sourceValue := reflect.ValueOf(source)
+
+	newBuf, err := c.dynssz.marshalType(sourceType, sourceValue, buf, []sszSizeHint{}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.dynssz.DetectConcurrentModification {
+		if err := c.dynssz.checkUnmodified(sourceType, sourceValue, sha256.Sum256(newBuf[len(buf):])); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.dynssz.VerifyRoundTrip {
+		if err := c.dynssz.verifyMarshalRoundTrip(sourceType, newBuf[len(buf):]); err != nil {
+			return nil, err
+		}
+	}
+
+	return newBuf, nil
+}
+
+// Unmarshal decodes the given SSZ-encoded data into the target value.
+// Returns an error if decoding fails or if the data has not been fully used for decoding.
+func (c *Codec[T]) Unmarshal(target *T, ssz []byte) error {
+	if c.staticSize >= 0 && len(ssz) != c.staticSize {
+		return fmt.Errorf("invalid ssz size for type %v (expected: %v, got: %v)", c.sszType, c.staticSize, len(ssz))
+	}
+
+	return c.dynssz.UnmarshalSSZ(target, ssz)
+}
+
+// Read reads SSZ-encoded data from r and decodes it into the target value.
+// Static size types read exactly the encoded size from r, dynamic types read until EOF.
+// Returns the number of bytes read from r.
+func (c *Codec[T]) Read(r io.Reader, target *T) (int64, error) {
+	var ssz []byte
+	if c.staticSize >= 0 {
+		ssz = make([]byte, c.staticSize)
+		n, err := io.ReadFull(r, ssz)
+		if err != nil {
+			return int64(n), fmt.Errorf("failed reading ssz data: %v", err)
+		}
+	} else {
+		var err error
+		ssz, err = io.ReadAll(r)
+		if err != nil {
+			return int64(len(ssz)), fmt.Errorf("failed reading ssz data: %v", err)
+		}
+	}
+
+	return int64(len(ssz)), c.Unmarshal(target, ssz)
+}
+
+// Write serializes the given value and streams its SSZ representation to w.
+// Returns the number of bytes written to w.
+func (c *Codec[T]) Write(w io.Writer, source *T) (int64, error) {
+	counter := &codecWriteCounter{writer: w}
+	err := c.dynssz.MarshalSSZWriter(source, counter)
+	return counter.count, err
+}
+
+// Root calculates the hash tree root of the given value.
+// dynssz does not implement merkleization itself, so this requires T to implement the fastssz HashRoot interface
+// and to be unaffected by the spec values of the DynSsz instance.
+// Returns an error if the hash tree root can not be calculated with the static fastssz code.
+func (c *Codec[T]) Root(source *T) ([32]byte, error) {
+	if c.dynssz.NoFastSsz || !c.fastsszCompat.isHashRoot {
+		return [32]byte{}, fmt.Errorf("type %v does not support hash tree root calculation via fastssz", c.sszType)
+	}
+	if c.fastsszCompat.hasDynamicSpecValues {
+		return [32]byte{}, fmt.Errorf("type %v is affected by dynamic spec values, fastssz hash tree root would be invalid", c.sszType)
+	}
+
+	return any(source).(fastsszHashRoot).HashTreeRoot()
+}
+
+// Bind returns a CodecValue that binds the given value to the codec.
+func (c *Codec[T]) Bind(value *T) *CodecValue[T] {
+	return &CodecValue[T]{
+		codec: c,
+		Value: value,
+	}
+}
+
+// CodecValue binds a value to a Codec and implements the io.ReaderFrom and io.WriterTo interfaces, so the value can be
+// used directly with io.Copy and other standard library helpers.
+type CodecValue[T any] struct {
+	codec *Codec[T]
+	Value *T
+}
+
+var _ io.ReaderFrom = (*CodecValue[struct{}])(nil)
+var _ io.WriterTo = (*CodecValue[struct{}])(nil)
+
+// ReadFrom reads SSZ-encoded data from r and decodes it into the bound value.
+// Returns the number of bytes read from r.
+func (v *CodecValue[T]) ReadFrom(r io.Reader) (int64, error) {
+	return v.codec.Read(r, v.Value)
+}
+
+// WriteTo streams the SSZ representation of the bound value to w.
+// Returns the number of bytes written to w.
+func (v *CodecValue[T]) WriteTo(w io.Writer) (int64, error) {
+	return v.codec.Write(w, v.Value)
+}
+
+// codecWriteCounter counts the bytes written to the underlying writer.
+type codecWriteCounter struct {
+	writer io.Writer
+	count  int64
+}
+
+func (w *codecWriteCounter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.count += int64(n)
+	return n, err
+}
@@ -0,0 +1,83 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_CodecStruct1 struct {
+	F1 uint32
+	F2 []uint8 `ssz-size:"?" dynssz-size:"?"`
+}
+
+type slug_CodecStruct2 struct {
+	F1 uint16
+	F2 []uint8 `ssz-size:"2" dynssz-size:"SPEC_A"`
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	dynssz := NewDynSsz(map[string]any{"SPEC_A": uint64(3)})
+
+	codec1, err := NewCodec[slug_CodecStruct1](dynssz)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	codec2, err := NewCodec[slug_CodecStruct2](dynssz)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	payload1 := &slug_CodecStruct1{F1: 1, F2: []uint8{2, 3}}
+	testCodecRoundTrip(t, codec1, payload1, fromHex("0x01000000080000000203"))
+
+	payload2 := &slug_CodecStruct2{F1: 1, F2: []uint8{2, 3, 4}}
+	testCodecRoundTrip(t, codec2, payload2, fromHex("0x0100020304"))
+}
+
+func testCodecRoundTrip[T any](t *testing.T, codec *Codec[T], payload *T, expected []byte) {
+	buf, err := codec.Marshal(payload)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	if !bytes.Equal(buf, expected) {
+		t.Errorf("unexpected encoding: 0x%x, wanted 0x%x", buf, expected)
+	}
+
+	writer := &bytes.Buffer{}
+	written, err := codec.Bind(payload).WriteTo(writer)
+	if err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	if written != int64(len(expected)) || !bytes.Equal(writer.Bytes(), expected) {
+		t.Errorf("unexpected streamed encoding: 0x%x (%v bytes), wanted 0x%x", writer.Bytes(), written, expected)
+	}
+
+	decoded := new(T)
+	read, err := codec.Bind(decoded).ReadFrom(bytes.NewReader(expected))
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	if read != int64(len(expected)) {
+		t.Errorf("unexpected read size: %v", read)
+	}
+	if !reflect.DeepEqual(decoded, payload) {
+		t.Errorf("unexpected decoded value: %+v, wanted %+v", decoded, payload)
+	}
+}
+
+func TestCodecRootUnsupported(t *testing.T) {
+	codec, err := NewCodec[slug_CodecStruct1](NewDynSsz(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := codec.Root(&slug_CodecStruct1{}); err == nil {
+		t.Errorf("expected error for type without HashTreeRoot")
+	}
+}
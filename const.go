@@ -7,4 +7,13 @@ import (
 	"reflect"
 )
 
-var byteType = reflect.TypeOf(byte(0))
+// isByteType checks if the given type is a byte type, including named types with uint8 as underlying type.
+func isByteType(targetType reflect.Type) bool {
+	return targetType.Kind() == reflect.Uint8
+}
+
+// isByteArrayType checks if the given type is a fixed size byte array, like [32]byte or named types based on it
+// (e.g. phase0.Root or bellatrix.ExecutionAddress).
+func isByteArrayType(targetType reflect.Type) bool {
+	return targetType.Kind() == reflect.Array && isByteType(targetType.Elem())
+}
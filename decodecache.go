@@ -0,0 +1,195 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"reflect"
+	"sync"
+)
+
+// decodeCache is a LRU cache of decoded values, keyed by the target type and the sha256 hash of the SSZ data.
+type decodeCache struct {
+	mutex      sync.Mutex
+	maxEntries int
+	entries    map[decodeCacheKey]*list.Element
+	lru        *list.List
+	cacheable  map[reflect.Type]bool
+}
+
+type decodeCacheKey struct {
+	targetType reflect.Type
+	hash       [32]byte
+}
+
+type decodeCacheEntry struct {
+	key   decodeCacheKey
+	value reflect.Value
+}
+
+// EnableDecodeCache enables a decode cache that holds up to 'maxEntries' decoded values.
+// When the exact same SSZ data is decoded repeatedly into the same type (e.g. when multiple pipeline stages decode the
+// same block), UnmarshalSSZ serves the result from the cache instead of decoding the data again. The target object
+// always receives a deep copy of the cached value, so cached values are never shared with or modified by the caller.
+// A cache hit replaces the whole target value with that copy, so unlike regular decoding it does not reuse the memory
+// of the target, and unexported fields of the target are reset to their state when the value was cached.
+// UnmarshalHook implementations run after each decode and cache hit, the cache holds the values before the hooks ran.
+// Types that can not be deep copied are never cached: types with map, chan, func or interface values, and types with
+// unexported fields holding pointers or slices, as these can not be copied via reflection.
+// Cache lookups are keyed by the sha256 hash of the SSZ data, so the cache only pays off for larger or complex types.
+// Calling EnableDecodeCache with a 'maxEntries' value of 0 disables and drops the cache.
+func (d *DynSsz) EnableDecodeCache(maxEntries int) {
+	if maxEntries <= 0 {
+		d.decodeCache = nil
+		return
+	}
+
+	d.decodeCache = &decodeCache{
+		maxEntries: maxEntries,
+		entries:    map[decodeCacheKey]*list.Element{},
+		lru:        list.New(),
+		cacheable:  map[reflect.Type]bool{},
+	}
+}
+
+// load copies the cached value for the given key into the target value.
+// Returns false if there is no cached value for the key.
+func (c *decodeCache) load(key decodeCacheKey, targetValue reflect.Value) bool {
+	c.mutex.Lock()
+	element, ok := c.entries[key]
+	if ok {
+		c.lru.MoveToFront(element)
+	}
+	c.mutex.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	targetValue.Set(cloneValue(element.Value.(*decodeCacheEntry).value))
+	return true
+}
+
+// store adds a copy of the decoded value to the cache and evicts the least recently used entries if the cache is full.
+func (c *decodeCache) store(key decodeCacheKey, sourceValue reflect.Value) {
+	value := cloneValue(sourceValue)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&decodeCacheEntry{
+		key:   key,
+		value: value,
+	})
+
+	for c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*decodeCacheEntry).key)
+	}
+}
+
+// isCacheable checks if values of the given type can be deep copied by cloneValue.
+func (c *decodeCache) isCacheable(targetType reflect.Type) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	cacheable, ok := c.cacheable[targetType]
+	if !ok {
+		cacheable = isCloneableType(targetType, true, map[reflect.Type]bool{})
+		c.cacheable[targetType] = cacheable
+	}
+	return cacheable
+}
+
+// isCloneableType checks if cloneValue returns a deep copy for values of the given type. Values in unexported fields
+// ('exported' false) can only be copied as a whole, so they must not hold pointers or slices.
+func isCloneableType(targetType reflect.Type, exported bool, visited map[reflect.Type]bool) bool {
+	if visited[targetType] {
+		return true
+	}
+	visited[targetType] = true
+	defer delete(visited, targetType)
+
+	switch targetType.Kind() {
+	case reflect.Ptr, reflect.Slice:
+		return exported && isCloneableType(targetType.Elem(), exported, visited)
+	case reflect.Array:
+		return isCloneableType(targetType.Elem(), exported, visited)
+	case reflect.Struct:
+		for i := 0; i < targetType.NumField(); i++ {
+			field := targetType.Field(i)
+			if !isCloneableType(field.Type, exported && field.IsExported(), visited) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map, reflect.Chan, reflect.Func, reflect.Interface, reflect.UnsafePointer:
+		return false
+	default:
+		return true
+	}
+}
+
+// getDecodeCacheKey returns the decode cache key for the given target type and SSZ data.
+func getDecodeCacheKey(targetType reflect.Type, ssz []byte) decodeCacheKey {
+	return decodeCacheKey{
+		targetType: targetType,
+		hash:       sha256.Sum256(ssz),
+	}
+}
+
+// cloneValue returns a deep copy of the given value, so that the copy does not share any pointers or slices with it.
+func cloneValue(sourceValue reflect.Value) reflect.Value {
+	sourceType := sourceValue.Type()
+
+	switch sourceType.Kind() {
+	case reflect.Ptr:
+		if sourceValue.IsNil() {
+			return reflect.Zero(sourceType)
+		}
+		newValue := reflect.New(sourceType.Elem())
+		newValue.Elem().Set(cloneValue(sourceValue.Elem()))
+		return newValue
+	case reflect.Slice:
+		if sourceValue.IsNil() {
+			return reflect.Zero(sourceType)
+		}
+		newValue := reflect.MakeSlice(sourceType, sourceValue.Len(), sourceValue.Len())
+		if isByteType(sourceType.Elem()) {
+			reflect.Copy(newValue, sourceValue)
+		} else {
+			for i := 0; i < sourceValue.Len(); i++ {
+				newValue.Index(i).Set(cloneValue(sourceValue.Index(i)))
+			}
+		}
+		return newValue
+	case reflect.Array:
+		newValue := reflect.New(sourceType).Elem()
+		newValue.Set(sourceValue)
+		if !isByteType(sourceType.Elem()) {
+			for i := 0; i < sourceValue.Len(); i++ {
+				newValue.Index(i).Set(cloneValue(sourceValue.Index(i)))
+			}
+		}
+		return newValue
+	case reflect.Struct:
+		newValue := reflect.New(sourceType).Elem()
+		newValue.Set(sourceValue)
+		for i := 0; i < sourceType.NumField(); i++ {
+			if field := newValue.Field(i); field.CanSet() {
+				field.Set(cloneValue(sourceValue.Field(i)))
+			}
+		}
+		return newValue
+	default:
+		return sourceValue
+	}
+}
@@ -0,0 +1,145 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_DecodeCacheStruct struct {
+	F1 uint16
+	F2 []uint8     `ssz-size:"?" dynssz-size:"?"`
+	F3 []*[2]uint8 `ssz-size:"?" dynssz-size:"?"`
+}
+
+func TestDecodeCache(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+	dynssz.EnableDecodeCache(1)
+
+	payload := &slug_DecodeCacheStruct{F1: 1, F2: []uint8{2, 3}, F3: []*[2]uint8{{4, 5}}}
+	ssz, err := dynssz.MarshalSSZ(payload)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+
+	obj1 := &slug_DecodeCacheStruct{}
+	if err := dynssz.UnmarshalSSZ(obj1, ssz); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+
+	// modifying the decoded object must not affect the cached value
+	obj1.F2[0] = 0xff
+	obj1.F3[0][0] = 0xff
+
+	obj2 := &slug_DecodeCacheStruct{}
+	if err := dynssz.UnmarshalSSZ(obj2, ssz); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+	if !reflect.DeepEqual(obj2, payload) {
+		t.Errorf("unexpected cached result: %+v, wanted %+v", obj2, payload)
+	}
+
+	// different data for the same type must evict the previous entry and decode correctly
+	payload2 := &slug_DecodeCacheStruct{F1: 2, F2: []uint8{}, F3: []*[2]uint8{}}
+	ssz2, err := dynssz.MarshalSSZ(payload2)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	obj3 := &slug_DecodeCacheStruct{}
+	if err := dynssz.UnmarshalSSZ(obj3, ssz2); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+	if !reflect.DeepEqual(obj3, payload2) {
+		t.Errorf("unexpected result: %+v, wanted %+v", obj3, payload2)
+	}
+
+	// invalid data must not be cached
+	if err := dynssz.UnmarshalSSZ(&slug_DecodeCacheStruct{}, ssz[:3]); err == nil {
+		t.Errorf("expected error for truncated data")
+	}
+}
+
+type slug_DecodeCacheHookStruct struct {
+	F1 uint16
+	F2 uint16
+}
+
+func (s *slug_DecodeCacheHookStruct) AfterSSZUnmarshal() error {
+	s.F2++
+	return nil
+}
+
+// slug_DecodeCacheUnexportedStruct is decoded via its fastssz methods, which fill the unexported slice.
+type slug_DecodeCacheUnexportedStruct struct {
+	F1   uint16
+	refs []uint16
+}
+
+func (s *slug_DecodeCacheUnexportedStruct) MarshalSSZ() ([]byte, error) {
+	return s.MarshalSSZTo(nil)
+}
+
+func (s *slug_DecodeCacheUnexportedStruct) MarshalSSZTo(dst []byte) ([]byte, error) {
+	return binary.LittleEndian.AppendUint16(dst, s.F1), nil
+}
+
+func (s *slug_DecodeCacheUnexportedStruct) SizeSSZ() int {
+	return 2
+}
+
+func (s *slug_DecodeCacheUnexportedStruct) UnmarshalSSZ(buf []byte) error {
+	if len(buf) != 2 {
+		return fmt.Errorf("invalid size %v", len(buf))
+	}
+	s.F1 = binary.LittleEndian.Uint16(buf)
+	s.refs = []uint16{s.F1}
+	return nil
+}
+
+func TestDecodeCacheHooks(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+	dynssz.EnableDecodeCache(1)
+
+	ssz, err := dynssz.MarshalSSZ(&slug_DecodeCacheHookStruct{F1: 1, F2: 0})
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+
+	// the hooks must run exactly once per decode, also for cache hits
+	for i := 0; i < 3; i++ {
+		obj := &slug_DecodeCacheHookStruct{}
+		if err := dynssz.UnmarshalSSZ(obj, ssz); err != nil {
+			t.Fatalf("unexpected unmarshal error: %v", err)
+		}
+		if obj.F2 != 1 {
+			t.Errorf("decode %v: expected hook to run once, got F2 = %v", i, obj.F2)
+		}
+	}
+}
+
+func TestDecodeCacheUnexportedFields(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+	dynssz.EnableDecodeCache(1)
+
+	ssz := []byte{1, 0}
+	obj1 := &slug_DecodeCacheUnexportedStruct{}
+	if err := dynssz.UnmarshalSSZ(obj1, ssz); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+	obj1.refs[0] = 0xff
+
+	// types with unexported slices can not be deep copied, so they must not be cached and shared with obj1
+	obj2 := &slug_DecodeCacheUnexportedStruct{}
+	if err := dynssz.UnmarshalSSZ(obj2, ssz); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+	if !reflect.DeepEqual(obj2, &slug_DecodeCacheUnexportedStruct{F1: 1, refs: []uint16{1}}) {
+		t.Errorf("unexpected result: %+v", obj2)
+	}
+}
@@ -0,0 +1,181 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// DecodeSession decodes SSZ data that arrives in chunks, e.g. a large BeaconState that is synced over an unreliable
+// connection. The session keeps the received data and the fields decoded so far, so an interrupted transfer can be
+// continued at Consumed (e.g. with an HTTP range request) instead of starting over.
+// For struct targets, each top-level field is decoded as soon as its data has been received completely, so early
+// fields are available before the transfer finishes. Other targets are decoded once all data has been received.
+// A DecodeSession is not safe for concurrent use.
+type DecodeSession struct {
+	dynssz  *DynSsz
+	target  reflect.Value
+	size    int
+	ssz     []byte
+	decoded map[int]bool
+	guard   *decodeGuard
+	done    bool
+}
+
+// BeginDecode starts a decode session for 'size' bytes of SSZ data into the target, which must be a pointer.
+// The data is passed to the session with Feed, and decoded with Resume. The buffer for the data and the fields decoded
+// by all Resume calls are accounted on a single decode guard, so the DecodeGuards limits apply to the whole session.
+func (d *DynSsz) BeginDecode(target any, size int) (*DecodeSession, error) {
+	targetValue := reflect.ValueOf(target)
+	if targetValue.Kind() != reflect.Ptr || targetValue.IsNil() {
+		return nil, fmt.Errorf("decode target must be a non-nil pointer, got %v", reflect.TypeOf(target))
+	}
+	if size < 0 {
+		return nil, fmt.Errorf("invalid ssz size %v", size)
+	}
+
+	guard := d.newDecodeGuard()
+	if err := guard.alloc(targetValue.Type(), uint64(size)); err != nil {
+		return nil, err
+	}
+
+	return &DecodeSession{
+		dynssz:  d,
+		target:  targetValue,
+		size:    size,
+		ssz:     make([]byte, 0, size),
+		decoded: map[int]bool{},
+		guard:   guard,
+	}, nil
+}
+
+// Feed appends the next chunk of the SSZ data to the session. Returns an error if the chunk exceeds the size of the
+// session.
+func (s *DecodeSession) Feed(chunk []byte) error {
+	if len(chunk) > s.size-s.Consumed() {
+		return fmt.Errorf("chunk exceeds ssz size (received: %v, chunk: %v, ssz size: %v)", len(s.ssz), len(chunk), s.size)
+	}
+
+	s.ssz = append(s.ssz, chunk...)
+	return nil
+}
+
+// Consumed returns the number of bytes passed to Feed so far, which is the position to continue an interrupted
+// transfer at.
+func (s *DecodeSession) Consumed() int {
+	if s.done {
+		return s.size
+	}
+	return len(s.ssz)
+}
+
+// Resume decodes the data received so far. For struct targets, all top-level fields whose data has been received
+// completely are decoded, fields that have been decoded by a previous call are skipped.
+// Returns true once the target has been decoded completely, in which case UnmarshalHook implementations have been
+// called and ValidateAfterDecode has been applied, or an error if decoding fails.
+func (s *DecodeSession) Resume() (bool, error) {
+	if s.done {
+		return true, nil
+	}
+
+	targetType := s.target.Type().Elem()
+	if targetType.Kind() != reflect.Struct {
+		if len(s.ssz) < s.size {
+			return false, nil
+		}
+		if err := s.dynssz.UnmarshalSSZ(s.target.Interface(), s.ssz); err != nil {
+			return false, err
+		}
+		s.finish()
+		return true, nil
+	}
+
+	fieldEnds, err := s.getFieldEnds(targetType)
+	if err != nil || fieldEnds == nil {
+		return false, err
+	}
+
+	selected := map[int]bool{}
+	for i, fieldEnd := range fieldEnds {
+		if !s.decoded[i] && fieldEnd <= len(s.ssz) {
+			selected[i] = true
+		}
+	}
+
+	// the offsets of all dynamic fields are checked on each call, so malformed offsets fail early
+	readRange := func(start int, end int) ([]byte, error) {
+		return s.ssz[start:end], nil
+	}
+	err = s.dynssz.unmarshalStructFields(targetType, s.target.Elem(), s.size, readRange, func(field *reflect.StructField) bool {
+		return selected[field.Index[0]]
+	}, s.guard)
+	if err != nil {
+		return false, err
+	}
+
+	for i := range selected {
+		s.decoded[i] = true
+	}
+
+	if len(s.decoded) < len(fieldEnds) {
+		return false, nil
+	}
+
+	if err := s.dynssz.runUnmarshalHooks(s.target.Type(), s.target); err != nil {
+		return false, err
+	}
+	if s.dynssz.ValidateAfterDecode {
+		if err := s.dynssz.ValidateSSZ(s.target.Interface()); err != nil {
+			return false, err
+		}
+	}
+
+	s.finish()
+	return true, nil
+}
+
+// getFieldEnds returns the end positions of the data of each field of the struct target, or nil if the fixed part of
+// the container has not been received yet.
+func (s *DecodeSession) getFieldEnds(targetType reflect.Type) ([]int, error) {
+	fieldEnds := make([]int, targetType.NumField())
+	dynamicFields := []int{}
+	position := 0
+
+	for i := range fieldEnds {
+		field := s.dynssz.getStructField(targetType, i)
+		fieldSize, _, _, err := s.dynssz.getSszFieldSize(&field)
+		if err != nil {
+			return nil, err
+		}
+
+		if fieldSize < 0 {
+			dynamicFields = append(dynamicFields, i)
+			fieldSize = 4
+		}
+		position += fieldSize
+		fieldEnds[i] = position
+	}
+
+	if position > len(s.ssz) {
+		return nil, nil
+	}
+
+	// the data of a dynamic field ends at the offset of the next dynamic field
+	for i, fieldIdx := range dynamicFields {
+		fieldEnds[fieldIdx] = s.size
+		if i < len(dynamicFields)-1 {
+			nextField := dynamicFields[i+1]
+			fieldEnds[fieldIdx] = readOffsetInt(s.ssz[fieldEnds[nextField]-4 : fieldEnds[nextField]])
+		}
+	}
+
+	return fieldEnds, nil
+}
+
+// finish marks the session as done and releases the received data.
+func (s *DecodeSession) finish() {
+	s.done = true
+	s.ssz = nil
+}
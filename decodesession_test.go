@@ -0,0 +1,157 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_SessionStruct1 struct {
+	F1 uint64
+	F2 []uint32               `ssz-max:"64"`
+	F3 []*slug_SessionStruct2 `ssz-max:"8"`
+}
+
+type slug_SessionStruct2 struct {
+	F1 uint16
+	F2 []uint8 `ssz-max:"16"`
+}
+
+func TestDecodeSession(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+	source := &slug_SessionStruct1{
+		F1: 42,
+		F2: []uint32{1, 2, 3, 4},
+		F3: []*slug_SessionStruct2{{F1: 5, F2: []uint8{6, 7}}, {F1: 8, F2: []uint8{}}},
+	}
+	ssz, err := dynssz.MarshalSSZ(source)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	target := &slug_SessionStruct1{}
+	session, err := dynssz.BeginDecode(target, len(ssz))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// feed the data in small chunks, with an interruption after the first part of F2
+	interruption := 24
+	for session.Consumed() < interruption {
+		end := session.Consumed() + 5
+		if end > interruption {
+			end = interruption
+		}
+		if err := session.Feed(ssz[session.Consumed():end]); err != nil {
+			t.Fatalf("unexpected feed error: %v", err)
+		}
+		if done, err := session.Resume(); err != nil || done {
+			t.Fatalf("unexpected resume result: %v, %v", done, err)
+		}
+	}
+
+	// static fields are decoded as soon as the fixed part has been received
+	if target.F1 != 42 || target.F2 != nil {
+		t.Errorf("unexpected partial decode: %+v", target)
+	}
+
+	// continue at the consumed position
+	if err := session.Feed(ssz[session.Consumed():]); err != nil {
+		t.Fatalf("unexpected feed error: %v", err)
+	}
+	done, err := session.Resume()
+	if err != nil || !done {
+		t.Fatalf("unexpected resume result: %v, %v", done, err)
+	}
+	if !reflect.DeepEqual(target, source) {
+		t.Errorf("unexpected decoded object: %+v", target)
+	}
+	if session.Consumed() != len(ssz) {
+		t.Errorf("unexpected consumed bytes: %v", session.Consumed())
+	}
+	if err := session.Feed([]byte{0}); err == nil {
+		t.Errorf("expected error for data beyond the ssz size")
+	}
+}
+
+func TestDecodeSessionErrors(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+
+	// offset of F3 beyond the ssz size
+	ssz := fromHex("0x2a000000000000001000000000ff000001000000")
+	session, err := dynssz.BeginDecode(&slug_SessionStruct1{}, len(ssz))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := session.Feed(ssz[:16]); err != nil {
+		t.Fatalf("unexpected feed error: %v", err)
+	}
+	if _, err := session.Resume(); err == nil {
+		t.Errorf("expected error for offset beyond the ssz size")
+	}
+
+	if _, err := dynssz.BeginDecode(slug_SessionStruct1{}, 0); err == nil {
+		t.Errorf("expected error for non-pointer target")
+	}
+
+	values := []uint16{}
+	session, err = dynssz.BeginDecode(&values, 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := session.Feed([]byte{1, 0}); err != nil {
+		t.Fatalf("unexpected feed error: %v", err)
+	}
+	if done, err := session.Resume(); done || err != nil {
+		t.Fatalf("unexpected resume result: %v, %v", done, err)
+	}
+	if err := session.Feed([]byte{2, 0}); err != nil {
+		t.Fatalf("unexpected feed error: %v", err)
+	}
+	if done, err := session.Resume(); !done || err != nil || !reflect.DeepEqual(values, []uint16{1, 2}) {
+		t.Errorf("unexpected resume result: %v, %v, %v", done, err, values)
+	}
+}
+
+func TestDecodeSessionGuards(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+	dynssz.DecodeGuards = DecodeGuards{MaxTotalAlloc: 64}
+
+	if _, err := dynssz.BeginDecode(&slug_SessionStruct1{}, 1<<30); !errors.Is(err, ErrDecodeGuard) {
+		t.Errorf("expected ErrDecodeGuard for size above MaxTotalAlloc, got %v", err)
+	}
+
+	source := &slug_SessionStruct1{
+		F1: 42,
+		F2: []uint32{1, 2, 3, 4},
+		F3: []*slug_SessionStruct2{{F1: 5, F2: []uint8{6, 7}}, {F1: 8, F2: []uint8{}}},
+	}
+	ssz, err := dynssz.MarshalSSZ(source)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the buffer and the fields decoded by all Resume calls count towards the same limit
+	dynssz.DecodeGuards = DecodeGuards{MaxTotalAlloc: uint64(len(ssz)) + 16}
+	session, err := dynssz.BeginDecode(&slug_SessionStruct1{}, len(ssz))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := session.Feed(ssz[:32]); err != nil {
+		t.Fatalf("unexpected feed error: %v", err)
+	}
+	if done, err := session.Resume(); done || err != nil {
+		t.Fatalf("unexpected resume result: %v, %v", done, err)
+	}
+	if err := session.Feed(ssz[32:]); err != nil {
+		t.Fatalf("unexpected feed error: %v", err)
+	}
+	if _, err := session.Resume(); !errors.Is(err, ErrDecodeGuard) {
+		t.Errorf("expected ErrDecodeGuard for the session total above MaxTotalAlloc, got %v", err)
+	}
+}
@@ -0,0 +1,266 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"reflect"
+
+	"github.com/pk910/dynamic-ssz/sszutils"
+)
+
+// SSZChunk is a content-addressed piece of an SSZ encoding, as produced by SplitSSZChunks.
+type SSZChunk struct {
+	Hash [32]byte // sha256 hash of Data
+	Data []byte
+}
+
+// SplitSSZChunks splits SSZ-encoded data of the given type into content-addressed chunks of about 'chunkSize' bytes.
+// This is an experimental helper for storage backends that want to deduplicate the data shared between similar
+// objects, like consecutive BeaconStates, by storing each distinct chunk only once.
+// Chunks are cut along field, offset and list item boundaries instead of fixed byte positions, and every container
+// field or list larger than 'chunkSize' starts a new chunk. So a change to one field or list item only affects the
+// chunks covering it, while the chunks of unchanged data keep their hashes even if preceding dynamic fields changed
+// their length. Single values larger than 'chunkSize' that can not be split further result in larger chunks.
+// The 'targetType' parameter accepts either an instance or a reflect.Type value of the type the SSZ data represents.
+// The chunk data references the 'ssz' buffer, which must not be modified while the chunks are in use.
+// Returns the chunks in order, concatenating their data results in the original encoding (see JoinSSZChunks).
+func (d *DynSsz) SplitSSZChunks(targetType any, ssz []byte, chunkSize int) ([]SSZChunk, error) {
+	sszType, ok := targetType.(reflect.Type)
+	if !ok {
+		sszType = reflect.TypeOf(targetType)
+	}
+	if chunkSize <= 0 {
+		return nil, fmt.Errorf("invalid chunk size %v", chunkSize)
+	}
+
+	splitter := &sszChunkSplitter{
+		dynssz:    d,
+		ssz:       ssz,
+		chunkSize: chunkSize,
+	}
+	if err := splitter.splitType(sszType, []sszSizeHint{}, 0, len(ssz)); err != nil {
+		return nil, err
+	}
+	splitter.boundary(len(ssz), true)
+
+	chunks := make([]SSZChunk, len(splitter.cuts))
+	start := 0
+	for i, end := range splitter.cuts {
+		chunks[i] = SSZChunk{
+			Hash: sha256.Sum256(ssz[start:end]),
+			Data: ssz[start:end],
+		}
+		start = end
+	}
+
+	return chunks, nil
+}
+
+// JoinSSZChunks reassembles an SSZ encoding from the ordered chunk hashes returned by SplitSSZChunks.
+// The 'loadChunk' callback is called for each hash to retrieve the chunk data from the storage backend.
+// Returns ErrChecksumMismatch if the data of a chunk does not match its hash, or the error returned by loadChunk.
+func JoinSSZChunks(hashes [][32]byte, loadChunk func(hash [32]byte) ([]byte, error)) ([]byte, error) {
+	ssz := []byte{}
+	for _, hash := range hashes {
+		data, err := loadChunk(hash)
+		if err != nil {
+			return nil, err
+		}
+		if !sszutils.EqualRoot(sha256.Sum256(data), hash) {
+			return nil, ErrChecksumMismatch
+		}
+		ssz = append(ssz, data...)
+	}
+
+	return ssz, nil
+}
+
+// sszChunkSplitter collects the chunk boundaries while walking the ssz encoding of a type.
+type sszChunkSplitter struct {
+	dynssz    *DynSsz
+	ssz       []byte
+	chunkSize int
+	cuts      []int
+	start     int // start of the current chunk
+	last      int // last boundary within the current chunk
+}
+
+// boundary marks a position where a chunk may be cut. The current chunk is cut at the last boundary before it would
+// exceed the chunk size, 'hard' boundaries always cut the current chunk.
+func (s *sszChunkSplitter) boundary(pos int, hard bool) {
+	if pos-s.start > s.chunkSize && s.last > s.start {
+		s.cut(s.last)
+	}
+	if hard || pos-s.start >= s.chunkSize {
+		s.cut(pos)
+	}
+	s.last = pos
+}
+
+func (s *sszChunkSplitter) cut(pos int) {
+	if pos > s.start {
+		s.cuts = append(s.cuts, pos)
+		s.start = pos
+	}
+}
+
+// splitType collects the boundaries within the ssz range [start:end] of a value of the given type.
+// Ranges that fit into a single chunk are not walked, larger ranges are enclosed by hard boundaries and split along
+// the boundaries of their fields or items.
+func (s *sszChunkSplitter) splitType(targetType reflect.Type, sizeHints []sszSizeHint, start int, end int) error {
+	if end-start <= s.chunkSize {
+		s.boundary(end, false)
+		return nil
+	}
+
+	if targetType.Kind() == reflect.Ptr {
+		targetType = targetType.Elem()
+	}
+
+	s.boundary(start, true)
+
+	var err error
+	switch targetType.Kind() {
+	case reflect.Struct:
+		err = s.splitStruct(targetType, start, end)
+	case reflect.Array, reflect.Slice:
+		err = s.splitList(targetType, sizeHints, start, end)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.boundary(end, true)
+	return nil
+}
+
+// splitStruct collects the boundaries of the static fields, the offsets and the dynamic fields of a container.
+func (s *sszChunkSplitter) splitStruct(targetType reflect.Type, start int, end int) error {
+	offset := start
+	dynamicFields := []*reflect.StructField{}
+	dynamicOffsets := []int{}
+	dynamicSizeHints := [][]sszSizeHint{}
+
+	for i := 0; i < targetType.NumField(); i++ {
+		field := s.dynssz.getStructField(targetType, i)
+
+		fieldSize, _, sizeHints, err := s.dynssz.getSszFieldSize(&field)
+		if err != nil {
+			return err
+		}
+
+		if fieldSize > 0 {
+			if offset+fieldSize > end {
+				return fmt.Errorf("unexpected end of SSZ. field %v expects %v bytes, got %v", field.Name, fieldSize, end-offset)
+			}
+			if err := s.splitType(field.Type, sizeHints, offset, offset+fieldSize); err != nil {
+				return fmt.Errorf("failed splitting field %v: %v", field.Name, err)
+			}
+		} else {
+			fieldSize = 4
+			if offset+fieldSize > end {
+				return fmt.Errorf("unexpected end of SSZ. dynamic field %v expects %v bytes (offset), got %v", field.Name, fieldSize, end-offset)
+			}
+			s.boundary(offset+fieldSize, false)
+
+			dynamicFields = append(dynamicFields, &field)
+			fieldOffset := readOffsetInt(s.ssz[offset : offset+fieldSize])
+			if fieldOffset > end-start {
+				return ErrOffset
+			}
+			dynamicOffsets = append(dynamicOffsets, start+fieldOffset)
+			dynamicSizeHints = append(dynamicSizeHints, sizeHints)
+		}
+		offset += fieldSize
+	}
+
+	for i, field := range dynamicFields {
+		startOffset := dynamicOffsets[i]
+		endOffset := end
+		if i < len(dynamicFields)-1 {
+			endOffset = dynamicOffsets[i+1]
+		}
+
+		// check offset integrity (not before previous field offset & not after range end)
+		if startOffset < offset || endOffset < startOffset || endOffset > end {
+			return ErrOffset
+		}
+
+		if err := s.splitType(field.Type, dynamicSizeHints[i], startOffset, endOffset); err != nil {
+			return fmt.Errorf("failed splitting field %v: %v", field.Name, err)
+		}
+		offset = endOffset
+	}
+
+	return nil
+}
+
+// splitList collects the boundaries of the items of a vector or list. Static size items are grouped so that each
+// group fits into a chunk, while dynamic size items are split individually after the offset table.
+func (s *sszChunkSplitter) splitList(targetType reflect.Type, sizeHints []sszSizeHint, start int, end int) error {
+	childSizeHints := []sszSizeHint{}
+	if len(sizeHints) > 1 {
+		childSizeHints = sizeHints[1:]
+	}
+
+	itemType := targetType.Elem()
+	itemSize, _, err := s.dynssz.getSszSize(itemType, childSizeHints)
+	if err != nil {
+		return err
+	}
+
+	if itemSize > 0 {
+		itemCount, ok := divideInt(end-start, itemSize)
+		if !ok {
+			return fmt.Errorf("invalid list length, expected multiple of %v, got %v", itemSize, end-start)
+		}
+
+		if itemSize > s.chunkSize {
+			for i := 0; i < itemCount; i++ {
+				if err := s.splitType(itemType, childSizeHints, start+i*itemSize, start+(i+1)*itemSize); err != nil {
+					return err
+				}
+			}
+		} else {
+			groupSize := (s.chunkSize / itemSize) * itemSize
+			for offset := start + groupSize; offset < end; offset += groupSize {
+				s.boundary(offset, false)
+			}
+		}
+
+		return nil
+	}
+
+	if end-start < 4 {
+		return fmt.Errorf("unexpected end of SSZ. list expects at least 4 bytes (offset), got %v", end-start)
+	}
+	firstOffset := readOffsetInt(s.ssz[start : start+4])
+	if firstOffset%4 != 0 || firstOffset > end-start {
+		return ErrOffset
+	}
+	itemCount := firstOffset / 4
+
+	for i := 0; i < itemCount; i++ {
+		s.boundary(start+(i+1)*4, false)
+	}
+	for i := 0; i < itemCount; i++ {
+		startOffset := readOffsetInt(s.ssz[start+i*4 : start+(i+1)*4])
+		endOffset := end - start
+		if i < itemCount-1 {
+			endOffset = readOffsetInt(s.ssz[start+(i+1)*4 : start+(i+2)*4])
+		}
+		if startOffset < firstOffset || endOffset < startOffset || endOffset > end-start {
+			return ErrOffset
+		}
+		startOffset, endOffset = start+startOffset, start+endOffset
+
+		if err := s.splitType(itemType, childSizeHints, startOffset, endOffset); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
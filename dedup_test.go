@@ -0,0 +1,105 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_DedupItem struct {
+	F1 uint64
+	F2 [24]uint8
+}
+
+type slug_DedupStruct struct {
+	F1 uint64
+	F2 []uint8 `ssz-size:"?" dynssz-size:"?"`
+	F3 []slug_DedupItem
+	F4 []*slug_DynStruct1
+}
+
+func TestSplitSSZChunks(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+
+	obj := &slug_DedupStruct{F1: 1, F2: []uint8{1, 2, 3}}
+	for i := 0; i < 100; i++ {
+		obj.F3 = append(obj.F3, slug_DedupItem{F1: uint64(i)})
+	}
+	for i := 0; i < 20; i++ {
+		obj.F4 = append(obj.F4, &slug_DynStruct1{F1: true, F2: bytes.Repeat([]uint8{uint8(i)}, i*4)})
+	}
+
+	ssz, err := dynssz.MarshalSSZ(obj)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+
+	chunks, err := dynssz.SplitSSZChunks(obj, ssz, 256)
+	if err != nil {
+		t.Fatalf("unexpected split error: %v", err)
+	}
+
+	store := map[[32]byte][]byte{}
+	hashes := [][32]byte{}
+	for _, chunk := range chunks {
+		store[chunk.Hash] = chunk.Data
+		hashes = append(hashes, chunk.Hash)
+	}
+
+	joined, err := JoinSSZChunks(hashes, func(hash [32]byte) ([]byte, error) {
+		data, found := store[hash]
+		if !found {
+			return nil, fmt.Errorf("chunk %x not found", hash)
+		}
+		return data, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected join error: %v", err)
+	}
+	if !bytes.Equal(joined, ssz) {
+		t.Fatalf("joined chunks do not match the original encoding")
+	}
+
+	// modify a single item and grow the preceding dynamic field, most chunks must be unchanged
+	obj.F2 = append(obj.F2, 4, 5, 6)
+	obj.F3[50].F1 = 1337
+
+	ssz2, err := dynssz.MarshalSSZ(obj)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+
+	chunks2, err := dynssz.SplitSSZChunks(obj, ssz2, 256)
+	if err != nil {
+		t.Fatalf("unexpected split error: %v", err)
+	}
+
+	newChunks := 0
+	for _, chunk := range chunks2 {
+		if _, found := store[chunk.Hash]; !found {
+			newChunks++
+		}
+	}
+	if newChunks > 3 {
+		t.Errorf("expected at most 3 new chunks, got %v of %v", newChunks, len(chunks2))
+	}
+
+	// corrupted chunk data
+	_, err = JoinSSZChunks(hashes, func(hash [32]byte) ([]byte, error) {
+		return []byte{1, 2, 3}, nil
+	})
+	if err != ErrChecksumMismatch {
+		t.Errorf("expected ErrChecksumMismatch, got %v", err)
+	}
+
+	// invalid offset
+	ssz[8] = 0xff
+	if _, err := dynssz.SplitSSZChunks(obj, ssz, 256); err != ErrOffset {
+		t.Errorf("expected ErrOffset, got %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	defaultMutex    sync.Mutex
+	defaultInstance atomic.Pointer[DynSsz]
+)
+
+// MainnetSpecs returns the mainnet preset values that affect the SSZ formats of the consensus types, as a new map
+// that can be modified by the caller.
+func MainnetSpecs() map[string]any {
+	return map[string]any{
+		"SLOTS_PER_EPOCH":                        uint64(32),
+		"SYNC_COMMITTEE_SIZE":                    uint64(512),
+		"SYNC_COMMITTEE_SUBNET_COUNT":            uint64(4),
+		"EPOCHS_PER_HISTORICAL_VECTOR":           uint64(65536),
+		"EPOCHS_PER_SLASHINGS_VECTOR":            uint64(8192),
+		"EPOCHS_PER_ETH1_VOTING_PERIOD":          uint64(64),
+		"SLOTS_PER_HISTORICAL_ROOT":              uint64(8192),
+		"HISTORICAL_ROOTS_LIMIT":                 uint64(16777216),
+		"VALIDATOR_REGISTRY_LIMIT":               uint64(1099511627776),
+		"MAX_VALIDATORS_PER_COMMITTEE":           uint64(2048),
+		"MAX_PROPOSER_SLASHINGS":                 uint64(16),
+		"MAX_ATTESTER_SLASHINGS":                 uint64(2),
+		"MAX_ATTESTATIONS":                       uint64(128),
+		"MAX_DEPOSITS":                           uint64(16),
+		"MAX_VOLUNTARY_EXITS":                    uint64(16),
+		"BYTES_PER_LOGS_BLOOM":                   uint64(256),
+		"MAX_EXTRA_DATA_BYTES":                   uint64(32),
+		"MAX_BYTES_PER_TRANSACTION":              uint64(1073741824),
+		"MAX_TRANSACTIONS_PER_PAYLOAD":           uint64(1048576),
+		"MAX_BLS_TO_EXECUTION_CHANGES":           uint64(16),
+		"MAX_WITHDRAWALS_PER_PAYLOAD":            uint64(16),
+		"MAX_BLOB_COMMITMENTS_PER_BLOCK":         uint64(4096),
+		"MAX_ATTESTER_SLASHINGS_ELECTRA":         uint64(1),
+		"MAX_ATTESTATIONS_ELECTRA":               uint64(8),
+		"PENDING_DEPOSITS_LIMIT":                 uint64(134217728),
+		"PENDING_PARTIAL_WITHDRAWALS_LIMIT":      uint64(134217728),
+		"PENDING_CONSOLIDATIONS_LIMIT":           uint64(262144),
+		"MAX_DEPOSIT_REQUESTS_PER_PAYLOAD":       uint64(8192),
+		"MAX_WITHDRAWAL_REQUESTS_PER_PAYLOAD":    uint64(16),
+		"MAX_CONSOLIDATION_REQUESTS_PER_PAYLOAD": uint64(2),
+	}
+}
+
+// Default returns the process-wide DynSsz instance, so small tools and tests do not need to pass an instance around.
+// Unless replaced by SetDefault, the instance is created on first use with the mainnet spec values (see MainnetSpecs)
+// and the default settings. Default is safe for concurrent use and always returns the same instance until
+// SetDefault is called.
+func Default() *DynSsz {
+	if ds := defaultInstance.Load(); ds != nil {
+		return ds
+	}
+
+	defaultMutex.Lock()
+	defer defaultMutex.Unlock()
+
+	if ds := defaultInstance.Load(); ds != nil {
+		return ds
+	}
+
+	ds := NewDynSsz(MainnetSpecs())
+	defaultInstance.Store(ds)
+	return ds
+}
+
+// SetDefault replaces the process-wide DynSsz instance returned by Default. Passing nil resets it, so the next call
+// to Default creates a new instance with the mainnet spec values.
+func SetDefault(ds *DynSsz) {
+	defaultMutex.Lock()
+	defer defaultMutex.Unlock()
+
+	defaultInstance.Store(ds)
+}
@@ -0,0 +1,52 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"sync"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+func TestDefault(t *testing.T) {
+	SetDefault(nil)
+	defer SetDefault(nil)
+
+	instances := make([]*DynSsz, 8)
+	wg := sync.WaitGroup{}
+	for i := range instances {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			instances[i] = Default()
+		}(i)
+	}
+	wg.Wait()
+
+	for i, ds := range instances {
+		if ds == nil || ds != instances[0] {
+			t.Fatalf("unexpected default instance %v: %p, wanted %p", i, ds, instances[0])
+		}
+	}
+
+	value, err := Default().ResolveExpression("SYNC_COMMITTEE_SIZE")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value != 512 {
+		t.Errorf("unexpected SYNC_COMMITTEE_SIZE: %v, wanted 512", value)
+	}
+
+	custom := NewDynSsz(map[string]any{"SYNC_COMMITTEE_SIZE": uint64(32)})
+	SetDefault(custom)
+	if Default() != custom {
+		t.Errorf("SetDefault did not replace the default instance")
+	}
+
+	SetDefault(nil)
+	if ds := Default(); ds == custom || ds == instances[0] {
+		t.Errorf("SetDefault(nil) did not reset the default instance")
+	}
+}
@@ -0,0 +1,116 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// DescribeType returns a human-readable tree of the ssz layout of the given type for debugging, with one line per
+// container field and list item type, their resolved sizes and size hints.
+// If the layout of the type can not be resolved, the tree is written up to the failing type, which is followed by the
+// error line, and the error is returned along with the partial tree. The error is a TypePathError that holds the path
+// to the failing type.
+// The 'targetType' parameter accepts either an instance or a reflect.Type value of the type to describe.
+func (d *DynSsz) DescribeType(targetType any) (string, error) {
+	sszType, ok := targetType.(reflect.Type)
+	if !ok {
+		sszType = reflect.TypeOf(targetType)
+	}
+	if sszType.Kind() == reflect.Ptr {
+		sszType = sszType.Elem()
+	}
+
+	builder := strings.Builder{}
+	_, _, err := d.getSszSize(sszType, []sszSizeHint{})
+	describeErr := d.describeType(&builder, getTypePathName(sszType), sszType, []sszSizeHint{}, nil, 0)
+	if err == nil {
+		err = describeErr
+	}
+
+	return builder.String(), err
+}
+
+// describeType writes the layout line of a type and the lines of its nested types. Nested types are only walked until
+// the first failing type, so the tree ends with the error line of the type that caused the failure.
+func (d *DynSsz) describeType(builder *strings.Builder, name string, targetType reflect.Type, sizeHints []sszSizeHint, parentTypes []reflect.Type, idt int) error {
+	if targetType.Kind() == reflect.Ptr {
+		targetType = targetType.Elem()
+	}
+
+	size, _, err := d.resolveSszSize(targetType, sizeHints, parentTypes)
+
+	sizeStr := "failed"
+	if err == nil && size < 0 {
+		sizeStr = "dynamic"
+	} else if err == nil {
+		sizeStr = fmt.Sprintf("%v bytes", size)
+	}
+	fmt.Fprintf(builder, "%v%v: %v (%v", strings.Repeat(" ", idt), name, targetType, sizeStr)
+	if len(sizeHints) > 0 {
+		fmt.Fprintf(builder, ", hints: %v", getSizeHintsDoc(sizeHints))
+	}
+	builder.WriteString(")\n")
+
+	childSizeHints := []sszSizeHint{}
+	if len(sizeHints) > 1 {
+		childSizeHints = sizeHints[1:]
+	}
+
+	isRecursive := false
+	for _, parentType := range parentTypes {
+		if parentType == targetType {
+			isRecursive = true
+		}
+	}
+
+	if !isRecursive {
+		switch targetType.Kind() {
+		case reflect.Struct:
+			parentTypes = append(parentTypes, targetType)
+			for i := 0; i < targetType.NumField(); i++ {
+				field := d.getStructField(targetType, i)
+				fieldSizeHints, tagErr := d.getSszSizeTag(&field)
+				if tagErr != nil {
+					fmt.Fprintf(builder, "%v%v: %v\n", strings.Repeat(" ", idt+2), field.Name, field.Type)
+					fmt.Fprintf(builder, "%verror: %v\n", strings.Repeat(" ", idt+4), tagErr)
+					return tagErr
+				}
+				if fieldErr := d.describeType(builder, field.Name, field.Type, fieldSizeHints, parentTypes, idt+2); fieldErr != nil {
+					return fieldErr
+				}
+			}
+		case reflect.Array:
+			if targetType.Len() > 0 {
+				itemName := fmt.Sprintf("[%v]", targetType.Len())
+				if itemErr := d.describeType(builder, itemName, targetType.Elem(), childSizeHints, parentTypes, idt+2); itemErr != nil {
+					return itemErr
+				}
+			}
+		case reflect.Slice:
+			if err == nil || len(sizeHints) == 0 || sizeHints[0].dynamic || sizeHints[0].size > 0 {
+				if itemErr := d.describeType(builder, "[]", targetType.Elem(), childSizeHints, parentTypes, idt+2); itemErr != nil {
+					return itemErr
+				}
+			}
+		}
+	}
+
+	if err != nil {
+		// none of the nested types failed, so the error is caused by this type itself
+		fmt.Fprintf(builder, "%verror: %v\n", strings.Repeat(" ", idt+2), err)
+	}
+
+	return err
+}
+
+// getTypePathName returns the name of a type for type paths, with "struct" for unnamed struct types.
+func getTypePathName(targetType reflect.Type) string {
+	if targetType.Name() == "" && targetType.Kind() == reflect.Struct {
+		return "struct"
+	}
+	return getTypeDocName(targetType)
+}
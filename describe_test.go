@@ -0,0 +1,67 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_DescribeInner struct {
+	F1 uint16
+	F2 []uint32 `ssz-size:"0"`
+	F3 uint8
+}
+
+type slug_DescribeOuter struct {
+	F1 uint64
+	F2 []slug_DescribeInner
+	F3 uint8
+}
+
+func TestDescribeType(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+
+	layout, err := dynssz.DescribeType(slug_DynStruct1{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "slug_DynStruct1: dynssz_test.slug_DynStruct1 (dynamic)\n" +
+		"  F1: bool (1 bytes)\n" +
+		"  F2: []uint8 (dynamic)\n" +
+		"    []: uint8 (1 bytes)\n"
+	if layout != expected {
+		t.Errorf("unexpected layout:\n%v", layout)
+	}
+
+	layout, err = dynssz.DescribeType(reflect.TypeOf(&slug_DescribeOuter{}))
+	if err == nil {
+		t.Fatalf("expected error for zero-length vector")
+	}
+	expected = "slug_DescribeOuter: dynssz_test.slug_DescribeOuter (failed)\n" +
+		"  F1: uint64 (8 bytes)\n" +
+		"  F2: []dynssz_test.slug_DescribeInner (failed)\n" +
+		"    []: dynssz_test.slug_DescribeInner (failed)\n" +
+		"      F1: uint16 (2 bytes)\n" +
+		"      F2: []uint32 (failed, hints: 0)\n" +
+		"        error: zero-length vector []uint32 is not supported, ssz vectors must have at least one item\n"
+	if layout != expected {
+		t.Errorf("unexpected layout:\n%v", layout)
+	}
+
+	pathErr := &TypePathError{}
+	if !errors.As(err, &pathErr) {
+		t.Fatalf("expected TypePathError, got %v", err)
+	}
+	if path := strings.Join(pathErr.Path, "→"); path != "slug_DescribeOuter.F2→[]→slug_DescribeInner.F2" {
+		t.Errorf("unexpected type path: %v", path)
+	}
+	if _, err := dynssz.MarshalSSZ(&slug_DescribeOuter{}); err == nil || !strings.Contains(err.Error(), "slug_DescribeOuter.F2→[]→slug_DescribeInner.F2: zero-length vector") {
+		t.Errorf("expected type path in marshal error, got %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// dumpConfig holds the settings of a single call of Dump.
+type dumpConfig struct {
+	roots    bool
+	maxItems int
+}
+
+// DumpOption configures a single call of Dump.
+type DumpOption func(config *dumpConfig)
+
+// WithDumpRoots adds the hash tree roots of containers to the dump. dynssz does not implement merkleization itself, so
+// roots are only shown for types that implement the fastssz HashRoot interface and are unaffected by the spec values
+// of the DynSsz instance.
+func WithDumpRoots() DumpOption {
+	return func(config *dumpConfig) {
+		config.roots = true
+	}
+}
+
+// WithDumpMaxItems limits the number of items that are dumped for each vector and list of non-byte items. The
+// remaining items are summarized in a single line.
+func WithDumpMaxItems(maxItems int) DumpOption {
+	return func(config *dumpConfig) {
+		config.maxItems = maxItems
+	}
+}
+
+// Dump writes an indented, field-annotated textual dump of the given source to 'w' for debugging. Each container
+// field and list item is written on its own line, byte vectors and byte lists are written as hex with their length,
+// and vectors and lists are annotated with their number of items.
+// Returns an error if the source contains types that are not supported by ssz or writing to 'w' fails.
+func (d *DynSsz) Dump(w io.Writer, source any, opts ...DumpOption) error {
+	config := &dumpConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	sourceType := reflect.TypeOf(source)
+	if sourceType == nil {
+		return fmt.Errorf("can not dump nil value")
+	}
+
+	builder := strings.Builder{}
+	if err := d.dumpValue(&builder, config, "", sourceType, reflect.ValueOf(source), 0); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
+
+// dumpValue writes the dump lines of a value and its nested values. The line of the value is prefixed with 'name'.
+func (d *DynSsz) dumpValue(builder *strings.Builder, config *dumpConfig, name string, sourceType reflect.Type, sourceValue reflect.Value, idt int) error {
+	prefix := strings.Repeat(" ", idt) + name
+	if name != "" {
+		prefix += ": "
+	}
+
+	for sourceType.Kind() == reflect.Ptr {
+		sourceType = sourceType.Elem()
+		if sourceValue.IsNil() {
+			fmt.Fprintf(builder, "%vnil (%v)\n", prefix, getDumpTypeName(sourceType))
+			return nil
+		}
+		sourceValue = sourceValue.Elem()
+	}
+
+	switch sourceType.Kind() {
+	case reflect.Bool:
+		fmt.Fprintf(builder, "%v%v\n", prefix, sourceValue.Bool())
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		fmt.Fprintf(builder, "%v%v\n", prefix, sourceValue.Uint())
+
+	case reflect.Struct:
+		fmt.Fprintf(builder, "%v%v", prefix, getDumpTypeName(sourceType))
+		if config.roots {
+			if root, ok := d.getDumpRoot(sourceType, sourceValue); ok {
+				fmt.Fprintf(builder, " (root: 0x%x)", root)
+			}
+		}
+		builder.WriteString("\n")
+
+		for i := 0; i < sourceType.NumField(); i++ {
+			field := d.getStructField(sourceType, i)
+			if err := d.dumpValue(builder, config, field.Name, field.Type, sourceValue.Field(i), idt+2); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Array, reflect.Slice:
+		itemType := sourceType.Elem()
+		if isByteType(itemType) {
+			data := sourceValue.Bytes()
+			if sourceType.Kind() == reflect.Array {
+				// array values are not necessarily addressable, so copy the bytes item by item
+				data = make([]byte, sourceValue.Len())
+				for i := range data {
+					data[i] = byte(sourceValue.Index(i).Uint())
+				}
+			}
+			fmt.Fprintf(builder, "%v0x%x (%v bytes)\n", prefix, data, len(data))
+			return nil
+		}
+
+		itemCount := sourceValue.Len()
+		fmt.Fprintf(builder, "%v%v (%v items)\n", prefix, getDumpTypeName(sourceType), itemCount)
+
+		dumpCount := itemCount
+		if config.maxItems > 0 && dumpCount > config.maxItems {
+			dumpCount = config.maxItems
+		}
+		for i := 0; i < dumpCount; i++ {
+			if err := d.dumpValue(builder, config, fmt.Sprintf("[%v]", i), itemType, sourceValue.Index(i), idt+2); err != nil {
+				return err
+			}
+		}
+		if dumpCount < itemCount {
+			fmt.Fprintf(builder, "%v... (%v more items)\n", strings.Repeat(" ", idt+2), itemCount-dumpCount)
+		}
+
+	default:
+		return fmt.Errorf("unknown type: %v", sourceType)
+	}
+
+	return nil
+}
+
+// getDumpRoot returns the hash tree root of a container via its fastssz HashTreeRoot method, if the type supports it
+// and is unaffected by the spec values of the instance.
+func (d *DynSsz) getDumpRoot(sourceType reflect.Type, sourceValue reflect.Value) ([32]byte, bool) {
+	fastsszCompat, err := d.getFastsszCompatibility(sourceType, []sszSizeHint{})
+	if err != nil || d.NoFastSsz || fastsszCompat.hasDynamicSpecValues {
+		return [32]byte{}, false
+	}
+
+	d.fastsszCompatMutex.Lock()
+	compatFlags, hasCompatFlags := d.compatFlags[sourceType]
+	d.fastsszCompatMutex.Unlock()
+
+	if !reflect.PointerTo(sourceType).Implements(hashTreeRooterType) || (hasCompatFlags && compatFlags&SszCompatFlagHashRoot == 0) {
+		return [32]byte{}, false
+	}
+
+	if !sourceValue.CanAddr() {
+		valueCopy := reflect.New(sourceType)
+		valueCopy.Elem().Set(sourceValue)
+		sourceValue = valueCopy.Elem()
+	}
+
+	root, err := sourceValue.Addr().Interface().(hashTreeRooter).HashTreeRoot()
+	if err != nil {
+		return [32]byte{}, false
+	}
+	return root, true
+}
+
+// getDumpTypeName returns the name of a type for dumps, which is the plain name for named types.
+func getDumpTypeName(targetType reflect.Type) string {
+	if targetType.Name() != "" {
+		return targetType.Name()
+	}
+	return targetType.String()
+}
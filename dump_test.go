@@ -0,0 +1,60 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_DumpStruct1 struct {
+	F1 bool
+	F2 [4]byte
+	F3 []uint16            `ssz-max:"16"`
+	F4 []*slug_DumpStruct2 `ssz-max:"4"`
+	F5 *slug_DumpStruct2
+}
+
+type slug_DumpStruct2 struct {
+	F1 uint64
+	F2 []byte `ssz-max:"32"`
+}
+
+func (s *slug_DumpStruct2) HashTreeRoot() ([32]byte, error) {
+	return [32]byte{byte(s.F1)}, nil
+}
+
+func TestDump(t *testing.T) {
+	source := &slug_DumpStruct1{
+		F1: true,
+		F2: [4]byte{1, 2, 3, 4},
+		F3: []uint16{5, 6, 7},
+		F4: []*slug_DumpStruct2{{F1: 8, F2: []byte{0xab}}, nil},
+	}
+
+	builder := strings.Builder{}
+	if err := NewDynSsz(nil).Dump(&builder, source, WithDumpRoots(), WithDumpMaxItems(2)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `slug_DumpStruct1
+  F1: true
+  F2: 0x01020304 (4 bytes)
+  F3: []uint16 (3 items)
+    [0]: 5
+    [1]: 6
+    ... (1 more items)
+  F4: []*dynssz_test.slug_DumpStruct2 (2 items)
+    [0]: slug_DumpStruct2 (root: 0x0800000000000000000000000000000000000000000000000000000000000000)
+      F1: 8
+      F2: 0xab (1 bytes)
+    [1]: nil (slug_DumpStruct2)
+  F5: nil (slug_DumpStruct2)
+`
+	if builder.String() != expected {
+		t.Errorf("unexpected dump:\n%v\nwanted:\n%v", builder.String(), expected)
+	}
+}
@@ -7,36 +7,136 @@
 package dynssz
 
 import (
+	"crypto/sha256"
 	"fmt"
+	"io"
+	"log/slog"
 	"reflect"
 	"sync"
+
+	"github.com/pk910/dynamic-ssz/sszutils"
 )
 
+// DynSsz is configured with the functional options passed to NewDynSsz. The exported settings fields are kept for
+// compatibility and must not be changed after the instance has been shared.
+// DynSsz is safe for concurrent use by multiple goroutines, as long as the settings are not changed concurrently.
+// The objects passed to the marshal functions must not be modified while being encoded, see DetectConcurrentModification.
 type DynSsz struct {
-	fastsszCompatMutex sync.Mutex
-	fastsszCompatCache map[reflect.Type]*fastsszCompatibility
-	typeSizeMutex      sync.RWMutex
-	typeSizeCache      map[reflect.Type]*cachedSszSize
-	specValues         map[string]any
-	specValueCache     map[string]*cachedSpecValue
-	NoFastSsz          bool
-	Verbose            bool
+	fastsszCompatMutex     sync.Mutex
+	fastsszCompatCache     map[reflect.Type]*fastsszCompatibility
+	compatFlags            map[reflect.Type]SszCompatFlag
+	processedTypes         map[reflect.Type]struct{}
+	typeProcessedCallbacks []func(targetType reflect.Type)
+	typeSizeMutex          sync.RWMutex
+	typeSizeCache          map[reflect.Type]*cachedSszSize
+	specValues             map[string]any
+	specValueMutex         sync.RWMutex
+	specValueCache         map[string]*cachedSpecValue
+	schemaMutex            sync.RWMutex
+	schemaCache            map[reflect.Type]Schema
+	validationMutex        sync.RWMutex
+	validationCache        map[reflect.Type]*cachedValidation
+	hooksMutex             sync.RWMutex
+	hooksCache             map[reflect.Type]*cachedHooks
+	pathStats              *pathStats
+	decodeCache            *decodeCache
+	logger                 *slog.Logger
+	fieldOverrides         Schema
+	overrideMutex          sync.Mutex
+	overrideInstances      map[string]*DynSsz
+
+	// NoFastSsz disables the use of fastssz methods.
+	//
+	// Deprecated: use the WithoutFastSSZ option of NewDynSsz instead.
+	NoFastSsz bool
+
+	// Verbose enables verbose debug output.
+	//
+	// Deprecated: use the WithVerbose option of NewDynSsz instead.
+	Verbose bool
+
+	// RequireSpecValues makes size calculation fail with an error if a 'dynssz-size' tag references a spec value
+	// that can not be resolved from the specs map, instead of silently falling back to the 'ssz-size' defaults.
+	//
+	// Deprecated: use the WithRequireSpecValues option of NewDynSsz instead.
+	RequireSpecValues bool
+
+	// StrictVectorLength makes marshalling fail with ErrVectorLength if a slice for a fixed size vector has less items
+	// than the vector length, instead of padding the encoding with zero values. Padded vectors decode to a full length
+	// slice, so nil or short slices do not survive a round trip.
+	//
+	// Deprecated: use the WithStrictVectorLength option of NewDynSsz instead.
+	StrictVectorLength bool
+
+	// ValidateAfterDecode makes UnmarshalSSZ run ValidateSSZ on the decoded object, so 'ssz-validate' tag rules and
+	// Validator implementations are enforced at the decode boundary.
+	//
+	// Deprecated: use the WithValidation option of NewDynSsz instead.
+	ValidateAfterDecode bool
+
+	// ZeroCopyDecode makes UnmarshalSSZ alias the input buffer for decoded byte lists instead of copying them.
+	// The decoded object is only valid as long as the input buffer is not modified or reused, use Detach to copy the
+	// byte lists before the object outlives the buffer. Byte vectors (fixed size arrays) and types decoded via fastssz
+	// are always copied.
+	//
+	// Deprecated: use the WithZeroCopyDecode option of NewDynSsz instead.
+	ZeroCopyDecode bool
+
+	// DetectConcurrentModification makes the marshal functions encode the source object a second time and fail with
+	// ErrConcurrentModification if the results differ. This catches objects that are modified by another goroutine
+	// while being encoded, which leads to corrupted encodings otherwise. MarshalSSZWriter can only detect the
+	// modification after the data has been written. As it doubles the encoding costs, it is meant for debugging.
+	//
+	// Deprecated: use the WithDetectConcurrentModification option of NewDynSsz instead.
+	DetectConcurrentModification bool
+
+	// VerifyRoundTrip makes UnmarshalSSZ re-encode each decoded object and the marshal functions re-decode and
+	// re-encode each encoding, failing with a RoundTripError if the data does not survive the round trip. The error
+	// reports the position and field of the first mismatch. As it more than doubles the costs of each call, it is meant
+	// for integration tests. MarshalSSZWriter does not verify the streamed data.
+	//
+	// Deprecated: use the WithVerifyRoundTrip option of NewDynSsz instead.
+	VerifyRoundTrip bool
+
+	// DecodeGuards limits the resources the unmarshal functions may use for a single decode, failing with a
+	// DecodeGuardError if the decoded data exceeds a limit. Set the limits when decoding untrusted input.
+	//
+	// Deprecated: use the WithDecodeGuards option of NewDynSsz instead.
+	DecodeGuards DecodeGuards
+
+	// StreamBufferSize is the size of the output buffer used by MarshalSSZWriter and TranscodeSSZToJSON.
+	// Defaults to 4096 bytes if not set. Larger buffers reduce the number of writes to the underlying writer.
+	//
+	// Deprecated: use the WithBufferSize option of NewDynSsz instead.
+	StreamBufferSize int
 }
 
 // NewDynSsz creates a new instance of the DynSsz encoder/decoder.
 // The 'specs' map contains dynamic properties and configurations that will be applied during SSZ serialization and deserialization processes.
 // This allows for flexible and dynamic handling of SSZ encoding/decoding based on the given specifications, making it suitable for various Ethereum presets and custom scenarios.
+// The 'opts' parameter takes functional options to configure the instance, like WithoutFastSSZ or WithValidation.
 // Returns a pointer to the newly created DynSsz instance, ready for use in serializing and deserializing operations.
-func NewDynSsz(specs map[string]any) *DynSsz {
+func NewDynSsz(specs map[string]any, opts ...Option) *DynSsz {
 	if specs == nil {
 		specs = map[string]any{}
 	}
-	return &DynSsz{
+	d := &DynSsz{
 		fastsszCompatCache: map[reflect.Type]*fastsszCompatibility{},
+		compatFlags:        map[reflect.Type]SszCompatFlag{},
+		processedTypes:     map[reflect.Type]struct{}{},
 		typeSizeCache:      map[reflect.Type]*cachedSszSize{},
 		specValues:         specs,
 		specValueCache:     map[string]*cachedSpecValue{},
+		schemaCache:        map[reflect.Type]Schema{},
+		validationCache:    map[reflect.Type]*cachedValidation{},
+		hooksCache:         map[reflect.Type]*cachedHooks{},
 	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
 }
 
 // MarshalSSZ serializes the given source into its SSZ (Simple Serialize) representation.
@@ -48,6 +148,10 @@ func (d *DynSsz) MarshalSSZ(source any) ([]byte, error) {
 	sourceType := reflect.TypeOf(source)
 	sourceValue := reflect.ValueOf(source)
 
+	if err := d.runMarshalHooks(sourceType, sourceValue); err != nil {
+		return nil, err
+	}
+
 	size, err := d.getSszValueSize(sourceType, sourceValue, []sszSizeHint{})
 	if err != nil {
 		return nil, err
@@ -63,6 +167,18 @@ func (d *DynSsz) MarshalSSZ(source any) ([]byte, error) {
 		return nil, fmt.Errorf("ssz length does not match expected length (expected: %v, got: %v)", size, len(newBuf))
 	}
 
+	if d.DetectConcurrentModification {
+		if err := d.checkUnmodified(sourceType, sourceValue, sha256.Sum256(newBuf)); err != nil {
+			return nil, err
+		}
+	}
+
+	if d.VerifyRoundTrip {
+		if err := d.verifyMarshalRoundTrip(sourceType, newBuf); err != nil {
+			return nil, err
+		}
+	}
+
 	return newBuf, nil
 }
 
@@ -75,11 +191,27 @@ func (d *DynSsz) MarshalSSZTo(source any, buf []byte) ([]byte, error) {
 	sourceType := reflect.TypeOf(source)
 	sourceValue := reflect.ValueOf(source)
 
+	if err := d.runMarshalHooks(sourceType, sourceValue); err != nil {
+		return nil, err
+	}
+
 	newBuf, err := d.marshalType(sourceType, sourceValue, buf, []sszSizeHint{}, 0)
 	if err != nil {
 		return nil, err
 	}
 
+	if d.DetectConcurrentModification {
+		if err := d.checkUnmodified(sourceType, sourceValue, sha256.Sum256(newBuf[len(buf):])); err != nil {
+			return nil, err
+		}
+	}
+
+	if d.VerifyRoundTrip {
+		if err := d.verifyMarshalRoundTrip(sourceType, newBuf[len(buf):]); err != nil {
+			return nil, err
+		}
+	}
+
 	return newBuf, nil
 }
 
@@ -102,19 +234,102 @@ func (d *DynSsz) SizeSSZ(source any) (int, error) {
 // The 'ssz' byte slice contains the SSZ-encoded data, and 'target' is a pointer to the Go value that will hold the decoded data.
 // This method dynamically handles the decoding, accommodating for types with dynamic field sizes.
 // It seamlessly integrates with fastssz for types without dynamic specifications to ensure efficient decoding.
+// All SSZ-visible values of the target are overwritten. Decoding into a previously used target reuses its memory:
+// slices with sufficient capacity are resized in place and existing pointers are decoded into, while items of
+// pointer lists are newly allocated. Use Reset to clear a target for reuse without releasing its memory.
 // Returns an error if decoding fails or if the provided ssz data has not been fully used for decoding.
 func (d *DynSsz) UnmarshalSSZ(target any, ssz []byte) error {
 	targetType := reflect.TypeOf(target)
 	targetValue := reflect.ValueOf(target)
 
-	consumedBytes, err := d.unmarshalType(targetType, targetValue, ssz, []sszSizeHint{}, 0)
-	if err != nil {
+	cache := d.decodeCache
+	useCache := cache != nil && targetType != nil && targetType.Kind() == reflect.Ptr && !targetValue.IsNil() &&
+		cache.isCacheable(targetType.Elem())
+	var cacheKey decodeCacheKey
+	cacheHit := false
+	if useCache {
+		cacheKey = getDecodeCacheKey(targetType, ssz)
+		cacheHit = cache.load(cacheKey, targetValue.Elem())
+	}
+
+	if !cacheHit {
+		consumedBytes, err := d.unmarshalType(targetType, targetValue, ssz, []sszSizeHint{}, d.newDecodeGuard(), 0)
+		if err != nil {
+			return err
+		}
+
+		if consumedBytes != len(ssz) {
+			return fmt.Errorf("did not consume full ssz range (consumed: %v, ssz size: %v)", consumedBytes, len(ssz))
+		}
+
+		if d.VerifyRoundTrip {
+			if err := d.verifyUnmarshalRoundTrip(targetType, targetValue, ssz); err != nil {
+				return err
+			}
+		}
+
+		// the cache holds the decoded value before any hooks ran, as the hooks are run again for each cache hit
+		if useCache {
+			cache.store(cacheKey, targetValue.Elem())
+		}
+	}
+
+	if err := d.runUnmarshalHooks(targetType, targetValue); err != nil {
 		return err
 	}
 
-	if consumedBytes != len(ssz) {
-		return fmt.Errorf("did not consume full ssz range (consumed: %v, ssz size: %v)", consumedBytes, len(ssz))
+	if d.ValidateAfterDecode {
+		if err := d.ValidateSSZ(target); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
+
+// UnmarshalSSZReader reads 'size' bytes of SSZ-encoded data from the reader 'r' and decodes them into the target object.
+// It behaves like UnmarshalSSZ, but allows decoding directly from files or network streams.
+// Returns an error if reading from 'r' fails or if decoding fails.
+func (d *DynSsz) UnmarshalSSZReader(target any, r io.Reader, size int) error {
+	ssz, err := d.readSszData(target, r, size)
+	if err != nil {
+		return err
+	}
+
+	return d.UnmarshalSSZ(target, ssz)
+}
+
+// UnmarshalSSZReaderVerify reads 'size' bytes of SSZ-encoded data from the reader 'r', verifies their sha256 checksum
+// against 'expectedHash' and decodes them into the target object.
+// The checksum is verified before decoding, so the target object is left untouched if the data does not match.
+// Returns ErrChecksumMismatch if the checksum does not match, or an error if reading or decoding fails.
+func (d *DynSsz) UnmarshalSSZReaderVerify(target any, r io.Reader, size int, expectedHash [32]byte) error {
+	ssz, err := d.readSszData(target, r, size)
+	if err != nil {
+		return err
+	}
+
+	if !sszutils.EqualRoot(sha256.Sum256(ssz), expectedHash) {
+		return ErrChecksumMismatch
+	}
+
+	return d.UnmarshalSSZ(target, ssz)
+}
+
+// readSszData reads 'size' bytes of SSZ-encoded data for the target object from the reader 'r'. The size is checked
+// against the MaxTotalAlloc decode guard before the buffer is allocated.
+func (d *DynSsz) readSszData(target any, r io.Reader, size int) ([]byte, error) {
+	if size < 0 {
+		return nil, fmt.Errorf("invalid ssz size %v", size)
+	}
+	if err := d.newDecodeGuard().alloc(reflect.TypeOf(target), uint64(size)); err != nil {
+		return nil, err
+	}
+
+	ssz := make([]byte, size)
+	if _, err := io.ReadFull(r, ssz); err != nil {
+		return nil, fmt.Errorf("failed reading ssz data: %v", err)
+	}
+
+	return ssz, nil
+}
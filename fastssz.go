@@ -1,6 +1,9 @@
 package dynssz
 
-import "reflect"
+import (
+	"fmt"
+	"reflect"
+)
 
 // fastsszMarshaler is the interface implemented by types that can marshal themselves into valid SZZ using fastssz.
 type fastsszMarshaler interface {
@@ -38,6 +41,18 @@ type fastsszHashWalker interface {
 	MerkleizeWithMixin(indx int, num, limit uint64)
 }
 
+// SszCompatFlag describes which fastssz interfaces of a type may be used by dynssz.
+type SszCompatFlag uint8
+
+const (
+	// SszCompatFlagFastsszMarshaler allows using the fastssz MarshalSSZTo & SizeSSZ methods of a type.
+	SszCompatFlagFastsszMarshaler SszCompatFlag = 1 << iota
+	// SszCompatFlagFastsszUnmarshaler allows using the fastssz UnmarshalSSZ method of a type.
+	SszCompatFlagFastsszUnmarshaler
+	// SszCompatFlagHashRoot allows using the fastssz HashTreeRoot & HashTreeRootWith methods of a type.
+	SszCompatFlagHashRoot
+)
+
 var sszMarshalerType = reflect.TypeOf((*fastsszMarshaler)(nil)).Elem()
 var sszUnmarshalerType = reflect.TypeOf((*fastsszUnmarshaler)(nil)).Elem()
 var sszHashRootType = reflect.TypeOf((*fastsszHashRoot)(nil)).Elem()
@@ -78,14 +93,15 @@ type fastsszCompatibility struct {
 
 func (d *DynSsz) getFastsszCompatibility(targetType reflect.Type, sizeHints []sszSizeHint) (*fastsszCompatibility, error) {
 	d.fastsszCompatMutex.Lock()
-	defer d.fastsszCompatMutex.Unlock()
 
 	if cachedCompatibility := d.fastsszCompatCache[targetType]; cachedCompatibility != nil {
+		d.fastsszCompatMutex.Unlock()
 		return cachedCompatibility, nil
 	}
 
 	_, hasSpecVals, err := d.getSszSize(targetType, sizeHints)
 	if err != nil {
+		d.fastsszCompatMutex.Unlock()
 		return nil, err
 	}
 
@@ -96,6 +112,75 @@ func (d *DynSsz) getFastsszCompatibility(targetType reflect.Type, sizeHints []ss
 		isHashRoot:           targetPtrType.Implements(sszHashRootType),
 		hasDynamicSpecValues: hasSpecVals,
 	}
+	if flags, hasFlags := d.compatFlags[targetType]; hasFlags {
+		// restrict to the explicitly registered interfaces
+		compatibility.isMarshaler = compatibility.isMarshaler && flags&SszCompatFlagFastsszMarshaler != 0
+		compatibility.isUnmarshaler = compatibility.isUnmarshaler && flags&SszCompatFlagFastsszUnmarshaler != 0
+		compatibility.isHashRoot = compatibility.isHashRoot && flags&SszCompatFlagHashRoot != 0
+	}
+	_, isKnownType := d.processedTypes[targetType]
+	d.processedTypes[targetType] = struct{}{}
 	d.fastsszCompatCache[targetType] = compatibility
+	d.logTypeCache("fastssz", targetType, "marshaler", compatibility.isMarshaler, "unmarshaler", compatibility.isUnmarshaler, "hashroot", compatibility.isHashRoot, "specvals", compatibility.hasDynamicSpecValues)
+
+	callbacks := d.typeProcessedCallbacks
+	d.fastsszCompatMutex.Unlock()
+
+	if !isKnownType {
+		// invoke callbacks outside the lock, so they can use the DynSsz instance
+		for _, callback := range callbacks {
+			callback(targetType)
+		}
+	}
+
 	return compatibility, nil
 }
+
+// OnTypeProcessed registers a callback that is invoked whenever a type is processed by the DynSsz instance for the
+// first time, e.g. when it is encoded, decoded or sized the first time. This allows frameworks to automatically set up
+// per type resources like metrics, JSON codecs or documentation. Nested types are reported as they are processed.
+// The callback is invoked once per type, it may use the DynSsz instance but must be safe for concurrent use.
+func (d *DynSsz) OnTypeProcessed(callback func(targetType reflect.Type)) {
+	d.fastsszCompatMutex.Lock()
+	defer d.fastsszCompatMutex.Unlock()
+
+	d.typeProcessedCallbacks = append(d.typeProcessedCallbacks, callback)
+}
+
+// RegisterCompatFlag registers the fastssz interfaces dynssz is allowed to use for the given type, overriding the
+// automatic detection. This allows disabling the fastssz code path for types with broken or outdated generated code,
+// or for a subset of their methods only. Passing 0 as flags disables fastssz entirely for the type.
+// The 'targetType' parameter accepts either an instance or a reflect.Type value of the type. Pointer types are resolved
+// to their value type.
+// Returns an error if the flags reference fastssz interfaces that are not implemented by the type.
+func (d *DynSsz) RegisterCompatFlag(targetType any, flags SszCompatFlag) error {
+	sszType, ok := targetType.(reflect.Type)
+	if !ok {
+		sszType = reflect.TypeOf(targetType)
+	}
+	if sszType == nil {
+		return fmt.Errorf("invalid type for compat flag registration")
+	}
+	if sszType.Kind() == reflect.Ptr {
+		sszType = sszType.Elem()
+	}
+
+	targetPtrType := reflect.New(sszType).Type()
+	if flags&SszCompatFlagFastsszMarshaler != 0 && !targetPtrType.Implements(sszMarshalerType) {
+		return fmt.Errorf("type %v does not implement the fastssz Marshaler interface", sszType)
+	}
+	if flags&SszCompatFlagFastsszUnmarshaler != 0 && !targetPtrType.Implements(sszUnmarshalerType) {
+		return fmt.Errorf("type %v does not implement the fastssz Unmarshaler interface", sszType)
+	}
+	if flags&SszCompatFlagHashRoot != 0 && !targetPtrType.Implements(sszHashRootType) {
+		return fmt.Errorf("type %v does not implement the fastssz HashRoot interface", sszType)
+	}
+
+	d.fastsszCompatMutex.Lock()
+	defer d.fastsszCompatMutex.Unlock()
+
+	d.compatFlags[sszType] = flags
+	delete(d.fastsszCompatCache, sszType)
+
+	return nil
+}
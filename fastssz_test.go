@@ -0,0 +1,109 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+// slug_FastsszStruct1 implements the fastssz Marshaler interface with a deliberately wrong encoding,
+// so tests can detect whether the fastssz code path has been used.
+type slug_FastsszStruct1 struct {
+	F1 uint16
+}
+
+func (s *slug_FastsszStruct1) MarshalSSZ() ([]byte, error) {
+	return s.MarshalSSZTo(nil)
+}
+
+func (s *slug_FastsszStruct1) MarshalSSZTo(dst []byte) ([]byte, error) {
+	return append(dst, 0xff, 0xff), nil
+}
+
+func (s *slug_FastsszStruct1) SizeSSZ() int {
+	return 2
+}
+
+func TestRegisterCompatFlag(t *testing.T) {
+	payload := &slug_FastsszStruct1{F1: 1}
+
+	dynssz := NewDynSsz(nil)
+	buf, err := dynssz.MarshalSSZ(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(buf, fromHex("0xffff")) {
+		t.Errorf("expected fastssz encoding, got 0x%x", buf)
+	}
+
+	if err := dynssz.RegisterCompatFlag(payload, SszCompatFlagFastsszUnmarshaler); err == nil {
+		t.Errorf("expected error for unimplemented fastssz interface")
+	}
+
+	if err := dynssz.RegisterCompatFlag(payload, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	buf, err = dynssz.MarshalSSZ(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(buf, fromHex("0x0100")) {
+		t.Errorf("expected dynamic encoding, got 0x%x", buf)
+	}
+
+	if err := dynssz.RegisterCompatFlag(payload, SszCompatFlagFastsszMarshaler); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	buf, err = dynssz.MarshalSSZ(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(buf, fromHex("0xffff")) {
+		t.Errorf("expected fastssz encoding, got 0x%x", buf)
+	}
+}
+
+type slug_ProcessedStruct1 struct {
+	F1 uint16
+	F2 []*slug_ProcessedStruct2 `ssz-size:"?" dynssz-size:"?"`
+}
+
+type slug_ProcessedStruct2 struct {
+	F1 uint32
+}
+
+func TestOnTypeProcessed(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+
+	processed := []reflect.Type{}
+	dynssz.OnTypeProcessed(func(targetType reflect.Type) {
+		processed = append(processed, targetType)
+
+		// callbacks may use the instance
+		if _, err := dynssz.SizeSSZ(&slug_ProcessedStruct2{}); err != nil {
+			t.Errorf("unexpected error in callback: %v", err)
+		}
+	})
+
+	payload := &slug_ProcessedStruct1{F1: 1, F2: []*slug_ProcessedStruct2{{F1: 2}}}
+	for i := 0; i < 2; i++ {
+		if _, err := dynssz.MarshalSSZ(payload); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	seen := map[reflect.Type]int{}
+	for _, targetType := range processed {
+		seen[targetType]++
+	}
+	for _, targetType := range []reflect.Type{reflect.TypeOf(slug_ProcessedStruct1{}), reflect.TypeOf(slug_ProcessedStruct2{}), reflect.TypeOf(uint16(0))} {
+		if seen[targetType] != 1 {
+			t.Errorf("expected type %v to be reported once, got %v", targetType, seen[targetType])
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// FieldOffset returns the position of a field within the SSZ encoding of a container type, so callers can patch
+// single fixed size fields of an existing encoding (e.g. the slot of a cached header) without a full re-marshal.
+// The 'path' parameter is a field name, fields of nested static containers are referenced with dots ("Message.Slot").
+// For static fields, offset and size describe the encoded value, which is at the same position in every encoding of
+// the type, and static is true. For dynamic fields, offset and size describe the 4 byte offset of the field within the
+// fixed part of the container and static is false.
+// The 'targetType' parameter accepts either an instance or a reflect.Type value of the container type.
+// Returns an UnknownFieldError if a field does not exist, or an error if the path descends into a dynamic field.
+func (d *DynSsz) FieldOffset(targetType any, path string) (offset int, size int, static bool, err error) {
+	sszType, ok := targetType.(reflect.Type)
+	if !ok {
+		sszType = reflect.TypeOf(targetType)
+	}
+
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		if i > 0 && !static {
+			return 0, 0, false, fmt.Errorf("field %v is dynamic, the positions of its fields depend on the encoded data", strings.Join(names[:i], "."))
+		}
+
+		field, fieldOffset, fieldSize, _, err := d.getStructFieldOffset(sszType, name)
+		if err != nil {
+			return 0, 0, false, err
+		}
+
+		sszType = field.Type
+		offset += fieldOffset
+		size = fieldSize
+		static = fieldSize >= 0
+		if !static {
+			size = 4
+		}
+	}
+
+	return offset, size, static, nil
+}
+
+// getStructFieldOffset returns the named field of a container type with its offset within the fixed part of the
+// container, its static size (-1 for dynamic fields) and its size hints.
+func (d *DynSsz) getStructFieldOffset(targetType reflect.Type, name string) (reflect.StructField, int, int, []sszSizeHint, error) {
+	if targetType.Kind() == reflect.Ptr {
+		targetType = targetType.Elem()
+	}
+	if targetType.Kind() != reflect.Struct {
+		return reflect.StructField{}, 0, 0, nil, fmt.Errorf("type %v is not a container", targetType)
+	}
+
+	offset := 0
+	for i := 0; i < targetType.NumField(); i++ {
+		field := d.getStructField(targetType, i)
+
+		fieldSize, _, sizeHints, err := d.getSszFieldSize(&field)
+		if err != nil {
+			return reflect.StructField{}, 0, 0, nil, err
+		}
+
+		if field.Name == name {
+			return field, offset, fieldSize, sizeHints, nil
+		}
+
+		if fieldSize < 0 {
+			offset += 4
+		} else {
+			offset += fieldSize
+		}
+	}
+
+	return reflect.StructField{}, 0, 0, nil, &UnknownFieldError{Type: targetType, Field: name}
+}
@@ -0,0 +1,65 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_OffsetHeader struct {
+	Slot      uint64
+	StateRoot [32]byte
+}
+
+type slug_OffsetStruct struct {
+	F1     uint16
+	F2     []uint8
+	Header slug_OffsetHeader
+	F3     *slug_DynStruct1
+	F4     []uint32 `ssz-size:"2" dynssz-size:"SPEC_A"`
+}
+
+func TestFieldOffset(t *testing.T) {
+	dynssz := NewDynSsz(map[string]any{"SPEC_A": uint64(3)})
+
+	testMatrix := []struct {
+		path   string
+		offset int
+		size   int
+		static bool
+	}{
+		{"F1", 0, 2, true},
+		{"F2", 2, 4, false},
+		{"Header", 6, 40, true},
+		{"Header.Slot", 6, 8, true},
+		{"Header.StateRoot", 14, 32, true},
+		{"F3", 46, 4, false},
+		{"F4", 50, 12, true},
+	}
+
+	for _, test := range testMatrix {
+		offset, size, static, err := dynssz.FieldOffset(reflect.TypeOf(&slug_OffsetStruct{}), test.path)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", test.path, err)
+		}
+		if offset != test.offset || size != test.size || static != test.static {
+			t.Errorf("%v: got offset %v, size %v, static %v, wanted %v, %v, %v", test.path, offset, size, static, test.offset, test.size, test.static)
+		}
+	}
+
+	unknownErr := &UnknownFieldError{}
+	if _, _, _, err := dynssz.FieldOffset(slug_OffsetStruct{}, "Header.Epoch"); !errors.As(err, &unknownErr) {
+		t.Errorf("expected UnknownFieldError, got %v", err)
+	}
+	if _, _, _, err := dynssz.FieldOffset(slug_OffsetStruct{}, "F3.F1"); err == nil {
+		t.Errorf("expected error for field in dynamic field")
+	}
+	if _, _, _, err := dynssz.FieldOffset(slug_OffsetStruct{}, "F1.F1"); err == nil {
+		t.Errorf("expected error for field in non-container field")
+	}
+}
@@ -0,0 +1,105 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// ForkSchedule maps fork digests and activation epochs to DynSsz instances, so networking code can pick the correct
+// encoder/decoder for a message by its fork digest. Forks that share identical spec values also share the same DynSsz
+// instance and therefore all of its type caches.
+type ForkSchedule struct {
+	mutex     sync.RWMutex
+	forks     []*forkScheduleEntry
+	digests   map[[4]byte]*forkScheduleEntry
+	instances []*DynSsz
+	options   []Option
+}
+
+type forkScheduleEntry struct {
+	digest [4]byte
+	epoch  uint64
+	dynssz *DynSsz
+}
+
+// NewForkSchedule creates a new, empty fork schedule. The given options are applied to the DynSsz instances of all
+// forks.
+func NewForkSchedule(opts ...Option) *ForkSchedule {
+	return &ForkSchedule{
+		digests: map[[4]byte]*forkScheduleEntry{},
+		options: opts,
+	}
+}
+
+// AddFork registers a fork with the given digest and activation epoch. The 'specs' map holds the spec values that apply
+// to the fork. If another fork has been registered with identical spec values, its DynSsz instance is reused.
+// Returns the DynSsz instance used for the fork, or an error if the digest has already been registered.
+func (s *ForkSchedule) AddFork(digest [4]byte, epoch uint64, specs map[string]any) (*DynSsz, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.digests[digest] != nil {
+		return nil, fmt.Errorf("fork digest 0x%x already registered", digest)
+	}
+
+	if specs == nil {
+		specs = map[string]any{}
+	}
+
+	var dynssz *DynSsz
+	for _, instance := range s.instances {
+		if reflect.DeepEqual(instance.specValues, specs) {
+			dynssz = instance
+			break
+		}
+	}
+	if dynssz == nil {
+		dynssz = NewDynSsz(specs, s.options...)
+		s.instances = append(s.instances, dynssz)
+	}
+
+	entry := &forkScheduleEntry{
+		digest: digest,
+		epoch:  epoch,
+		dynssz: dynssz,
+	}
+	s.digests[digest] = entry
+	s.forks = append(s.forks, entry)
+	sort.SliceStable(s.forks, func(a, b int) bool {
+		return s.forks[a].epoch < s.forks[b].epoch
+	})
+
+	return dynssz, nil
+}
+
+// ForVersion returns the DynSsz instance registered for the given fork digest, or nil if the digest is unknown.
+func (s *ForkSchedule) ForVersion(digest [4]byte) *DynSsz {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if entry := s.digests[digest]; entry != nil {
+		return entry.dynssz
+	}
+	return nil
+}
+
+// ForEpoch returns the DynSsz instance of the latest fork activated at or before the given epoch, or nil if no fork
+// is active yet.
+func (s *ForkSchedule) ForEpoch(epoch uint64) *DynSsz {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var dynssz *DynSsz
+	for _, entry := range s.forks {
+		if entry.epoch > epoch {
+			break
+		}
+		dynssz = entry.dynssz
+	}
+	return dynssz
+}
@@ -0,0 +1,70 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+func TestForkSchedule(t *testing.T) {
+	schedule := NewForkSchedule()
+
+	phase0, err := schedule.AddFork([4]byte{0, 0, 0, 1}, 0, map[string]any{"SYNC_COMMITTEE_SIZE": uint64(32)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	altair, err := schedule.AddFork([4]byte{0, 0, 0, 2}, 10, map[string]any{"SYNC_COMMITTEE_SIZE": uint64(32)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	bellatrix, err := schedule.AddFork([4]byte{0, 0, 0, 3}, 20, map[string]any{"SYNC_COMMITTEE_SIZE": uint64(512)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if phase0 != altair {
+		t.Errorf("expected forks with identical specs to share the DynSsz instance")
+	}
+	if phase0 == bellatrix {
+		t.Errorf("expected forks with different specs to use separate DynSsz instances")
+	}
+
+	if _, err := schedule.AddFork([4]byte{0, 0, 0, 3}, 30, nil); err == nil {
+		t.Errorf("expected error for duplicate fork digest")
+	}
+
+	if schedule.ForVersion([4]byte{0, 0, 0, 3}) != bellatrix {
+		t.Errorf("ForVersion returned unexpected instance")
+	}
+	if schedule.ForVersion([4]byte{0, 0, 0, 4}) != nil {
+		t.Errorf("ForVersion returned instance for unknown digest")
+	}
+	if schedule.ForEpoch(15) != altair {
+		t.Errorf("ForEpoch returned unexpected instance")
+	}
+	if schedule.ForEpoch(100) != bellatrix {
+		t.Errorf("ForEpoch returned unexpected instance")
+	}
+}
+
+func TestForkScheduleOptions(t *testing.T) {
+	schedule := NewForkSchedule(WithoutFastSSZ(), WithStrictVectorLength())
+
+	phase0, err := schedule.AddFork([4]byte{0, 0, 0, 1}, 0, map[string]any{"SYNC_COMMITTEE_SIZE": uint64(32)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	altair, err := schedule.AddFork([4]byte{0, 0, 0, 2}, 10, map[string]any{"SYNC_COMMITTEE_SIZE": uint64(512)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, dynssz := range []*DynSsz{phase0, altair} {
+		if !dynssz.NoFastSsz || !dynssz.StrictVectorLength {
+			t.Errorf("expected the schedule options to be applied to the fork instances")
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/pk910/dynamic-ssz/gindex"
+)
+
+// PathToGIndex calculates the generalized merkle tree index of the element identified by 'path' within the given type.
+// Path elements are either struct field names or item indices for vectors. Vector lengths are resolved from the
+// 'ssz-size' and 'dynssz-size' annotations with the spec values of this DynSsz instance, so the resulting index
+// matches the tree layout of the configured preset.
+// The 'targetType' parameter accepts either an instance or a reflect.Type value of the root type.
+// Returns an error if the path can not be resolved, e.g. for unknown fields, out of range indices or lists, as the
+// limits of lists are not known to dynssz.
+func (d *DynSsz) PathToGIndex(targetType any, path ...string) (uint64, error) {
+	sszType, ok := targetType.(reflect.Type)
+	if !ok {
+		sszType = reflect.TypeOf(targetType)
+	}
+
+	result := gindex.Root
+	sizeHints := []sszSizeHint{}
+
+	for _, element := range path {
+		for sszType.Kind() == reflect.Ptr {
+			sszType = sszType.Elem()
+		}
+
+		childSizeHints := []sszSizeHint{}
+		if len(sizeHints) > 1 {
+			childSizeHints = sizeHints[1:]
+		}
+
+		switch sszType.Kind() {
+		case reflect.Struct:
+			fieldIdx := -1
+			for i := 0; i < sszType.NumField(); i++ {
+				if sszType.Field(i).Name == element {
+					fieldIdx = i
+					break
+				}
+			}
+			if fieldIdx < 0 {
+				return 0, fmt.Errorf("unknown field %v in type %v", element, sszType)
+			}
+
+			field := d.getStructField(sszType, fieldIdx)
+			fieldSizeHints, err := d.getSszSizeTag(&field)
+			if err != nil {
+				return 0, err
+			}
+
+			depth := gindex.TreeDepth(uint64(sszType.NumField()))
+			result = gindex.Concat(result, gindex.FromDepthIndex(depth, uint64(fieldIdx)))
+			sszType = field.Type
+			sizeHints = fieldSizeHints
+
+		case reflect.Array, reflect.Slice:
+			var vectorLen uint64
+			if sszType.Kind() == reflect.Array {
+				vectorLen = uint64(sszType.Len())
+			} else if len(sizeHints) > 0 && !sizeHints[0].dynamic {
+				vectorLen = sizeHints[0].size
+			} else {
+				return 0, fmt.Errorf("can not resolve index %v in list type %v, list limits are unknown", element, sszType)
+			}
+
+			itemIdx, err := strconv.ParseUint(element, 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid index %v for vector type %v: %v", element, sszType, err)
+			}
+			if itemIdx >= vectorLen {
+				return 0, fmt.Errorf("index %v out of range for vector type %v with length %v", itemIdx, sszType, vectorLen)
+			}
+
+			itemType := sszType.Elem()
+			for itemType.Kind() == reflect.Ptr {
+				itemType = itemType.Elem()
+			}
+
+			if itemSize := getBasicTypeSize(itemType); itemSize > 0 {
+				// basic items are packed into 32 byte chunks
+				chunkCount := (vectorLen*uint64(itemSize) + 31) / 32
+				depth := gindex.TreeDepth(chunkCount)
+				result = gindex.Concat(result, gindex.FromDepthIndex(depth, itemIdx*uint64(itemSize)/32))
+			} else {
+				depth := gindex.TreeDepth(vectorLen)
+				result = gindex.Concat(result, gindex.FromDepthIndex(depth, itemIdx))
+			}
+
+			sszType = itemType
+			sizeHints = childSizeHints
+
+		default:
+			return 0, fmt.Errorf("can not resolve path element %v in basic type %v", element, sszType)
+		}
+	}
+
+	return result, nil
+}
+
+// getBasicTypeSize returns the size of basic ssz types (booleans and unsigned integers), or 0 for composite types.
+func getBasicTypeSize(targetType reflect.Type) int {
+	switch targetType.Kind() {
+	case reflect.Bool, reflect.Uint8:
+		return 1
+	case reflect.Uint16:
+		return 2
+	case reflect.Uint32:
+		return 4
+	case reflect.Uint64:
+		return 8
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,63 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+
+// Package gindex provides helpers for generalized merkle tree index math as used by SSZ merkle proofs.
+// A generalized index (gindex) identifies a node in a binary merkle tree: the root has index 1, and the left and right
+// children of a node with index i have the indices 2*i and 2*i+1.
+package gindex
+
+import "math/bits"
+
+// Root is the generalized index of the root node of a merkle tree.
+const Root uint64 = 1
+
+// Depth returns the depth of the node with the given generalized index, with the root node at depth 0.
+func Depth(gindex uint64) int {
+	if gindex == 0 {
+		return 0
+	}
+	return bits.Len64(gindex) - 1
+}
+
+// Parent returns the generalized index of the parent node.
+func Parent(gindex uint64) uint64 {
+	return gindex / 2
+}
+
+// Child returns the generalized index of the left (right = false) or right (right = true) child node.
+func Child(gindex uint64, right bool) uint64 {
+	if right {
+		return gindex*2 + 1
+	}
+	return gindex * 2
+}
+
+// Sibling returns the generalized index of the sibling node.
+func Sibling(gindex uint64) uint64 {
+	return gindex ^ 1
+}
+
+// FromDepthIndex returns the generalized index of the node at position 'index' in the layer at the given depth.
+func FromDepthIndex(depth int, index uint64) uint64 {
+	return uint64(1)<<depth + index
+}
+
+// TreeDepth returns the depth of a merkle tree with at least 'count' leaf chunks (the next power of two).
+func TreeDepth(count uint64) int {
+	if count <= 1 {
+		return 0
+	}
+	return bits.Len64(count - 1)
+}
+
+// Concat concatenates generalized indices, where each index is relative to the node identified by the previous ones.
+// The result is the generalized index of the last node relative to the root of the first tree.
+func Concat(gindices ...uint64) uint64 {
+	result := Root
+	for _, gindex := range gindices {
+		depth := Depth(gindex)
+		result = result<<depth | (gindex ^ uint64(1)<<depth)
+	}
+	return result
+}
@@ -0,0 +1,48 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package gindex_test
+
+import (
+	"testing"
+
+	"github.com/pk910/dynamic-ssz/gindex"
+)
+
+func TestGIndex(t *testing.T) {
+	if d := gindex.Depth(1); d != 0 {
+		t.Errorf("Depth(1) = %v, wanted 0", d)
+	}
+	if d := gindex.Depth(13); d != 3 {
+		t.Errorf("Depth(13) = %v, wanted 3", d)
+	}
+	if p := gindex.Parent(13); p != 6 {
+		t.Errorf("Parent(13) = %v, wanted 6", p)
+	}
+	if c := gindex.Child(6, true); c != 13 {
+		t.Errorf("Child(6, true) = %v, wanted 13", c)
+	}
+	if s := gindex.Sibling(13); s != 12 {
+		t.Errorf("Sibling(13) = %v, wanted 12", s)
+	}
+	if g := gindex.FromDepthIndex(5, 3); g != 35 {
+		t.Errorf("FromDepthIndex(5, 3) = %v, wanted 35", g)
+	}
+
+	for count, depth := range map[uint64]int{0: 0, 1: 0, 2: 1, 3: 2, 4: 2, 5: 3, 8192: 13} {
+		if d := gindex.TreeDepth(count); d != depth {
+			t.Errorf("TreeDepth(%v) = %v, wanted %v", count, d, depth)
+		}
+	}
+
+	// examples from the consensus specs (concat_generalized_indices)
+	if g := gindex.Concat(2, 3); g != 5 {
+		t.Errorf("Concat(2, 3) = %v, wanted 5", g)
+	}
+	if g := gindex.Concat(5, 6, 1); g != 22 {
+		t.Errorf("Concat(5, 6, 1) = %v, wanted 22", g)
+	}
+	if g := gindex.Concat(); g != gindex.Root {
+		t.Errorf("Concat() = %v, wanted 1", g)
+	}
+}
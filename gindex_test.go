@@ -0,0 +1,53 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_GIndexStruct1 struct {
+	F1 uint64
+	F2 [][32]uint8 `ssz-size:"8,32" dynssz-size:"SLOTS,32"`
+	F3 []uint64    `ssz-size:"16" dynssz-size:"SLOTS*2"`
+	F4 []uint8
+	F5 slug_StaticStruct1
+}
+
+func TestPathToGIndex(t *testing.T) {
+	tests := []struct {
+		slots    uint64
+		path     []string
+		expected uint64
+	}{
+		{8, []string{}, 1},
+		{8, []string{"F1"}, 8},
+		{8, []string{"F2"}, 9},
+		{8, []string{"F2", "5"}, 9*8 + 5},
+		{4, []string{"F2", "3"}, 9*4 + 3},
+		{8, []string{"F3", "5"}, 10*4 + 1},
+		{4, []string{"F3", "5"}, 10*2 + 1},
+		{8, []string{"F5", "F2"}, 12*2 + 1},
+		{8, []string{"F4", "0"}, 0},
+		{8, []string{"F2", "8"}, 0},
+		{8, []string{"F6"}, 0},
+		{8, []string{"F1", "0"}, 0},
+	}
+
+	for idx, test := range tests {
+		dynssz := NewDynSsz(map[string]any{"SLOTS": test.slots})
+		result, err := dynssz.PathToGIndex(slug_GIndexStruct1{}, test.path...)
+
+		switch {
+		case test.expected == 0 && err != nil:
+			// expected error
+		case err != nil:
+			t.Errorf("test %v error: %v", idx, err)
+		case result != test.expected:
+			t.Errorf("test %v failed: got %v, wanted %v", idx, result, test.expected)
+		}
+	}
+}
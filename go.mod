@@ -1,5 +1,5 @@
 module github.com/pk910/dynamic-ssz
 
-go 1.20
+go 1.21
 
 require gopkg.in/Knetic/govaluate.v3 v3.0.0
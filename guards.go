@@ -0,0 +1,85 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"reflect"
+)
+
+// DecodeGuards limits the resources a single decode may use, to defend handlers of untrusted input (e.g. gossip or
+// req/resp messages) against crafted payloads that pass the offset checks but explode the allocations of the decoder.
+// Limits that are 0 are not enforced. Types decoded via fastssz are bounded by their generated limits and are not
+// accounted.
+type DecodeGuards struct {
+	// MaxTotalAlloc limits the total memory in bytes that is needed for the lists and pointers of a decoded object.
+	// The memory is accounted regardless of whether the memory of a previously used target is reused, so the limit
+	// only depends on the decoded data. UnmarshalSSZReader and UnmarshalSSZReaderVerify check the size of the read
+	// buffer against it as well.
+	MaxTotalAlloc uint64
+
+	// MaxListElems limits the number of items of each decoded list.
+	MaxListElems uint64
+
+	// MaxNestingDepth limits the nesting depth of the decoded containers, vectors and lists.
+	MaxNestingDepth int
+}
+
+// decodeGuard tracks the resources used by a single decode. A nil decodeGuard does not enforce any limits.
+type decodeGuard struct {
+	guards     *DecodeGuards
+	totalAlloc uint64
+	depth      int
+}
+
+// newDecodeGuard returns a decodeGuard for a single decode, or nil if no decode guards are set.
+func (d *DynSsz) newDecodeGuard() *decodeGuard {
+	if d.DecodeGuards.MaxTotalAlloc == 0 && d.DecodeGuards.MaxListElems == 0 && d.DecodeGuards.MaxNestingDepth == 0 {
+		return nil
+	}
+
+	return &decodeGuard{
+		guards: &d.DecodeGuards,
+	}
+}
+
+// enter is called when decoding of a container, vector or list of the given type starts and checks the nesting depth.
+func (g *decodeGuard) enter(targetType reflect.Type) error {
+	g.depth++
+	if g.guards.MaxNestingDepth > 0 && g.depth > g.guards.MaxNestingDepth {
+		return &DecodeGuardError{Guard: "MaxNestingDepth", Type: targetType, Limit: uint64(g.guards.MaxNestingDepth), Value: uint64(g.depth)}
+	}
+	return nil
+}
+
+// leave is called when decoding of a container, vector or list is done.
+func (g *decodeGuard) leave() {
+	g.depth--
+}
+
+// allocList checks the number of items of a decoded list and accounts the list, before it is allocated. The items of
+// pointer lists are accounted separately when they are allocated.
+func (g *decodeGuard) allocList(targetType reflect.Type, length int) error {
+	if g == nil {
+		return nil
+	}
+
+	if g.guards.MaxListElems > 0 && uint64(length) > g.guards.MaxListElems {
+		return &DecodeGuardError{Guard: "MaxListElems", Type: targetType, Limit: g.guards.MaxListElems, Value: uint64(length)}
+	}
+
+	return g.alloc(targetType, uint64(length)*uint64(targetType.Elem().Size()))
+}
+
+// alloc accounts the given number of bytes, before they are allocated.
+func (g *decodeGuard) alloc(targetType reflect.Type, size uint64) error {
+	if g == nil {
+		return nil
+	}
+
+	g.totalAlloc += size
+	if g.guards.MaxTotalAlloc > 0 && g.totalAlloc > g.guards.MaxTotalAlloc {
+		return &DecodeGuardError{Guard: "MaxTotalAlloc", Type: targetType, Limit: g.guards.MaxTotalAlloc, Value: g.totalAlloc}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_GuardStruct1 struct {
+	F1 uint64
+	F2 []uint64               `ssz-max:"1024"`
+	F3 []*slug_GuardStruct2   `ssz-max:"16"`
+	F4 [][]*slug_GuardStruct2 `ssz-max:"4,4"`
+}
+
+type slug_GuardStruct2 struct {
+	F1 []uint8 `ssz-max:"32"`
+}
+
+func TestDecodeGuards(t *testing.T) {
+	source := &slug_GuardStruct1{
+		F1: 1,
+		F2: make([]uint64, 100),
+		F3: []*slug_GuardStruct2{{F1: []uint8{1}}, {F1: []uint8{2, 3}}},
+		F4: [][]*slug_GuardStruct2{{{F1: []uint8{4}}}},
+	}
+	ssz, err := NewDynSsz(nil).MarshalSSZ(source)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		name   string
+		guards DecodeGuards
+		guard  string
+	}{
+		{"no limits", DecodeGuards{}, ""},
+		{"within limits", DecodeGuards{MaxTotalAlloc: 4096, MaxListElems: 100, MaxNestingDepth: 5}, ""},
+		{"list elements", DecodeGuards{MaxListElems: 99}, "MaxListElems"},
+		{"total allocation", DecodeGuards{MaxTotalAlloc: 800}, "MaxTotalAlloc"},
+		{"nesting depth", DecodeGuards{MaxNestingDepth: 4}, "MaxNestingDepth"},
+	}
+
+	for _, tc := range testCases {
+		ds := NewDynSsz(nil, WithDecodeGuards(tc.guards))
+		err := ds.UnmarshalSSZ(&slug_GuardStruct1{}, ssz)
+
+		if tc.guard == "" {
+			if err != nil {
+				t.Errorf("%v: unexpected error: %v", tc.name, err)
+			}
+			continue
+		}
+
+		var guardErr *DecodeGuardError
+		if !errors.As(err, &guardErr) || !errors.Is(err, ErrDecodeGuard) {
+			t.Errorf("%v: expected DecodeGuardError, got: %v", tc.name, err)
+			continue
+		}
+		if guardErr.Guard != tc.guard {
+			t.Errorf("%v: unexpected guard: %v, wanted %v", tc.name, guardErr.Guard, tc.guard)
+		}
+	}
+}
+
+func TestDecodeGuardsProjection(t *testing.T) {
+	ssz, err := NewDynSsz(nil).MarshalSSZ(&slug_GuardStruct1{F2: make([]uint64, 100)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ds := NewDynSsz(nil, WithDecodeGuards(DecodeGuards{MaxListElems: 10}))
+	if err := ds.UnmarshalSSZProjection(&slug_GuardStruct1{}, ssz, "F1"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ds.UnmarshalSSZProjection(&slug_GuardStruct1{}, ssz, "F2"); !errors.Is(err, ErrDecodeGuard) {
+		t.Errorf("expected ErrDecodeGuard, got: %v", err)
+	}
+}
+
+func TestDecodeGuardsNestingDepth(t *testing.T) {
+	source := &slug_GuardStruct1{
+		F3: []*slug_GuardStruct2{{F1: []uint8{1}}},
+		F4: [][]*slug_GuardStruct2{{{F1: []uint8{4}}}},
+	}
+	ssz, err := NewDynSsz(nil).MarshalSSZ(source)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// F4 nests 5 levels deep (container, list, list, container, list), projected and ReaderAt decodes must count the
+	// container once and the decode of F4 must not be affected by the decode of F3 before it
+	ds := NewDynSsz(nil, WithDecodeGuards(DecodeGuards{MaxNestingDepth: 5}))
+	if err := ds.UnmarshalSSZProjection(&slug_GuardStruct1{}, ssz, "F3", "F4"); err != nil {
+		t.Errorf("unexpected projection error: %v", err)
+	}
+	if err := ds.UnmarshalSSZReaderAt(&slug_GuardStruct1{}, bytes.NewReader(ssz), len(ssz)); err != nil {
+		t.Errorf("unexpected ReaderAt error: %v", err)
+	}
+
+	ds = NewDynSsz(nil, WithDecodeGuards(DecodeGuards{MaxNestingDepth: 4}))
+	if err := ds.UnmarshalSSZProjection(&slug_GuardStruct1{}, ssz, "F4"); !errors.Is(err, ErrDecodeGuard) {
+		t.Errorf("expected ErrDecodeGuard for projection, got: %v", err)
+	}
+	if err := ds.UnmarshalSSZReaderAt(&slug_GuardStruct1{}, bytes.NewReader(ssz), len(ssz)); !errors.Is(err, ErrDecodeGuard) {
+		t.Errorf("expected ErrDecodeGuard for ReaderAt, got: %v", err)
+	}
+}
@@ -0,0 +1,34 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// MarshalSSZHex serializes the given source like MarshalSSZ and returns the SSZ representation as 0x prefixed
+// hex string, as used by the beacon APIs.
+func (d *DynSsz) MarshalSSZHex(source any) (string, error) {
+	ssz, err := d.MarshalSSZ(source)
+	if err != nil {
+		return "", err
+	}
+
+	return "0x" + hex.EncodeToString(ssz), nil
+}
+
+// UnmarshalSSZHex decodes the given hex encoded SSZ data into the target like UnmarshalSSZ.
+// The 0x prefix of the hex string is optional.
+func (d *DynSsz) UnmarshalSSZHex(target any, sszHex string) error {
+	sszHex = strings.TrimPrefix(strings.TrimPrefix(sszHex, "0x"), "0X")
+
+	ssz, err := hex.DecodeString(sszHex)
+	if err != nil {
+		return fmt.Errorf("failed decoding hex data: %v", err)
+	}
+
+	return d.UnmarshalSSZ(target, ssz)
+}
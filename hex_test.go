@@ -0,0 +1,38 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+func TestMarshalSSZHex(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+
+	sszHex, err := dynssz.MarshalSSZHex(&slug_DynStruct1{true, []uint8{4, 8}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sszHex != "0x01050000000408" {
+		t.Errorf("unexpected encoding: %v", sszHex)
+	}
+
+	for _, input := range []string{"0x01050000000408", "01050000000408", "0X01050000000408"} {
+		obj := slug_DynStruct1{}
+		if err := dynssz.UnmarshalSSZHex(&obj, input); err != nil {
+			t.Fatalf("unexpected error for %v: %v", input, err)
+		}
+		if !obj.F1 || !bytes.Equal(obj.F2, []uint8{4, 8}) {
+			t.Errorf("unexpected decoding result for %v: %v", input, obj)
+		}
+	}
+
+	obj := slug_DynStruct1{}
+	if err := dynssz.UnmarshalSSZHex(&obj, "0x0105000000040"); err == nil {
+		t.Errorf("expected error for odd length hex string")
+	}
+}
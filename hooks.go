@@ -0,0 +1,204 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// MarshalHook is the interface implemented by types that need to prepare themselves before being encoded, e.g. to
+// sync fields that are derived from other representations. BeforeSSZMarshal is called by the marshal functions of
+// DynSsz before the value is sized and encoded, for the root value and all nested values (parents before children).
+type MarshalHook interface {
+	BeforeSSZMarshal() error
+}
+
+// UnmarshalHook is the interface implemented by types that need to update themselves after being decoded, e.g. to
+// rebuild caches or normalize representations. AfterSSZUnmarshal is called by UnmarshalSSZ after the value has been
+// decoded, for all nested values and the root value (children before parents).
+type UnmarshalHook interface {
+	AfterSSZUnmarshal() error
+}
+
+var marshalHookType = reflect.TypeOf((*MarshalHook)(nil)).Elem()
+var unmarshalHookType = reflect.TypeOf((*UnmarshalHook)(nil)).Elem()
+
+// cachedHooks holds the information whether a type or any type nested in it implements the hook interfaces.
+type cachedHooks struct {
+	hasMarshalHooks   bool
+	hasUnmarshalHooks bool
+}
+
+// runMarshalHooks calls BeforeSSZMarshal on the given value and all values nested in it.
+func (d *DynSsz) runMarshalHooks(sourceType reflect.Type, sourceValue reflect.Value) error {
+	if !d.getHooks(sourceType).hasMarshalHooks {
+		return nil
+	}
+
+	// the hooks run before the value is sized, so the type is resolved first to report recursive types as error
+	// instead of walking cyclic values endlessly (decoded values are always resolved already)
+	if _, _, err := d.getSszSize(sourceType, []sszSizeHint{}); err != nil {
+		return err
+	}
+
+	return d.runHooks(sourceType, sourceValue, marshalHookType, "")
+}
+
+// runUnmarshalHooks calls AfterSSZUnmarshal on all values nested in the given value and on the value itself.
+func (d *DynSsz) runUnmarshalHooks(targetType reflect.Type, targetValue reflect.Value) error {
+	if !d.getHooks(targetType).hasUnmarshalHooks {
+		return nil
+	}
+
+	return d.runHooks(targetType, targetValue, unmarshalHookType, "")
+}
+
+// runHooks walks a value and calls the given hook interface on all values implementing it.
+func (d *DynSsz) runHooks(sourceType reflect.Type, sourceValue reflect.Value, hookType reflect.Type, path string) error {
+	hooks := d.getHooks(sourceType)
+	if hookType == marshalHookType && !hooks.hasMarshalHooks || hookType == unmarshalHookType && !hooks.hasUnmarshalHooks {
+		return nil
+	}
+
+	if sourceType.Kind() == reflect.Ptr {
+		if sourceValue.IsNil() {
+			return nil
+		}
+		sourceType = sourceType.Elem()
+		sourceValue = sourceValue.Elem()
+	}
+
+	if hookType == marshalHookType {
+		if err := callHook(sourceType, sourceValue, hookType, path); err != nil {
+			return err
+		}
+	}
+
+	switch sourceType.Kind() {
+	case reflect.Struct:
+		for i := 0; i < sourceType.NumField(); i++ {
+			field := sourceType.Field(i)
+			fieldPath := field.Name
+			if path != "" {
+				fieldPath = path + "." + field.Name
+			}
+
+			if err := d.runHooks(field.Type, sourceValue.Field(i), hookType, fieldPath); err != nil {
+				return err
+			}
+		}
+	case reflect.Array, reflect.Slice:
+		for i := 0; i < sourceValue.Len(); i++ {
+			if err := d.runHooks(sourceType.Elem(), sourceValue.Index(i), hookType, fmt.Sprintf("%v[%v]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if hookType == unmarshalHookType {
+		if err := callHook(sourceType, sourceValue, hookType, path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// callHook calls the given hook interface on a single value, if it is implemented by the value type.
+func callHook(sourceType reflect.Type, sourceValue reflect.Value, hookType reflect.Type, path string) error {
+	var hookValue reflect.Value
+	if sourceValue.CanAddr() && reflect.PointerTo(sourceType).Implements(hookType) {
+		hookValue = sourceValue.Addr()
+	} else if sourceType.Implements(hookType) {
+		hookValue = sourceValue
+	} else {
+		return nil
+	}
+
+	var err error
+	var hookName string
+	if hookType == marshalHookType {
+		hookName = "BeforeSSZMarshal"
+		err = hookValue.Interface().(MarshalHook).BeforeSSZMarshal()
+	} else {
+		hookName = "AfterSSZUnmarshal"
+		err = hookValue.Interface().(UnmarshalHook).AfterSSZUnmarshal()
+	}
+	if err != nil {
+		if path == "" {
+			return fmt.Errorf("%v hook failed: %v", hookName, err)
+		}
+		return fmt.Errorf("%v hook failed for field %v: %v", hookName, path, err)
+	}
+
+	return nil
+}
+
+// getHooks checks if the given type or any type nested in it implements the hook interfaces.
+func (d *DynSsz) getHooks(targetType reflect.Type) *cachedHooks {
+	for targetType.Kind() == reflect.Ptr {
+		targetType = targetType.Elem()
+	}
+
+	d.hooksMutex.RLock()
+	cached := d.hooksCache[targetType]
+	d.hooksMutex.RUnlock()
+	if cached != nil {
+		return cached
+	}
+
+	// only the result of the outermost call is cached, as the results of nested types that are still being resolved
+	// miss the hooks of the recursive types around them
+	hooks := d.collectHooks(targetType, map[reflect.Type]bool{})
+
+	d.hooksMutex.Lock()
+	d.hooksCache[targetType] = hooks
+	d.hooksMutex.Unlock()
+
+	return hooks
+}
+
+// collectHooks checks if the given type or any type nested in it implements the hook interfaces, without caching
+// the result.
+func (d *DynSsz) collectHooks(targetType reflect.Type, visited map[reflect.Type]bool) *cachedHooks {
+	for targetType.Kind() == reflect.Ptr {
+		targetType = targetType.Elem()
+	}
+
+	d.hooksMutex.RLock()
+	cached := d.hooksCache[targetType]
+	d.hooksMutex.RUnlock()
+	if cached != nil {
+		return cached
+	}
+
+	hooks := &cachedHooks{}
+	if visited[targetType] {
+		// recursion guard, the outer call collects the hooks of the type
+		return hooks
+	}
+	visited[targetType] = true
+
+	targetPtrType := reflect.PointerTo(targetType)
+	hooks.hasMarshalHooks = targetPtrType.Implements(marshalHookType)
+	hooks.hasUnmarshalHooks = targetPtrType.Implements(unmarshalHookType)
+
+	var nestedTypes []reflect.Type
+	switch targetType.Kind() {
+	case reflect.Struct:
+		for i := 0; i < targetType.NumField(); i++ {
+			nestedTypes = append(nestedTypes, targetType.Field(i).Type)
+		}
+	case reflect.Array, reflect.Slice:
+		nestedTypes = append(nestedTypes, targetType.Elem())
+	}
+	for _, nestedType := range nestedTypes {
+		nestedHooks := d.collectHooks(nestedType, visited)
+		hooks.hasMarshalHooks = hooks.hasMarshalHooks || nestedHooks.hasMarshalHooks
+		hooks.hasUnmarshalHooks = hooks.hasUnmarshalHooks || nestedHooks.hasUnmarshalHooks
+	}
+
+	return hooks
+}
@@ -0,0 +1,108 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+// slug_HooksStruct1 keeps the derived Count field in sync with its items.
+type slug_HooksStruct1 struct {
+	Count uint32
+	Items []*slug_HooksStruct2 `ssz-size:"?" dynssz-size:"?"`
+}
+
+type slug_HooksStruct2 struct {
+	Value  uint16
+	Double uint32
+}
+
+func (s *slug_HooksStruct1) BeforeSSZMarshal() error {
+	s.Count = uint32(len(s.Items))
+	return nil
+}
+
+func (s *slug_HooksStruct1) AfterSSZUnmarshal() error {
+	if s.Count != uint32(len(s.Items)) {
+		return fmt.Errorf("count mismatch")
+	}
+	return nil
+}
+
+func (s *slug_HooksStruct2) BeforeSSZMarshal() error {
+	s.Double = uint32(s.Value) * 2
+	return nil
+}
+
+func (s *slug_HooksStruct2) AfterSSZUnmarshal() error {
+	if s.Double != uint32(s.Value)*2 {
+		return fmt.Errorf("double mismatch")
+	}
+	return nil
+}
+
+func TestHooks(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+
+	payload := &slug_HooksStruct1{Items: []*slug_HooksStruct2{{Value: 1}, {Value: 2}}}
+	buf, err := dynssz.MarshalSSZ(payload)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	if expected := fromHex("0x0200000008000000010002000000020004000000"); !bytes.Equal(buf, expected) {
+		t.Errorf("unexpected encoding: 0x%x, wanted 0x%x", buf, expected)
+	}
+
+	if err := dynssz.UnmarshalSSZ(&slug_HooksStruct1{}, buf); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+
+	// corrupt the derived field of the second item
+	buf[16] = 0x05
+	err = dynssz.UnmarshalSSZ(&slug_HooksStruct1{}, buf)
+	if err == nil || !strings.Contains(err.Error(), "AfterSSZUnmarshal hook failed for field Items[1]") {
+		t.Errorf("expected hook error for Items[1], got %v", err)
+	}
+}
+
+// slug_HooksNode and slug_HooksLink are mutually recursive, only slug_HooksNode implements a hook.
+type slug_HooksNode struct {
+	Called bool
+	Links  []*slug_HooksLink `ssz-max:"4"`
+}
+
+type slug_HooksLink struct {
+	Node *slug_HooksNode
+}
+
+func (s *slug_HooksNode) BeforeSSZMarshal() error {
+	s.Called = true
+	return nil
+}
+
+func TestHooksRecursiveType(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+
+	// recursive types can not be encoded, which must be reported before the hooks walk the value
+	for _, source := range []any{&slug_HooksNode{}, &slug_HooksLink{Node: &slug_HooksNode{}}} {
+		if _, err := dynssz.MarshalSSZ(source); err == nil || !strings.Contains(err.Error(), "recursive type") {
+			t.Errorf("expected recursive type error for %T, got %v", source, err)
+		}
+	}
+
+	// cyclic values must not be walked endlessly
+	node := &slug_HooksNode{}
+	node.Links = []*slug_HooksLink{{Node: node}}
+	if _, err := dynssz.MarshalSSZ(node); err == nil || !strings.Contains(err.Error(), "recursive type") {
+		t.Errorf("expected recursive type error for cyclic value, got %v", err)
+	}
+	if node.Called {
+		t.Errorf("hook called for recursive type")
+	}
+}
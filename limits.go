@@ -0,0 +1,273 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"fmt"
+	"math/bits"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// StaticSizeOf calculates the SSZ encoded size of the given type without requiring an instance of it.
+// The size annotations are resolved with the spec values of the DynSsz instance.
+// Returns the size and true if all values of the type have the same encoded size, or 0 and false for dynamic types.
+// Returns an error if the type is not supported by SSZ or has invalid size annotations.
+func (d *DynSsz) StaticSizeOf(t reflect.Type) (int, bool, error) {
+	size, _, err := d.getSszSize(t, []sszSizeHint{})
+	if err != nil {
+		return 0, false, err
+	}
+	if size < 0 {
+		return 0, false, nil
+	}
+
+	return size, true, nil
+}
+
+// LimitsOf calculates the range of SSZ encoded sizes of the given type without requiring an instance of it.
+// This allows validating buffer sizes or setting message size caps before any data exists.
+// The minimum size is the size of the zero value of the type, where all lists are empty. The maximum size is only
+// returned for static types and -1 for types that contain lists, use MaxSizeSSZ for limits based on 'ssz-max' tags.
+// Returns an error if the type is not supported by SSZ or has invalid size annotations.
+func (d *DynSsz) LimitsOf(t reflect.Type) (int, int, error) {
+	size, _, err := d.getSszSize(t, []sszSizeHint{})
+	if err != nil {
+		return 0, 0, err
+	}
+	if size >= 0 {
+		return size, size, nil
+	}
+
+	minSize, err := d.getSszMinSize(t, []sszSizeHint{})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return minSize, -1, nil
+}
+
+// getSszMinSize calculates the smallest SSZ encoded size of a type, which is the size of its zero value.
+func (d *DynSsz) getSszMinSize(targetType reflect.Type, sizeHints []sszSizeHint) (int, error) {
+	if targetType.Kind() == reflect.Ptr {
+		targetType = targetType.Elem()
+	}
+
+	size, _, err := d.getSszSize(targetType, sizeHints)
+	if err != nil {
+		return 0, err
+	}
+	if size >= 0 {
+		return size, nil
+	}
+
+	childSizeHints := []sszSizeHint{}
+	if len(sizeHints) > 1 {
+		childSizeHints = sizeHints[1:]
+	}
+
+	switch targetType.Kind() {
+	case reflect.Struct:
+		minSize := 0
+		for i := 0; i < targetType.NumField(); i++ {
+			field := d.getStructField(targetType, i)
+			fieldSize, _, fieldSizeHints, err := d.getSszFieldSize(&field)
+			if err != nil {
+				return 0, err
+			}
+
+			if fieldSize < 0 {
+				fieldMinSize, err := d.getSszMinSize(field.Type, fieldSizeHints)
+				if err != nil {
+					return 0, fmt.Errorf("failed calculating size of field %v: %v", field.Name, err)
+				}
+
+				// dynamic field, add 4 bytes for offset
+				fieldSize = fieldMinSize + 4
+			}
+			minSize += fieldSize
+		}
+		return minSize, nil
+	case reflect.Array, reflect.Slice:
+		itemCount := 0
+		if targetType.Kind() == reflect.Array {
+			itemCount = targetType.Len()
+		} else if len(sizeHints) > 0 && !sizeHints[0].dynamic {
+			itemCount = int(sizeHints[0].size)
+		}
+		if itemCount == 0 {
+			return 0, nil
+		}
+
+		// vectors of dynamic items, each item is prefixed by its offset
+		itemMinSize, err := d.getSszMinSize(targetType.Elem(), childSizeHints)
+		if err != nil {
+			return 0, err
+		}
+		return mulSize(itemMinSize+4, uint64(itemCount))
+	default:
+		return 0, fmt.Errorf("unhandled reflection kind in size check: %v", targetType.Kind())
+	}
+}
+
+// MaxSizeSSZ calculates the worst-case SSZ encoded size of the given type, with all lists at their maximum length.
+// Networking layers can use it to set hard caps for received messages of a type.
+// The maximum list lengths are taken from the 'ssz-max' tags (the fastssz format) of the fields. Like 'dynssz-size'
+// for vector sizes, 'dynssz-max' tags can override the maximum list lengths with spec value expressions.
+// Returns an error if the type contains a list without maximum length or the size exceeds the uint64 range.
+func (d *DynSsz) MaxSizeSSZ(t reflect.Type) (uint64, error) {
+	return d.getSszMaxSize(t, []sszSizeHint{}, []sszMaxHint{})
+}
+
+// sszMaxHint holds the maximum length of a list dimension, derived from 'ssz-max' and 'dynssz-max' tag annotations.
+type sszMaxHint struct {
+	max   uint64
+	known bool
+}
+
+// getSszMaxTag parses the 'ssz-max' and 'dynssz-max' tag annotations of a struct field.
+func (d *DynSsz) getSszMaxTag(field *reflect.StructField) ([]sszMaxHint, error) {
+	maxHints := []sszMaxHint{}
+
+	if sszMaxStr, hasSszMax := field.Tag.Lookup("ssz-max"); hasSszMax {
+		for _, maxStr := range strings.Split(sszMaxStr, ",") {
+			maxHint := sszMaxHint{}
+			if maxStr != "?" {
+				maxInt, err := strconv.ParseUint(maxStr, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("error parsing ssz-max tag for '%v' field: %v", field.Name, err)
+				}
+				maxHint.max = maxInt
+				maxHint.known = true
+			}
+			maxHints = append(maxHints, maxHint)
+		}
+	}
+
+	if dynSszMaxStr, hasDynSszMax := field.Tag.Lookup("dynssz-max"); hasDynSszMax {
+		for i, maxStr := range strings.Split(dynSszMaxStr, ",") {
+			maxHint := sszMaxHint{}
+			if maxStr == "?" {
+				continue
+			} else if maxInt, err := strconv.ParseUint(maxStr, 10, 64); err == nil {
+				maxHint.max = maxInt
+				maxHint.known = true
+			} else {
+				ok, specVal, err := d.getSpecValue(maxStr)
+				if err != nil {
+					return nil, fmt.Errorf("error parsing dynssz-max tag for '%v' field: %w", field.Name, err)
+				}
+				if !ok {
+					// unknown spec value, fallback to the ssz-max default of this dimension
+					continue
+				}
+				maxHint.max = specVal
+				maxHint.known = true
+			}
+
+			for len(maxHints) <= i {
+				maxHints = append(maxHints, sszMaxHint{})
+			}
+			maxHints[i] = maxHint
+		}
+	}
+
+	return maxHints, nil
+}
+
+// getSszMaxSize calculates the largest SSZ encoded size of a type, with all lists at their maximum length.
+func (d *DynSsz) getSszMaxSize(targetType reflect.Type, sizeHints []sszSizeHint, maxHints []sszMaxHint) (uint64, error) {
+	if targetType.Kind() == reflect.Ptr {
+		targetType = targetType.Elem()
+	}
+
+	size, _, err := d.getSszSize(targetType, sizeHints)
+	if err != nil {
+		return 0, err
+	}
+	if size >= 0 {
+		return uint64(size), nil
+	}
+
+	childSizeHints := []sszSizeHint{}
+	if len(sizeHints) > 1 {
+		childSizeHints = sizeHints[1:]
+	}
+	childMaxHints := []sszMaxHint{}
+	if len(maxHints) > 1 {
+		childMaxHints = maxHints[1:]
+	}
+
+	switch targetType.Kind() {
+	case reflect.Struct:
+		maxSize := uint64(0)
+		for i := 0; i < targetType.NumField(); i++ {
+			field := d.getStructField(targetType, i)
+			fieldSize, _, fieldSizeHints, err := d.getSszFieldSize(&field)
+			if err != nil {
+				return 0, err
+			}
+
+			fieldMaxSize := uint64(fieldSize)
+			if fieldSize < 0 {
+				fieldMaxHints, err := d.getSszMaxTag(&field)
+				if err != nil {
+					return 0, err
+				}
+				fieldMaxSize, err = d.getSszMaxSize(field.Type, fieldSizeHints, fieldMaxHints)
+				if err != nil {
+					return 0, fmt.Errorf("failed calculating max size of field %v: %v", field.Name, err)
+				}
+
+				// dynamic field, add 4 bytes for offset
+				fieldMaxSize += 4
+			}
+
+			var carry uint64
+			maxSize, carry = bits.Add64(maxSize, fieldMaxSize, 0)
+			if carry != 0 {
+				return 0, fmt.Errorf("max size of type %v exceeds uint64 range", targetType)
+			}
+		}
+		return maxSize, nil
+	case reflect.Array, reflect.Slice:
+		var itemCount uint64
+		if targetType.Kind() == reflect.Array {
+			itemCount = uint64(targetType.Len())
+		} else if len(sizeHints) > 0 && !sizeHints[0].dynamic {
+			itemCount = sizeHints[0].size
+		} else if len(maxHints) > 0 && maxHints[0].known {
+			itemCount = maxHints[0].max
+		} else {
+			return 0, fmt.Errorf("list %v has no ssz-max limit", targetType)
+		}
+
+		itemSize, _, err := d.getSszSize(targetType.Elem(), childSizeHints)
+		if err != nil {
+			return 0, err
+		}
+
+		var itemMaxSize uint64
+		if itemSize >= 0 {
+			itemMaxSize = uint64(itemSize)
+		} else {
+			itemMaxSize, err = d.getSszMaxSize(targetType.Elem(), childSizeHints, childMaxHints)
+			if err != nil {
+				return 0, err
+			}
+
+			// dynamic items, each item is prefixed by its offset
+			itemMaxSize += 4
+		}
+
+		hi, maxSize := bits.Mul64(itemCount, itemMaxSize)
+		if hi != 0 {
+			return 0, fmt.Errorf("max size of type %v exceeds uint64 range", targetType)
+		}
+		return maxSize, nil
+	default:
+		return 0, fmt.Errorf("unhandled reflection kind in size check: %v", targetType.Kind())
+	}
+}
@@ -0,0 +1,129 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_LimitsStatic struct {
+	F1 uint32
+	F2 []uint8 `ssz-size:"4" dynssz-size:"SPEC_A"`
+}
+
+type slug_LimitsDynamic struct {
+	F1 uint16
+	F2 []uint8   `ssz-size:"?" dynssz-size:"?"`
+	F3 [][]uint8 `ssz-size:"2,?" dynssz-size:"2,?"`
+	F4 *slug_LimitsStatic
+}
+
+func TestStaticSizeOf(t *testing.T) {
+	dynssz := NewDynSsz(map[string]any{"SPEC_A": uint64(8)})
+
+	size, isStatic, err := dynssz.StaticSizeOf(reflect.TypeOf(slug_LimitsStatic{}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !isStatic || size != 12 {
+		t.Errorf("unexpected static size: %v (static: %v), wanted 12", size, isStatic)
+	}
+
+	_, isStatic, err = dynssz.StaticSizeOf(reflect.TypeOf(&slug_LimitsDynamic{}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if isStatic {
+		t.Errorf("expected dynamic type to be reported as non static")
+	}
+
+	if _, _, err := dynssz.StaticSizeOf(reflect.TypeOf(int(0))); err == nil {
+		t.Errorf("expected error for unsupported type")
+	}
+}
+
+func TestLimitsOf(t *testing.T) {
+	dynssz := NewDynSsz(map[string]any{"SPEC_A": uint64(8)})
+
+	minSize, maxSize, err := dynssz.LimitsOf(reflect.TypeOf(slug_LimitsStatic{}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if minSize != 12 || maxSize != 12 {
+		t.Errorf("unexpected limits: %v-%v, wanted 12-12", minSize, maxSize)
+	}
+
+	minSize, maxSize, err = dynssz.LimitsOf(reflect.TypeOf(slug_LimitsDynamic{}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the minimum size is the size of the zero value
+	zeroSsz, err := dynssz.MarshalSSZ(&slug_LimitsDynamic{})
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	if minSize != len(zeroSsz) || maxSize != -1 {
+		t.Errorf("unexpected limits: %v-%v, wanted %v-(-1)", minSize, maxSize, len(zeroSsz))
+	}
+}
+
+type slug_MaxSizeStruct struct {
+	F1 uint16
+	F2 []uint8              `ssz-max:"32"`
+	F3 [][]uint8            `ssz-max:"4,16" dynssz-max:"SPEC_A,?"`
+	F4 []*slug_LimitsStatic `ssz-size:"2"`
+	F5 []slug_MaxSizeItem   `ssz-max:"3"`
+}
+
+type slug_MaxSizeItem struct {
+	F1 []uint16 `ssz-max:"10"`
+}
+
+func TestMaxSizeSSZ(t *testing.T) {
+	dynssz := NewDynSsz(map[string]any{"SPEC_A": uint64(8)})
+
+	maxSize, err := dynssz.MaxSizeSSZ(reflect.TypeOf(&slug_MaxSizeStruct{}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// F1: 2, F2: 4 + 32, F3: 4 + 8 * (4 + 16), F4: 2 * 12, F5: 4 + 3 * (4 + 4 + 20)
+	expected := uint64(2 + 36 + 164 + 24 + 88)
+	if maxSize != expected {
+		t.Errorf("unexpected max size: %v, wanted %v", maxSize, expected)
+	}
+
+	// encoding of a maximum size instance must match
+	obj := &slug_MaxSizeStruct{
+		F2: make([]uint8, 32),
+		F3: make([][]uint8, 8),
+		F5: make([]slug_MaxSizeItem, 3),
+	}
+	for i := range obj.F3 {
+		obj.F3[i] = make([]uint8, 16)
+	}
+	for i := range obj.F5 {
+		obj.F5[i].F1 = make([]uint16, 10)
+	}
+	size, err := dynssz.SizeSSZ(obj)
+	if err != nil {
+		t.Fatalf("unexpected size error: %v", err)
+	}
+	if uint64(size) != maxSize {
+		t.Errorf("unexpected size of maximum instance: %v, wanted %v", size, maxSize)
+	}
+
+	size64, err := dynssz.MaxSizeSSZ(reflect.TypeOf(slug_LimitsStatic{}))
+	if err != nil || size64 != 12 {
+		t.Errorf("unexpected max size of static type: %v (%v), wanted 12", size64, err)
+	}
+
+	if _, err := dynssz.MaxSizeSSZ(reflect.TypeOf(slug_LimitsDynamic{})); err == nil {
+		t.Errorf("expected error for list without ssz-max")
+	}
+}
@@ -0,0 +1,164 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GetTypeWarnings checks the struct tag annotations of the given type and all types nested in it for suspicious
+// but non-fatal configurations, like 'dynssz-size' tags without a 'ssz-size' fallback, lists without a 'ssz-max' or
+// 'dynssz-max' limit or size annotations that are ignored for the annotated field type. These issues do not break encoding or decoding, but often indicate mistakes
+// that lead to unexpected encodings on some presets.
+// The 'targetType' parameter accepts either an instance or a reflect.Type value of the type to check.
+// Returns the warnings in a deterministic order, prefixed with the path to the affected field, or an error if the
+// tag annotations can not be parsed.
+func (d *DynSsz) GetTypeWarnings(targetType any) ([]string, error) {
+	sszType, ok := targetType.(reflect.Type)
+	if !ok {
+		sszType = reflect.TypeOf(targetType)
+	}
+	for sszType.Kind() == reflect.Ptr {
+		sszType = sszType.Elem()
+	}
+
+	warnings := []string{}
+	err := d.lintType(sszType, getTypeDocName(sszType), map[reflect.Type]bool{}, &warnings)
+	if err != nil {
+		return nil, err
+	}
+
+	return warnings, nil
+}
+
+// lintType collects warnings for the fields of the given type and recurses into nested struct types.
+func (d *DynSsz) lintType(targetType reflect.Type, path string, visited map[reflect.Type]bool, warnings *[]string) error {
+	for targetType.Kind() == reflect.Ptr || targetType.Kind() == reflect.Array || targetType.Kind() == reflect.Slice {
+		targetType = targetType.Elem()
+	}
+	if targetType.Kind() != reflect.Struct || visited[targetType] {
+		return nil
+	}
+	visited[targetType] = true
+
+	if schema := d.getSchema(targetType); schema != nil {
+		schemaFields := make([]string, 0, len(schema))
+		for name := range schema {
+			schemaFields = append(schemaFields, name)
+		}
+		sort.Strings(schemaFields)
+
+		for _, name := range schemaFields {
+			if _, exists := targetType.FieldByName(name); !exists {
+				addWarning(warnings, path, fmt.Sprintf("SSZSchema references unknown field %v", name))
+			}
+		}
+	}
+
+	for i := 0; i < targetType.NumField(); i++ {
+		field := d.getStructField(targetType, i)
+		fieldPath := fmt.Sprintf("%v.%v", path, field.Name)
+
+		sszSizeStr, hasSszSize := field.Tag.Lookup("ssz-size")
+		dynSszSizeStr, hasDynSszSize := field.Tag.Lookup("dynssz-size")
+
+		sszSizeCount := 0
+		if hasSszSize {
+			sszSizeCount = len(strings.Split(sszSizeStr, ","))
+		}
+		dynSszSizeCount := 0
+		if hasDynSszSize {
+			dynSszSizeCount = len(strings.Split(dynSszSizeStr, ","))
+		}
+
+		if hasDynSszSize && !hasSszSize {
+			addWarning(warnings, fieldPath, "dynssz-size tag without ssz-size fallback, fastssz defaults are unknown")
+		} else if dynSszSizeCount > sszSizeCount {
+			addWarning(warnings, fieldPath, fmt.Sprintf("dynssz-size tag has more dimensions (%v) than the ssz-size fallback (%v)", dynSszSizeCount, sszSizeCount))
+		}
+
+		sizeHints, err := d.getSszSizeTag(&field)
+		if err != nil {
+			return err
+		}
+
+		if sszMaxStr, hasSszMax := field.Tag.Lookup("ssz-max"); hasSszMax {
+			// ssz-max is not used by dynssz, but must be consistent with the fixed ssz-size dimensions for fastssz
+			sszSizeDims := []string{}
+			if hasSszSize {
+				sszSizeDims = strings.Split(sszSizeStr, ",")
+			}
+			for dim, maxStr := range strings.Split(sszMaxStr, ",") {
+				if maxStr == "?" {
+					continue
+				}
+				if _, err := strconv.ParseUint(maxStr, 10, 64); err != nil {
+					return fmt.Errorf("error parsing ssz-max tag for '%v' field (dimension %v): %v", field.Name, dim, err)
+				}
+				if dim < len(sszSizeDims) && sszSizeDims[dim] != "?" && sszSizeDims[dim] != maxStr {
+					addWarning(warnings, fieldPath, fmt.Sprintf("ssz-max %v for dimension %v conflicts with fixed ssz-size %v", maxStr, dim, sszSizeDims[dim]))
+				}
+			}
+		}
+
+		// lists need a limit for their hash tree root, which mixes in the length with the limit based tree depth
+		maxHints, err := d.getSszMaxTag(&field)
+		if err != nil {
+			return err
+		}
+		if len(sizeHints) == 0 || !sizeHints[0].raw {
+			listType := field.Type
+			for dim := 0; ; dim++ {
+				for listType.Kind() == reflect.Ptr {
+					listType = listType.Elem()
+				}
+				if listType.Kind() != reflect.Slice && listType.Kind() != reflect.Array {
+					break
+				}
+
+				isList := listType.Kind() == reflect.Slice && (dim >= len(sizeHints) || sizeHints[dim].dynamic)
+				if isList && (dim >= len(maxHints) || !maxHints[dim].known) {
+					addWarning(warnings, fieldPath, fmt.Sprintf("list dimension %v has no ssz-max or dynssz-max limit, its hash tree root is undefined", dim))
+				}
+				listType = listType.Elem()
+			}
+		}
+
+		fieldType := field.Type
+		for dim, sizeHint := range sizeHints {
+			for fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+
+			switch fieldType.Kind() {
+			case reflect.Slice:
+			case reflect.Array:
+				if !sizeHint.dynamic && sizeHint.size != uint64(fieldType.Len()) {
+					addWarning(warnings, fieldPath, fmt.Sprintf("size annotation %v for dimension %v is ignored for array type %v", sizeHint.size, dim, fieldType))
+				}
+			default:
+				addWarning(warnings, fieldPath, fmt.Sprintf("size annotation for dimension %v is ignored for non-list type %v", dim, fieldType))
+			}
+
+			if fieldType.Kind() != reflect.Slice && fieldType.Kind() != reflect.Array {
+				break
+			}
+			fieldType = fieldType.Elem()
+		}
+
+		if err := d.lintType(field.Type, fieldPath, visited, warnings); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func addWarning(warnings *[]string, path string, message string) {
+	*warnings = append(*warnings, fmt.Sprintf("%v: %v", path, message))
+}
@@ -0,0 +1,107 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_LintStruct1 struct {
+	F1 []uint8   `dynssz-size:"SPEC_A"`
+	F2 [][]uint8 `ssz-size:"4" dynssz-size:"SPEC_A,SPEC_B"`
+	F3 uint64    `ssz-size:"8"`
+	F4 [4]uint8  `ssz-size:"5"`
+	F5 []uint8   `ssz-size:"32" dynssz-size:"SPEC_A"`
+	F6 *slug_LintStruct2
+	F7 [][]uint8 `ssz-size:"?,32" ssz-max:"64,16"`
+	F8 [][]uint8 `ssz-size:"?,32" ssz-max:"64,32"`
+}
+
+type slug_LintStruct2 struct {
+	F1 [][32]uint8 `ssz-size:"?,32"`
+	F2 []uint16    `ssz-size:"4,2"`
+}
+
+func TestGetTypeWarnings(t *testing.T) {
+	dynssz := NewDynSsz(map[string]any{"SPEC_A": uint64(16), "SPEC_B": uint64(2)})
+
+	warnings, err := dynssz.GetTypeWarnings(slug_LintStruct1{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"slug_LintStruct1.F1: dynssz-size tag without ssz-size fallback, fastssz defaults are unknown",
+		"slug_LintStruct1.F2: dynssz-size tag has more dimensions (2) than the ssz-size fallback (1)",
+		"slug_LintStruct1.F3: size annotation for dimension 0 is ignored for non-list type uint64",
+		"slug_LintStruct1.F4: size annotation 5 for dimension 0 is ignored for array type [4]uint8",
+		"slug_LintStruct1.F6.F1: list dimension 0 has no ssz-max or dynssz-max limit, its hash tree root is undefined",
+		"slug_LintStruct1.F6.F2: size annotation for dimension 1 is ignored for non-list type uint16",
+		"slug_LintStruct1.F7: ssz-max 16 for dimension 1 conflicts with fixed ssz-size 32",
+	}
+	if !reflect.DeepEqual(warnings, expected) {
+		t.Errorf("unexpected warnings:\n%v\nwanted:\n%v", warnings, expected)
+	}
+}
+
+type slug_LintListStruct struct {
+	F1 []uint64
+	F2 []uint64   `ssz-max:"16"`
+	F3 []uint64   `dynssz-max:"SPEC_A"`
+	F4 [][]uint8  `ssz-max:"4"`
+	F5 [][]uint8  `ssz-size:"?,32" ssz-max:"4"`
+	F6 [4][]uint8 `ssz-max:"?,8"`
+	F7 []uint8    `ssz-type:"raw"`
+}
+
+func TestGetTypeWarningsListLimits(t *testing.T) {
+	dynssz := NewDynSsz(map[string]any{"SPEC_A": uint64(16)})
+
+	warnings, err := dynssz.GetTypeWarnings(slug_LintListStruct{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"slug_LintListStruct.F1: list dimension 0 has no ssz-max or dynssz-max limit, its hash tree root is undefined",
+		"slug_LintListStruct.F4: list dimension 1 has no ssz-max or dynssz-max limit, its hash tree root is undefined",
+	}
+	if !reflect.DeepEqual(warnings, expected) {
+		t.Errorf("unexpected warnings:\n%v\nwanted:\n%v", warnings, expected)
+	}
+}
+
+type slug_LintStruct3 struct {
+	F1 [][]uint8 `ssz-size:"?,4" dynssz-size:"UNKNOWN_SPEC,SPEC_A"`
+}
+
+type slug_LintStruct4 struct {
+	F1 [][]uint8 `ssz-size:"?" dynssz-size:"?,UNKNOWN_SPEC"`
+}
+
+func TestPartialDynSszSizeTags(t *testing.T) {
+	dynssz := NewDynSsz(map[string]any{"SPEC_A": uint64(2)})
+
+	// unresolved dimensions fall back to their ssz-size default, later dimensions are still resolved
+	payload := &slug_LintStruct3{F1: [][]uint8{{1, 2}}}
+	buf, err := dynssz.MarshalSSZ(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := fromHex("0x040000000102"); !reflect.DeepEqual(buf, expected) {
+		t.Errorf("unexpected encoding: 0x%x, wanted 0x%x", buf, expected)
+	}
+
+	// unresolved dimensions without ssz-size default fall back to the fastssz defaults, unless spec values are required
+	if _, err := dynssz.MarshalSSZ(&slug_LintStruct4{}); err != nil {
+		t.Errorf("unexpected error for unresolved dimension without ssz-size fallback: %v", err)
+	}
+	dynssz = NewDynSsz(map[string]any{"SPEC_A": uint64(2)}, WithRequireSpecValues())
+	if _, err := dynssz.MarshalSSZ(&slug_LintStruct4{}); err == nil {
+		t.Errorf("expected error for unresolved dimension with RequireSpecValues")
+	}
+}
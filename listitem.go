@@ -0,0 +1,68 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// UnmarshalListItem decodes a single item of an SSZ-encoded top-level list into the target object, without decoding
+// the other items. The byte range of the item is calculated directly from the item size for lists with static size
+// items, or from the item offsets for lists with dynamic size items. This allows fetching e.g. a single validator
+// from a serialized registry without decoding millions of entries.
+// The 'target' parameter must be a pointer to a value of the list item type.
+// Returns an error if the index is out of range, the list encoding is invalid or decoding the item fails.
+func (d *DynSsz) UnmarshalListItem(target any, ssz []byte, index int) error {
+	targetType := reflect.TypeOf(target)
+	if targetType == nil || targetType.Kind() != reflect.Ptr {
+		return fmt.Errorf("target must be a pointer, got %v", targetType)
+	}
+
+	itemSize, _, err := d.getSszSize(targetType.Elem(), []sszSizeHint{})
+	if err != nil {
+		return err
+	}
+
+	var startOffset, endOffset int
+	if itemSize == 0 {
+		return fmt.Errorf("list items of type %v have no size", targetType.Elem())
+	} else if itemSize > 0 {
+		itemCount, ok := divideInt(len(ssz), itemSize)
+		if !ok {
+			return fmt.Errorf("invalid list length, expected multiple of %v, got %v", itemSize, len(ssz))
+		}
+		if index < 0 || index >= itemCount {
+			return fmt.Errorf("list index %v out of range (items: %v)", index, itemCount)
+		}
+
+		startOffset = index * itemSize
+		endOffset = startOffset + itemSize
+	} else {
+		if len(ssz) < 4 {
+			return fmt.Errorf("list index %v out of range (items: 0)", index)
+		}
+
+		firstOffset := readOffsetInt(ssz[0:4])
+		if firstOffset%4 != 0 || firstOffset > len(ssz) {
+			return ErrOffset
+		}
+
+		itemCount := firstOffset / 4
+		if index < 0 || index >= itemCount {
+			return fmt.Errorf("list index %v out of range (items: %v)", index, itemCount)
+		}
+
+		startOffset = readOffsetInt(ssz[index*4 : (index+1)*4])
+		endOffset = len(ssz)
+		if index < itemCount-1 {
+			endOffset = readOffsetInt(ssz[(index+1)*4 : (index+2)*4])
+		}
+		if startOffset < firstOffset || startOffset > endOffset || endOffset > len(ssz) {
+			return ErrOffset
+		}
+	}
+
+	return d.UnmarshalSSZ(target, ssz[startOffset:endOffset])
+}
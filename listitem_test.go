@@ -0,0 +1,67 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_ListItemStatic struct {
+	F1 uint16
+	F2 [2]uint8
+}
+
+type slug_ListItemDynamic struct {
+	F1 uint16
+	F2 []uint8 `ssz-size:"?" dynssz-size:"?"`
+}
+
+func TestUnmarshalListItem(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+
+	staticList := []slug_ListItemStatic{{1, [2]uint8{2, 3}}, {4, [2]uint8{5, 6}}, {7, [2]uint8{8, 9}}}
+	ssz, err := dynssz.MarshalSSZ(staticList)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+
+	staticItem := &slug_ListItemStatic{}
+	if err := dynssz.UnmarshalListItem(staticItem, ssz, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(*staticItem, staticList[1]) {
+		t.Errorf("unexpected item: %+v, wanted %+v", staticItem, staticList[1])
+	}
+	if err := dynssz.UnmarshalListItem(staticItem, ssz, 3); err == nil {
+		t.Errorf("expected error for index out of range")
+	}
+
+	dynamicList := []*slug_ListItemDynamic{{1, []uint8{2}}, {3, []uint8{}}, {5, []uint8{6, 7, 8}}}
+	ssz, err = dynssz.MarshalSSZ(dynamicList)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+
+	for idx, expected := range dynamicList {
+		dynamicItem := &slug_ListItemDynamic{}
+		if err := dynssz.UnmarshalListItem(dynamicItem, ssz, idx); err != nil {
+			t.Fatalf("unexpected error for item %v: %v", idx, err)
+		}
+		if !reflect.DeepEqual(dynamicItem, expected) {
+			t.Errorf("unexpected item %v: %+v, wanted %+v", idx, dynamicItem, expected)
+		}
+	}
+	if err := dynssz.UnmarshalListItem(&slug_ListItemDynamic{}, ssz, -1); err == nil {
+		t.Errorf("expected error for negative index")
+	}
+
+	// corrupt offset of the last item
+	ssz[8] = 0xff
+	if err := dynssz.UnmarshalListItem(&slug_ListItemDynamic{}, ssz, 2); err != ErrOffset {
+		t.Errorf("expected ErrOffset, got %v", err)
+	}
+}
@@ -0,0 +1,255 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"reflect"
+
+	"github.com/pk910/dynamic-ssz/gindex"
+)
+
+// hashTreeRooter is the subset of the fastssz HashRoot interface needed to calculate the root of single list items.
+type hashTreeRooter interface {
+	HashTreeRoot() ([32]byte, error)
+}
+
+var hashTreeRooterType = reflect.TypeOf((*hashTreeRooter)(nil)).Elem()
+
+// zeroHashes holds the roots of zero filled merkle trees by depth.
+var zeroHashes = func() [65][32]byte {
+	hashes := [65][32]byte{}
+	for i := 1; i < len(hashes); i++ {
+		hashes[i] = hashPair(hashes[i-1], hashes[i-1])
+	}
+	return hashes
+}()
+
+// HashTreeRootFromChan calculates the hash tree root of a list of items that arrive on the given channel, without
+// holding the list in memory. The items are merkleized incrementally as they arrive, so the root of derived lists
+// (e.g. a filtered validator list) can be calculated while the items are produced.
+// The 'limit' parameter is the maximum number of items of the list type. The root is calculated once the channel is
+// closed. dynssz does not implement merkleization of containers itself, so T must implement the fastssz HashRoot
+// interface (via pointer receiver or directly) and must be unaffected by the spec values of the DynSsz instance.
+// Returns an error if the item type is not supported, an item root can not be calculated or the channel yields more
+// than 'limit' items. On error the channel is not drained.
+func HashTreeRootFromChan[T any](ds *DynSsz, ch <-chan T, limit uint64) ([32]byte, error) {
+	return HashTreeRootFromChanCtx(context.Background(), ds, ch, limit)
+}
+
+// HashTreeRootFromChanCtx calculates the hash tree root of a list of items that arrive on the given channel like
+// HashTreeRootFromChan, but aborts once the given context is done. The context is checked between items and while
+// waiting for the next item, which bounds the time request scoped callers spend on adversarially large lists.
+// Returns the context error if the context is done before the channel is closed, or an error like
+// HashTreeRootFromChan.
+func HashTreeRootFromChanCtx[T any](ctx context.Context, ds *DynSsz, ch <-chan T, limit uint64) ([32]byte, error) {
+	hasher, err := hashListFromChan(ctx, ds, ch, limit)
+	if err != nil {
+		return [32]byte{}, err
+	}
+
+	return hasher.root(), nil
+}
+
+// LengthMixinProof is the merkle proof of the length of a list against the hash tree root of the list. The length is
+// mixed into the list root as the right sibling of the root of the list items, so the proof consists of that single
+// sibling. Light clients use it to prove list lengths (e.g. the number of validators) without the list items.
+// The proof is relative to the list root. Proofs against the root of a containing object can be built by prepending
+// the proof of the list root within that object, with the generalized indices combined via gindex.Concat.
+type LengthMixinProof struct {
+	// Root is the hash tree root of the list.
+	Root [32]byte
+	// Length is the number of items of the list.
+	Length uint64
+	// Leaf is the length mixin leaf, the little endian length padded to 32 bytes.
+	Leaf [32]byte
+	// GIndex is the generalized index of the leaf relative to the list root.
+	GIndex uint64
+	// Branch holds the sibling nodes from the leaf up to the list root.
+	Branch [][32]byte
+}
+
+// Verify checks that the proof leads from the length mixin leaf to the list root.
+func (p *LengthMixinProof) Verify() bool {
+	if p.GIndex != gindex.Child(gindex.Root, true) || len(p.Branch) != 1 {
+		return false
+	}
+
+	var leaf [32]byte
+	binary.LittleEndian.PutUint64(leaf[:8], p.Length)
+	return leaf == p.Leaf && hashPair(p.Branch[0], p.Leaf) == p.Root
+}
+
+// LengthMixinProofFromChan calculates the hash tree root of a list of items that arrive on the given channel like
+// HashTreeRootFromChan, and returns the proof of the list length against that root.
+// Returns an error like HashTreeRootFromChan.
+func LengthMixinProofFromChan[T any](ds *DynSsz, ch <-chan T, limit uint64) (*LengthMixinProof, error) {
+	hasher, err := hashListFromChan(context.Background(), ds, ch, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	dataRoot := hasher.dataRoot()
+	leaf := hasher.lengthLeaf()
+	return &LengthMixinProof{
+		Root:   hashPair(dataRoot, leaf),
+		Length: hasher.count,
+		Leaf:   leaf,
+		GIndex: gindex.Child(gindex.Root, true),
+		Branch: [][32]byte{dataRoot},
+	}, nil
+}
+
+// hashListFromChan merkleizes the items that arrive on the given channel into a listHasher, until the channel is
+// closed or the context is done.
+func hashListFromChan[T any](ctx context.Context, ds *DynSsz, ch <-chan T, limit uint64) (*listHasher, error) {
+	itemType := reflect.TypeOf((*T)(nil)).Elem()
+	valueType := itemType
+	if valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+	}
+
+	fastsszCompat, err := ds.getFastsszCompatibility(valueType, []sszSizeHint{})
+	if err != nil {
+		return nil, fmt.Errorf("failed checking fastssz compatibility: %v", err)
+	}
+
+	ds.fastsszCompatMutex.Lock()
+	compatFlags, hasCompatFlags := ds.compatFlags[valueType]
+	ds.fastsszCompatMutex.Unlock()
+
+	isHashRoot := reflect.PointerTo(valueType).Implements(hashTreeRooterType)
+	if hasCompatFlags && compatFlags&SszCompatFlagHashRoot == 0 {
+		isHashRoot = false
+	}
+	if ds.NoFastSsz || !isHashRoot {
+		return nil, fmt.Errorf("type %v does not support hash tree root calculation via fastssz", itemType)
+	}
+	if fastsszCompat.hasDynamicSpecValues {
+		return nil, fmt.Errorf("type %v is affected by dynamic spec values, fastssz hash tree root would be invalid", itemType)
+	}
+
+	hasher := newListHasher(limit)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var item T
+		var ok bool
+		select {
+		case item, ok = <-ch:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if !ok {
+			break
+		}
+
+		itemValue := reflect.ValueOf(&item).Elem()
+		if itemType.Kind() == reflect.Ptr {
+			if itemValue.IsNil() {
+				itemValue = reflect.New(valueType)
+			}
+		} else {
+			itemValue = itemValue.Addr()
+		}
+
+		itemRoot, err := itemValue.Interface().(hashTreeRooter).HashTreeRoot()
+		if err != nil {
+			return nil, fmt.Errorf("failed calculating root of item %v: %v", hasher.count, err)
+		}
+		if err := hasher.add(itemRoot); err != nil {
+			return nil, err
+		}
+	}
+
+	return hasher, nil
+}
+
+// listHasher incrementally merkleizes the chunks of a list. It only keeps the pending left nodes of each tree level.
+type listHasher struct {
+	limit  uint64
+	depth  int
+	count  uint64
+	layers [][32]byte
+}
+
+func newListHasher(limit uint64) *listHasher {
+	depth := 0
+	if limit > 1 {
+		depth = bits.Len64(limit - 1)
+	}
+
+	return &listHasher{
+		limit:  limit,
+		depth:  depth,
+		layers: make([][32]byte, depth+1),
+	}
+}
+
+// add appends a chunk to the list and merges all completed subtrees.
+func (h *listHasher) add(chunk [32]byte) error {
+	if h.count >= h.limit {
+		return ErrListTooBig
+	}
+
+	level := 0
+	for i := h.count; i&1 == 1; i >>= 1 {
+		chunk = hashPair(h.layers[level], chunk)
+		level++
+	}
+	h.layers[level] = chunk
+	h.count++
+
+	return nil
+}
+
+// root returns the hash tree root of the list, with the number of items mixed in.
+func (h *listHasher) root() [32]byte {
+	return hashPair(h.dataRoot(), h.lengthLeaf())
+}
+
+// dataRoot returns the root of the zero padded list items, without the length mixin.
+func (h *listHasher) dataRoot() [32]byte {
+	var root [32]byte
+	if h.count == uint64(1)<<h.depth {
+		root = h.layers[h.depth]
+	} else {
+		hasRoot := false
+		i := h.count
+		for level := 0; level < h.depth; level++ {
+			if i&1 == 1 {
+				if !hasRoot {
+					root = zeroHashes[level]
+				}
+				root = hashPair(h.layers[level], root)
+				hasRoot = true
+			} else if hasRoot {
+				root = hashPair(root, zeroHashes[level])
+			}
+			i >>= 1
+		}
+		if !hasRoot {
+			root = zeroHashes[h.depth]
+		}
+	}
+
+	return root
+}
+
+// lengthLeaf returns the length mixin leaf of the list.
+func (h *listHasher) lengthLeaf() [32]byte {
+	var length [32]byte
+	binary.LittleEndian.PutUint64(length[:8], h.count)
+	return length
+}
+
+func hashPair(left [32]byte, right [32]byte) [32]byte {
+	return sha256.Sum256(append(left[:], right[:]...))
+}
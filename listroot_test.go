@@ -0,0 +1,164 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_ListRootItem struct {
+	F1 uint64
+}
+
+func (s *slug_ListRootItem) HashTreeRoot() ([32]byte, error) {
+	var root [32]byte
+	binary.LittleEndian.PutUint64(root[:8], s.F1)
+	return root, nil
+}
+
+// naiveListRoot merkleizes the full, zero padded list of chunks.
+func naiveListRoot(chunks [][32]byte, limit uint64) [32]byte {
+	size := uint64(1)
+	for size < limit {
+		size *= 2
+	}
+	layer := make([][32]byte, size)
+	copy(layer, chunks)
+	for len(layer) > 1 {
+		next := make([][32]byte, len(layer)/2)
+		for i := range next {
+			next[i] = sha256.Sum256(append(layer[2*i][:], layer[2*i+1][:]...))
+		}
+		layer = next
+	}
+
+	var length [32]byte
+	binary.LittleEndian.PutUint64(length[:8], uint64(len(chunks)))
+	return sha256.Sum256(append(layer[0][:], length[:]...))
+}
+
+func TestHashTreeRootFromChan(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+
+	for _, limit := range []uint64{1, 2, 5, 8, 16} {
+		for count := uint64(0); count <= limit; count++ {
+			ch := make(chan *slug_ListRootItem, count)
+			chunks := [][32]byte{}
+			for i := uint64(0); i < count; i++ {
+				item := &slug_ListRootItem{F1: i + 1}
+				root, _ := item.HashTreeRoot()
+				chunks = append(chunks, root)
+				ch <- item
+			}
+			close(ch)
+
+			root, err := HashTreeRootFromChan(dynssz, ch, limit)
+			if err != nil {
+				t.Fatalf("unexpected error (limit: %v, count: %v): %v", limit, count, err)
+			}
+			if expected := naiveListRoot(chunks, limit); root != expected {
+				t.Errorf("unexpected root (limit: %v, count: %v): 0x%x, wanted 0x%x", limit, count, root, expected)
+			}
+		}
+	}
+
+	ch := make(chan slug_ListRootItem, 3)
+	ch <- slug_ListRootItem{F1: 1}
+	ch <- slug_ListRootItem{F1: 2}
+	ch <- slug_ListRootItem{F1: 3}
+	close(ch)
+	if _, err := HashTreeRootFromChan(dynssz, ch, 2); err != ErrListTooBig {
+		t.Errorf("expected ErrListTooBig, got %v", err)
+	}
+
+	if _, err := HashTreeRootFromChan(dynssz, make(chan uint32), 2); err == nil {
+		t.Errorf("expected error for type without HashTreeRoot")
+	}
+}
+
+func TestLengthMixinProofFromChan(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+
+	for _, count := range []uint64{0, 3, 8} {
+		ch := make(chan *slug_ListRootItem, count)
+		chunks := [][32]byte{}
+		for i := uint64(0); i < count; i++ {
+			item := &slug_ListRootItem{F1: i + 1}
+			root, _ := item.HashTreeRoot()
+			chunks = append(chunks, root)
+			ch <- item
+		}
+		close(ch)
+
+		proof, err := LengthMixinProofFromChan(dynssz, ch, 8)
+		if err != nil {
+			t.Fatalf("unexpected error (count: %v): %v", count, err)
+		}
+		if expected := naiveListRoot(chunks, 8); proof.Root != expected {
+			t.Errorf("unexpected root (count: %v): 0x%x, wanted 0x%x", count, proof.Root, expected)
+		}
+		if proof.Length != count || proof.GIndex != 3 || !proof.Verify() {
+			t.Errorf("invalid proof (count: %v): %+v", count, proof)
+		}
+
+		proof.Length++
+		binary.LittleEndian.PutUint64(proof.Leaf[:8], proof.Length)
+		if proof.Verify() {
+			t.Errorf("proof with modified length verified (count: %v)", count)
+		}
+	}
+
+	ch := make(chan *slug_ListRootItem, 3)
+	for i := 0; i < 3; i++ {
+		ch <- &slug_ListRootItem{F1: 1}
+	}
+	close(ch)
+	if _, err := LengthMixinProofFromChan(dynssz, ch, 2); err != ErrListTooBig {
+		t.Errorf("expected ErrListTooBig, got %v", err)
+	}
+}
+
+func TestHashTreeRootFromChanCtx(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+
+	ch := make(chan *slug_ListRootItem, 2)
+	ch <- &slug_ListRootItem{F1: 1}
+	ch <- &slug_ListRootItem{F1: 2}
+	close(ch)
+
+	root, err := HashTreeRootFromChanCtx(context.Background(), dynssz, ch, 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	chunk1, _ := (&slug_ListRootItem{F1: 1}).HashTreeRoot()
+	chunk2, _ := (&slug_ListRootItem{F1: 2}).HashTreeRoot()
+	if expected := naiveListRoot([][32]byte{chunk1, chunk2}, 4); root != expected {
+		t.Errorf("root mismatch: got %x, expected %x", root, expected)
+	}
+
+	// the producer never closes the channel, the context must abort the wait for the next item
+	ctx, cancel := context.WithCancel(context.Background())
+	pending := make(chan *slug_ListRootItem, 1)
+	pending <- &slug_ListRootItem{F1: 1}
+	go cancel()
+	if _, err := HashTreeRootFromChanCtx(ctx, dynssz, pending, 4); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	// a done context is reported before any item is consumed
+	full := make(chan *slug_ListRootItem, 1)
+	full <- &slug_ListRootItem{F1: 1}
+	if _, err := HashTreeRootFromChanCtx(ctx, dynssz, full, 4); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if len(full) != 1 {
+		t.Errorf("expected the item to remain in the channel")
+	}
+}
@@ -0,0 +1,63 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+)
+
+// SetLogger sets a structured logger for the DynSsz instance. Trace output is written at debug level, so it is opt-in
+// via the level of the logger: with debug logging enabled, the logger receives type cache builds, the chosen code path
+// (fastssz or dynamic) for each encoded or decoded type and the reason why fastssz could not be used.
+// Passing nil disables logging.
+func (d *DynSsz) SetLogger(logger *slog.Logger) {
+	d.logger = logger
+}
+
+// isTraceEnabled checks if a logger is set that accepts debug level messages.
+func (d *DynSsz) isTraceEnabled() bool {
+	return d.logger != nil && d.logger.Enabled(context.Background(), slog.LevelDebug)
+}
+
+// logCodePath logs the code path chosen to encode or decode a type.
+func (d *DynSsz) logCodePath(operation string, targetType reflect.Type, useFastSsz bool, hasFastSszMethods bool, fastsszCompat *fastsszCompatibility) {
+	if !d.isTraceEnabled() {
+		return
+	}
+
+	codePath := "dynamic"
+	if useFastSsz {
+		codePath = "fastssz"
+	}
+	reason := d.getCodePathReason(useFastSsz, hasFastSszMethods, fastsszCompat)
+
+	d.logger.Debug("dynssz code path", "operation", operation, "type", targetType.String(), "path", codePath, "reason", reason)
+}
+
+// getCodePathReason returns the reason why fastssz could not be used for a type, or an empty string if it is used.
+func (d *DynSsz) getCodePathReason(useFastSsz bool, hasFastSszMethods bool, fastsszCompat *fastsszCompatibility) string {
+	switch {
+	case useFastSsz:
+		return ""
+	case d.NoFastSsz:
+		return "fastssz disabled"
+	case !hasFastSszMethods:
+		return "no fastssz methods"
+	case fastsszCompat.hasDynamicSpecValues:
+		return "spec values applied"
+	default:
+		return ""
+	}
+}
+
+// logTypeCache logs the creation of a new type cache entry.
+func (d *DynSsz) logTypeCache(cache string, targetType reflect.Type, args ...any) {
+	if !d.isTraceEnabled() {
+		return
+	}
+
+	d.logger.Debug("dynssz type cache", append([]any{"cache", cache, "type", targetType.String()}, args...)...)
+}
@@ -0,0 +1,47 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+func TestSetLogger(t *testing.T) {
+	logBuf := bytes.Buffer{}
+
+	dynssz := NewDynSsz(nil)
+	dynssz.SetLogger(slog.New(slog.NewTextHandler(&logBuf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+
+	if _, err := dynssz.MarshalSSZ(&slug_FastsszStruct1{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if logBuf.Len() > 0 {
+		t.Errorf("unexpected trace output without debug level: %v", logBuf.String())
+	}
+
+	dynssz = NewDynSsz(nil)
+	dynssz.SetLogger(slog.New(slog.NewTextHandler(&logBuf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	if _, err := dynssz.MarshalSSZ(&slug_FastsszStruct1{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := dynssz.MarshalSSZ(&slug_DynStruct1{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, expected := range []string{
+		"msg=\"dynssz type cache\" cache=fastssz type=dynssz_test.slug_FastsszStruct1 marshaler=true",
+		"msg=\"dynssz code path\" operation=marshal type=dynssz_test.slug_FastsszStruct1 path=fastssz",
+		"msg=\"dynssz code path\" operation=marshal type=dynssz_test.slug_DynStruct1 path=dynamic reason=\"no fastssz methods\"",
+	} {
+		if !strings.Contains(logBuf.String(), expected) {
+			t.Errorf("missing %q in log output:\n%v", expected, logBuf.String())
+		}
+	}
+}
@@ -4,10 +4,11 @@
 package dynssz
 
 import (
-	"encoding/binary"
 	"fmt"
 	"reflect"
 	"strings"
+
+	"github.com/pk910/dynamic-ssz/sszutils"
 )
 
 // marshalType is the entry point for marshalling Go values into SSZ-encoded data, using reflection to navigate
@@ -65,6 +66,8 @@ func (d *DynSsz) marshalType(sourceType reflect.Type, sourceValue reflect.Value,
 	if d.Verbose {
 		fmt.Printf("%stype: %s\t kind: %v\t fastssz: %v (compat: %v/ dynamic: %v)\n", strings.Repeat(" ", idt), sourceType.Name(), sourceType.Kind(), useFastSsz, fastsszCompat.isMarshaler, fastsszCompat.hasDynamicSpecValues)
 	}
+	d.logCodePath("marshal", sourceType, useFastSsz, fastsszCompat.isMarshaler, fastsszCompat)
+	d.countCodePath("marshal", sourceType, useFastSsz, fastsszCompat.isMarshaler, fastsszCompat)
 
 	if useFastSsz {
 		marshaller, ok := sourceValue.Addr().Interface().(fastsszMarshaler)
@@ -101,15 +104,15 @@ func (d *DynSsz) marshalType(sourceType reflect.Type, sourceValue reflect.Value,
 			}
 			buf = newBuf
 		case reflect.Bool:
-			buf = marshalBool(buf, sourceValue.Bool())
+			buf = sszutils.AppendBool(buf, sourceValue.Bool())
 		case reflect.Uint8:
-			buf = marshalUint8(buf, uint8(sourceValue.Uint()))
+			buf = sszutils.AppendUint8(buf, uint8(sourceValue.Uint()))
 		case reflect.Uint16:
-			buf = marshalUint16(buf, uint16(sourceValue.Uint()))
+			buf = sszutils.AppendUint16(buf, uint16(sourceValue.Uint()))
 		case reflect.Uint32:
-			buf = marshalUint32(buf, uint32(sourceValue.Uint()))
+			buf = sszutils.AppendUint32(buf, uint32(sourceValue.Uint()))
 		case reflect.Uint64:
-			buf = marshalUint64(buf, uint64(sourceValue.Uint()))
+			buf = sszutils.AppendUint64(buf, sourceValue.Uint())
 		default:
 			return nil, fmt.Errorf("unknown type: %v", sourceType)
 		}
@@ -150,7 +153,7 @@ func (d *DynSsz) marshalStruct(sourceType reflect.Type, sourceValue reflect.Valu
 	dynamicSizeHints := [][]sszSizeHint{}
 
 	for i := 0; i < sourceType.NumField(); i++ {
-		field := sourceType.Field(i)
+		field := d.getStructField(sourceType, i)
 
 		fieldSize, _, sizeHints, err := d.getSszFieldSize(&field)
 		if err != nil {
@@ -182,9 +185,12 @@ func (d *DynSsz) marshalStruct(sourceType reflect.Type, sourceValue reflect.Valu
 	for i, field := range dynamicFields {
 		// set field offset
 		fieldOffset := dynamicOffsets[i]
-		offsetBuf := make([]byte, 4)
-		binary.LittleEndian.PutUint32(offsetBuf, uint32(offset))
-		copy(buf[fieldOffset+startLen:fieldOffset+startLen+4], offsetBuf)
+		if uint64(offset) > maxOffset {
+			return nil, ErrOffsetOverflow
+		}
+		if err := sszutils.PutUint32(buf[fieldOffset+startLen:], uint32(offset)); err != nil {
+			return nil, err
+		}
 
 		//fmt.Printf("%sfield %d:\t dynamic [%v:]\t %v\n", strings.Repeat(" ", idt+1), field.Index[0], offset, field.Name)
 
@@ -240,20 +246,42 @@ func (d *DynSsz) marshalArray(sourceType reflect.Type, sourceValue reflect.Value
 	}
 
 	arrLen := sourceType.Len()
-	if fieldType == byteType {
-		// shortcut for performance: use append on []byte arrays
+	if !fieldIsPtr && (isByteType(fieldType) || isByteArrayType(fieldType)) {
 		if !sourceValue.CanAddr() {
 			// workaround for unaddressable static arrays
 			sourceValPtr := reflect.New(sourceType)
 			sourceValPtr.Elem().Set(sourceValue)
 			sourceValue = sourceValPtr.Elem()
 		}
-		buf = append(buf, sourceValue.Bytes()...)
+
+		if isByteType(fieldType) {
+			// shortcut for performance: use append on []byte arrays
+			buf = append(buf, sourceValue.Bytes()...)
+		} else {
+			// shortcut for performance: append byte arrays (roots, addresses, ...) directly
+			for i := 0; i < arrLen; i++ {
+				buf = append(buf, sourceValue.Index(i).Bytes()...)
+			}
+		}
 	} else {
+		itemSize, _, err := d.getSszSize(fieldType, childSizeHints)
+		if err != nil {
+			return nil, err
+		}
+		if itemSize < 0 {
+			// vector with dynamic size items, encode with offsets like a fixed size slice
+			vectorSizeHints := append([]sszSizeHint{{size: uint64(arrLen)}}, childSizeHints...)
+			return d.marshalDynamicSlice(sourceType, sourceValue, buf, vectorSizeHints, idt)
+		}
+
 		for i := 0; i < arrLen; i++ {
 			itemVal := sourceValue.Index(i)
 			if fieldIsPtr {
-				itemVal = itemVal.Elem()
+				if itemVal.IsNil() {
+					itemVal = reflect.New(fieldType).Elem()
+				} else {
+					itemVal = itemVal.Elem()
+				}
 			}
 
 			newBuf, err := d.marshalType(fieldType, itemVal, buf, childSizeHints, idt+2)
@@ -333,11 +361,14 @@ func (d *DynSsz) marshalSlice(sourceType reflect.Type, sourceValue reflect.Value
 			return nil, ErrListTooBig
 		}
 		if uint64(sliceLen) < sizeHints[0].size {
+			if d.StrictVectorLength || sizeHints[0].raw {
+				return nil, ErrVectorLength
+			}
 			appendZero = int(sizeHints[0].size - uint64(sliceLen))
 		}
 	}
 
-	if fieldType == byteType {
+	if !fieldIsPtr && isByteType(fieldType) {
 		// shortcut for performance: use append on []byte arrays
 		buf = append(buf, sourceValue.Bytes()...)
 
@@ -345,6 +376,16 @@ func (d *DynSsz) marshalSlice(sourceType reflect.Type, sourceValue reflect.Value
 			zeroBytes := make([]uint8, appendZero)
 			buf = append(buf, zeroBytes...)
 		}
+	} else if !fieldIsPtr && isByteArrayType(fieldType) {
+		// shortcut for performance: append byte arrays (roots, addresses, ...) directly
+		for i := 0; i < sliceLen; i++ {
+			buf = append(buf, sourceValue.Index(i).Bytes()...)
+		}
+
+		if appendZero > 0 {
+			zeroBytes := make([]uint8, appendZero*fieldType.Len())
+			buf = append(buf, zeroBytes...)
+		}
 	} else {
 
 		for i := 0; i < sliceLen; i++ {
@@ -415,12 +456,20 @@ func (d *DynSsz) marshalDynamicSlice(sourceType reflect.Type, sourceValue reflec
 			return nil, ErrListTooBig
 		}
 		if uint64(sliceLen) < sizeHints[0].size {
+			if d.StrictVectorLength || sizeHints[0].raw {
+				return nil, ErrVectorLength
+			}
 			appendZero = int(sizeHints[0].size - uint64(sliceLen))
 		}
 	}
 
+	offsetsSize, err := mulSize(4, uint64(sliceLen)+uint64(appendZero))
+	if err != nil {
+		return nil, err
+	}
+
 	startOffset := len(buf)
-	offsetBuf := make([]byte, 4*(sliceLen+appendZero))
+	offsetBuf := make([]byte, offsetsSize)
 	buf = append(buf, offsetBuf...)
 
 	fieldType := sourceType.Elem()
@@ -429,13 +478,17 @@ func (d *DynSsz) marshalDynamicSlice(sourceType reflect.Type, sourceValue reflec
 		fieldType = fieldType.Elem()
 	}
 
-	offset := 4 * (sliceLen + appendZero)
+	offset := offsetsSize
 	bufLen := len(buf)
 
 	for i := 0; i < sliceLen; i++ {
 		itemVal := sourceValue.Index(i)
 		if fieldIsPtr {
-			itemVal = itemVal.Elem()
+			if itemVal.IsNil() {
+				itemVal = reflect.New(fieldType).Elem()
+			} else {
+				itemVal = itemVal.Elem()
+			}
 		}
 
 		newBuf, err := d.marshalType(fieldType, itemVal, buf, childSizeHints, idt+2)
@@ -445,9 +498,12 @@ func (d *DynSsz) marshalDynamicSlice(sourceType reflect.Type, sourceValue reflec
 		newBufLen := len(newBuf)
 		buf = newBuf
 
-		offsetBuf := make([]byte, 4)
-		binary.LittleEndian.PutUint32(offsetBuf, uint32(offset))
-		copy(buf[startOffset+(i*4):startOffset+((i+1)*4)], offsetBuf)
+		if uint64(offset) > maxOffset {
+			return nil, ErrOffsetOverflow
+		}
+		if err := sszutils.PutUint32(buf[startOffset+(i*4):], uint32(offset)); err != nil {
+			return nil, err
+		}
 
 		offset += newBufLen - bufLen
 		bufLen = newBufLen
@@ -465,9 +521,12 @@ func (d *DynSsz) marshalDynamicSlice(sourceType reflect.Type, sourceValue reflec
 		for i := 0; i < appendZero; i++ {
 			buf = append(buf, zeroBuf...)
 
-			offsetBuf := make([]byte, 4)
-			binary.LittleEndian.PutUint32(offsetBuf, uint32(offset))
-			copy(buf[startOffset+((sliceLen+i)*4):startOffset+(((sliceLen+i)+1)*4)], offsetBuf)
+			if uint64(offset) > maxOffset {
+				return nil, ErrOffsetOverflow
+			}
+			if err := sszutils.PutUint32(buf[startOffset+((sliceLen+i)*4):], uint32(offset)); err != nil {
+				return nil, err
+			}
 
 			offset += zeroBufLen
 		}
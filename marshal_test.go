@@ -5,6 +5,10 @@ package dynssz_test
 
 import (
 	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"strings"
 	"testing"
 
 	. "github.com/pk910/dynamic-ssz"
@@ -35,6 +39,14 @@ var marshalTestMatrix = []struct {
 	{[]uint8{1, 2, 3, 4, 5}, fromHex("0x0102030405")},
 	{[5]uint8{1, 2, 3, 4, 5}, fromHex("0x0102030405")},
 	{[10]uint8{1, 2, 3, 4, 5}, fromHex("0x01020304050000000000")},
+	{[]slug_Byte{1, 2, 3}, fromHex("0x010203")},
+	{[3]slug_Byte{1, 2, 3}, fromHex("0x010203")},
+	{[]slug_Root4{{1, 2, 3, 4}, {5, 6, 7, 8}}, fromHex("0x0102030405060708")},
+	{[2]slug_Root4{{1, 2, 3, 4}, {5, 6, 7, 8}}, fromHex("0x0102030405060708")},
+	{[][20]byte{{1}, {2}}, fromHex("0x01000000000000000000000000000000000000000200000000000000000000000000000000000000")},
+	{[2]slug_DynStruct1{{true, []uint8{4}}, {false, []uint8{}}}, fromHex("0x080000000e0000000105000000040005000000")},
+	{[2]*slug_DynStruct1{{true, []uint8{4}}, nil}, fromHex("0x080000000e0000000105000000040005000000")},
+	{[]*slug_DynStruct1{{true, []uint8{4}}, nil}, fromHex("0x080000000e0000000105000000040005000000")},
 
 	// complex types
 	{
@@ -88,6 +100,32 @@ var marshalTestMatrix = []struct {
 		}{42, []*slug_StaticStruct1{nil, nil, nil, nil}, 43},
 		nil, // size too long error
 	},
+	{
+		struct {
+			F1 []slug_Root4 `ssz-size:"3,4"`
+		}{[]slug_Root4{{1, 2, 3, 4}}},
+		fromHex("0x010203040000000000000000"),
+	},
+	{
+		struct {
+			F1 []*slug_DynStruct1
+		}{[]*slug_DynStruct1{nil, {true, nil}}},
+		fromHex("0x04000000080000000d00000000050000000105000000"),
+	},
+	{
+		struct {
+			F1 *slug_DynStruct1
+			F2 [2]*slug_StaticStruct1
+		}{nil, [2]*slug_StaticStruct1{}},
+		fromHex("0x0c00000000000000000000000005000000"),
+	},
+	{
+		struct {
+			F1 []uint16 `ssz-size:"2"`
+			F2 []uint8  `ssz-size:"2"`
+		}{nil, []uint8{}},
+		fromHex("0x000000000000"),
+	},
 }
 
 func TestMarshal(t *testing.T) {
@@ -107,3 +145,254 @@ func TestMarshal(t *testing.T) {
 		}
 	}
 }
+
+func TestMarshalRequireSpecValues(t *testing.T) {
+	payload := struct {
+		F1 []uint8 `ssz-size:"4" dynssz-size:"UNKNOWN_SPEC_VALUE"`
+	}{[]uint8{1, 2, 3, 4}}
+
+	dynssz := NewDynSsz(nil)
+	dynssz.NoFastSsz = true
+
+	buf, err := dynssz.MarshalSSZ(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(buf, fromHex("0x01020304")) {
+		t.Errorf("fallback encoding failed: got 0x%x", buf)
+	}
+
+	dynssz = NewDynSsz(nil)
+	dynssz.NoFastSsz = true
+	dynssz.RequireSpecValues = true
+
+	_, err = dynssz.MarshalSSZ(payload)
+	if err == nil {
+		t.Errorf("expected error for unresolved spec value")
+	}
+}
+
+func TestMarshalStrictVectorLength(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+	dynssz.NoFastSsz = true
+	dynssz.StrictVectorLength = true
+
+	_, err := dynssz.MarshalSSZ(struct {
+		F1 []uint16 `ssz-size:"2"`
+	}{[]uint16{1, 2}})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	for idx, payload := range []any{
+		struct {
+			F1 []uint16 `ssz-size:"2"`
+		}{nil},
+		struct {
+			F1 []uint8 `ssz-size:"2"`
+		}{[]uint8{1}},
+		struct {
+			F1 []slug_DynStruct1 `ssz-size:"2"`
+		}{[]slug_DynStruct1{}},
+	} {
+		_, err := dynssz.MarshalSSZ(payload)
+		if err == nil || !strings.Contains(err.Error(), ErrVectorLength.Error()) {
+			t.Errorf("test %v: expected vector length error, got %v", idx, err)
+		}
+	}
+}
+
+type slug_RawStruct struct {
+	F1 uint16
+	F2 []byte `ssz-type:"raw"`
+	F3 []byte `ssz-size:"2" dynssz-size:"SPEC_A" ssz-type:"raw"`
+}
+
+func TestMarshalRawPassthrough(t *testing.T) {
+	dynssz := NewDynSsz(map[string]any{"SPEC_A": uint64(3)})
+
+	payload := slug_RawStruct{F1: 1, F2: fromHex("0x0400000001"), F3: fromHex("0xaabbcc")}
+	expected := fromHex("0x010009000000aabbcc0400000001")
+	buf, err := dynssz.MarshalSSZ(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(buf, expected) {
+		t.Errorf("unexpected encoding: 0x%x, wanted 0x%x", buf, expected)
+	}
+
+	decoded := slug_RawStruct{}
+	if err := dynssz.UnmarshalSSZ(&decoded, buf); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+	if !bytes.Equal(decoded.F2, payload.F2) || !bytes.Equal(decoded.F3, payload.F3) {
+		t.Errorf("raw values not passed through: %+v", decoded)
+	}
+
+	// raw values of static size must not be padded
+	payload.F3 = fromHex("0xaabb")
+	if _, err := dynssz.MarshalSSZ(payload); err == nil || !strings.Contains(err.Error(), ErrVectorLength.Error()) {
+		t.Errorf("expected vector length error for short raw value, got %v", err)
+	}
+
+	if _, err := dynssz.MarshalSSZ(struct {
+		F1 []uint16 `ssz-type:"raw"`
+	}{}); err == nil {
+		t.Errorf("expected error for raw annotation on non-byte field")
+	}
+}
+
+func TestMarshalWriter(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+	dynssz.NoFastSsz = true
+
+	for idx, test := range marshalTestMatrix {
+		buf := bytes.Buffer{}
+		err := dynssz.MarshalSSZWriter(test.payload, &buf)
+
+		switch {
+		case test.expected == nil && err != nil:
+			// expected error
+		case err != nil:
+			t.Errorf("test %v error: %v", idx, err)
+		case !bytes.Equal(buf.Bytes(), test.expected):
+			t.Errorf("test %v failed: got 0x%x, wanted 0x%x", idx, buf.Bytes(), test.expected)
+		}
+	}
+}
+
+// writeCounter counts the writes to the underlying buffer.
+type writeCounter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *writeCounter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+func TestMarshalWriterBufferSize(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+	dynssz.NoFastSsz = true
+	dynssz.StreamBufferSize = 1
+
+	for idx, test := range marshalTestMatrix {
+		buf := bytes.Buffer{}
+		err := dynssz.MarshalSSZWriter(test.payload, &buf)
+
+		switch {
+		case test.expected == nil && err != nil:
+			// expected error
+		case err != nil:
+			t.Errorf("test %v error: %v", idx, err)
+		case !bytes.Equal(buf.Bytes(), test.expected):
+			t.Errorf("test %v failed: got 0x%x, wanted 0x%x", idx, buf.Bytes(), test.expected)
+		}
+	}
+
+	payload := struct {
+		F1 uint32
+		F2 []uint8 `ssz-size:"?" dynssz-size:"?"`
+	}{1, make([]uint8, 1024)}
+
+	dynssz.StreamBufferSize = 0
+	counter := &writeCounter{}
+	if err := dynssz.MarshalSSZWriter(payload, counter); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if counter.Len() != 1032 || counter.writes != 1 {
+		t.Errorf("unexpected output: %v bytes in %v writes, wanted 1032 bytes in 1 write", counter.Len(), counter.writes)
+	}
+}
+
+func BenchmarkMarshalWriter(b *testing.B) {
+	payload := struct {
+		F1 []uint64  `ssz-size:"?" dynssz-size:"?"`
+		F2 [][]uint8 `ssz-size:"?,?" dynssz-size:"?,?"`
+	}{make([]uint64, 1024), make([][]uint8, 256)}
+	for i := range payload.F2 {
+		payload.F2[i] = make([]uint8, 8192)
+	}
+
+	for _, bufferSize := range []int{512, 4096, 65536} {
+		b.Run(fmt.Sprintf("buffer-%v", bufferSize), func(b *testing.B) {
+			dynssz := NewDynSsz(nil)
+			dynssz.StreamBufferSize = bufferSize
+
+			for i := 0; i < b.N; i++ {
+				if err := dynssz.MarshalSSZWriter(payload, io.Discard); err != nil {
+					b.Fatalf("unexpected error: %v", err)
+				}
+			}
+		})
+	}
+
+	// compare against the baselines on a tcp connection, where vectored writes map to writev
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		b.Skipf("failed listening on loopback: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, _ = io.Copy(io.Discard, conn)
+				conn.Close()
+			}()
+		}
+	}()
+
+	dialConn := func(b *testing.B) net.Conn {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			b.Fatalf("failed connecting to loopback: %v", err)
+		}
+		return conn
+	}
+
+	b.Run("tcp-marshal-write", func(b *testing.B) {
+		dynssz := NewDynSsz(nil)
+		conn := dialConn(b)
+		defer conn.Close()
+
+		for i := 0; i < b.N; i++ {
+			ssz, err := dynssz.MarshalSSZ(payload)
+			if err != nil {
+				b.Fatalf("unexpected error: %v", err)
+			}
+			if _, err := conn.Write(ssz); err != nil {
+				b.Fatalf("unexpected error: %v", err)
+			}
+		}
+	})
+	b.Run("tcp-writer-sequential", func(b *testing.B) {
+		dynssz := NewDynSsz(nil)
+		conn := dialConn(b)
+		defer conn.Close()
+
+		// hiding the connection type disables writev, so buffer and value are written one after another like with the
+		// previous bufio based writer
+		writer := struct{ io.Writer }{conn}
+		for i := 0; i < b.N; i++ {
+			if err := dynssz.MarshalSSZWriter(payload, writer); err != nil {
+				b.Fatalf("unexpected error: %v", err)
+			}
+		}
+	})
+	b.Run("tcp-writer-vectored", func(b *testing.B) {
+		dynssz := NewDynSsz(nil)
+		conn := dialConn(b)
+		defer conn.Close()
+
+		for i := 0; i < b.N; i++ {
+			if err := dynssz.MarshalSSZWriter(payload, conn); err != nil {
+				b.Fatalf("unexpected error: %v", err)
+			}
+		}
+	})
+}
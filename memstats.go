@@ -0,0 +1,148 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// MemStats compares the Go memory footprint of a value with the size of its SSZ encoding.
+//
+// Fields:
+//   - Name: The field name, or the type name for the root value.
+//   - GoSize: The estimated number of bytes used by the Go representation, including the inline size of the value and
+//     all memory referenced via pointers and slices (slice capacities are taken into account).
+//   - SszSize: The size of the SSZ encoding of the value, excluding the offset in the parent container for dynamic fields.
+//   - Fields: The statistics of the struct fields, if the value is a struct.
+type MemStats struct {
+	Name    string
+	GoSize  uint64
+	SszSize uint64
+	Fields  []*MemStats
+}
+
+// MemStatsOf calculates the Go memory footprint and the SSZ encoded size of the given object, broken down per struct
+// field. Comparing both sizes helps to find structures where the Go representation is wasteful, e.g. pointer heavy
+// lists or slices with large unused capacities.
+// Returns the statistics for the root value, or an error if the SSZ size can not be calculated.
+func (d *DynSsz) MemStatsOf(source any) (*MemStats, error) {
+	sourceType := reflect.TypeOf(source)
+	sourceValue := reflect.ValueOf(source)
+
+	return d.getMemStats(getTypeDocName(sourceType), sourceType, sourceValue, []sszSizeHint{})
+}
+
+// getMemStats calculates the memory statistics for a value and its struct fields.
+func (d *DynSsz) getMemStats(name string, sourceType reflect.Type, sourceValue reflect.Value, sizeHints []sszSizeHint) (*MemStats, error) {
+	sszSize, err := d.getSszValueSize(sourceType, sourceValue, sizeHints)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &MemStats{
+		Name:    name,
+		GoSize:  uint64(sourceType.Size()) + getHeapSize(sourceType, sourceValue),
+		SszSize: uint64(sszSize),
+	}
+
+	if sourceType.Kind() == reflect.Ptr {
+		sourceType = sourceType.Elem()
+		if sourceValue.IsNil() {
+			sourceValue = reflect.New(sourceType).Elem()
+		} else {
+			sourceValue = sourceValue.Elem()
+		}
+	}
+
+	if sourceType.Kind() == reflect.Struct {
+		for i := 0; i < sourceType.NumField(); i++ {
+			field := d.getStructField(sourceType, i)
+
+			sizeHints, err := d.getSszSizeTag(&field)
+			if err != nil {
+				return nil, err
+			}
+
+			fieldStats, err := d.getMemStats(field.Name, field.Type, sourceValue.Field(i), sizeHints)
+			if err != nil {
+				return nil, fmt.Errorf("failed calculating stats for field %v: %v", field.Name, err)
+			}
+			stats.Fields = append(stats.Fields, fieldStats)
+		}
+	}
+
+	return stats, nil
+}
+
+// getHeapSize returns the number of bytes referenced by a value via pointers and slices, excluding its inline size.
+func getHeapSize(sourceType reflect.Type, sourceValue reflect.Value) uint64 {
+	switch sourceType.Kind() {
+	case reflect.Ptr:
+		if sourceValue.IsNil() {
+			return 0
+		}
+		return uint64(sourceType.Elem().Size()) + getHeapSize(sourceType.Elem(), sourceValue.Elem())
+	case reflect.Slice:
+		itemType := sourceType.Elem()
+		size := uint64(sourceValue.Cap()) * uint64(itemType.Size())
+		if hasHeapReferences(itemType) {
+			for i := 0; i < sourceValue.Len(); i++ {
+				size += getHeapSize(itemType, sourceValue.Index(i))
+			}
+		}
+		return size
+	case reflect.Array:
+		itemType := sourceType.Elem()
+		size := uint64(0)
+		if hasHeapReferences(itemType) {
+			for i := 0; i < sourceValue.Len(); i++ {
+				size += getHeapSize(itemType, sourceValue.Index(i))
+			}
+		}
+		return size
+	case reflect.Struct:
+		size := uint64(0)
+		for i := 0; i < sourceType.NumField(); i++ {
+			size += getHeapSize(sourceType.Field(i).Type, sourceValue.Field(i))
+		}
+		return size
+	default:
+		return 0
+	}
+}
+
+// hasHeapReferences checks if values of the given type can reference memory via pointers or slices.
+func hasHeapReferences(targetType reflect.Type) bool {
+	switch targetType.Kind() {
+	case reflect.Ptr, reflect.Slice:
+		return true
+	case reflect.Array:
+		return hasHeapReferences(targetType.Elem())
+	case reflect.Struct:
+		for i := 0; i < targetType.NumField(); i++ {
+			if hasHeapReferences(targetType.Field(i).Type) {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+// String renders the statistics as an indented tree with one line per value.
+func (s *MemStats) String() string {
+	builder := strings.Builder{}
+	s.writeTree(&builder, 0)
+	return builder.String()
+}
+
+func (s *MemStats) writeTree(builder *strings.Builder, depth int) {
+	fmt.Fprintf(builder, "%s%v: go %v bytes, ssz %v bytes\n", strings.Repeat("  ", depth), s.Name, s.GoSize, s.SszSize)
+	for _, field := range s.Fields {
+		field.writeTree(builder, depth+1)
+	}
+}
@@ -0,0 +1,65 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"testing"
+	"unsafe"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_MemStatsStruct1 struct {
+	F1 uint64
+	F2 []uint16 `ssz-size:"?" dynssz-size:"?"`
+	F3 *slug_MemStatsStruct2
+}
+
+type slug_MemStatsStruct2 struct {
+	F1 [4]uint8
+}
+
+func TestMemStatsOf(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+
+	stats, err := dynssz.MemStatsOf(&slug_MemStatsStruct1{
+		F1: 1,
+		F2: make([]uint16, 2, 10),
+		F3: &slug_MemStatsStruct2{},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if stats.SszSize != 8+4+4+4 {
+		t.Errorf("unexpected ssz size: %v", stats.SszSize)
+	}
+	if len(stats.Fields) != 3 {
+		t.Fatalf("unexpected field count: %v", len(stats.Fields))
+	}
+
+	// go sizes depend on the pointer size of the platform
+	ptrSize := uint64(unsafe.Sizeof(uintptr(0)))
+	structSize := uint64(unsafe.Sizeof(slug_MemStatsStruct1{}))
+
+	expected := []struct {
+		name    string
+		goSize  uint64
+		sszSize uint64
+	}{
+		{"F1", 8, 8},
+		{"F2", 3*ptrSize + 10*2, 4},
+		{"F3", ptrSize + 4, 4},
+	}
+	for i, exp := range expected {
+		field := stats.Fields[i]
+		if field.Name != exp.name || field.GoSize != exp.goSize || field.SszSize != exp.sszSize {
+			t.Errorf("unexpected stats for field %v: %v (go %v, ssz %v)", exp.name, field.Name, field.GoSize, field.SszSize)
+		}
+	}
+
+	if stats.GoSize != ptrSize+structSize+20+4 {
+		t.Errorf("unexpected go size: %v", stats.GoSize)
+	}
+}
@@ -0,0 +1,23 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"crypto/sha256"
+	"reflect"
+
+	"github.com/pk910/dynamic-ssz/sszutils"
+)
+
+// checkUnmodified encodes the source a second time and compares the result with the hash of the first encoding.
+// Used by the marshal functions if DetectConcurrentModification is set, a mismatch indicates that the source has been
+// modified by another goroutine while it was encoded.
+func (d *DynSsz) checkUnmodified(sourceType reflect.Type, sourceValue reflect.Value, sszHash [32]byte) error {
+	ssz, err := d.marshalType(sourceType, sourceValue, []byte{}, []sszSizeHint{}, 0)
+	if err != nil || !sszutils.EqualRoot(sha256.Sum256(ssz), sszHash) {
+		return ErrConcurrentModification
+	}
+
+	return nil
+}
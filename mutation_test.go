@@ -0,0 +1,74 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+// slug_MutatingStruct simulates a concurrent modification by changing its value on every encoding.
+type slug_MutatingStruct struct {
+	F1 uint64
+}
+
+func (s *slug_MutatingStruct) MarshalSSZTo(dst []byte) ([]byte, error) {
+	s.F1++
+	return binary.LittleEndian.AppendUint64(dst, s.F1), nil
+}
+
+func (s *slug_MutatingStruct) MarshalSSZ() ([]byte, error) {
+	return s.MarshalSSZTo(nil)
+}
+
+func (s *slug_MutatingStruct) SizeSSZ() int {
+	return 8
+}
+
+type slug_MutatingContainer struct {
+	F1 uint16
+	F2 *slug_MutatingStruct
+	F3 []uint8 `ssz-size:"?" dynssz-size:"?"`
+}
+
+func TestDetectConcurrentModification(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+	obj := &slug_MutatingContainer{F1: 1, F2: &slug_MutatingStruct{}, F3: []uint8{1, 2}}
+
+	if _, err := dynssz.MarshalSSZ(obj); err != nil {
+		t.Fatalf("unexpected error without detection: %v", err)
+	}
+
+	dynssz.DetectConcurrentModification = true
+
+	if _, err := dynssz.MarshalSSZ(obj); err != ErrConcurrentModification {
+		t.Errorf("MarshalSSZ: expected ErrConcurrentModification, got %v", err)
+	}
+	if _, err := dynssz.MarshalSSZTo(obj, []byte{1, 2, 3}); err != ErrConcurrentModification {
+		t.Errorf("MarshalSSZTo: expected ErrConcurrentModification, got %v", err)
+	}
+	if err := dynssz.MarshalSSZWriter(obj, &bytes.Buffer{}); err != ErrConcurrentModification {
+		t.Errorf("MarshalSSZWriter: expected ErrConcurrentModification, got %v", err)
+	}
+
+	codec, err := NewCodec[slug_MutatingContainer](dynssz)
+	if err != nil {
+		t.Fatalf("unexpected codec error: %v", err)
+	}
+	if _, err := codec.Marshal(obj); err != ErrConcurrentModification {
+		t.Errorf("Codec.Marshal: expected ErrConcurrentModification, got %v", err)
+	}
+
+	// unmodified objects must pass the check
+	staticObj := &slug_DynStruct1{F1: true, F2: []uint8{1, 2, 3}}
+	if _, err := dynssz.MarshalSSZTo(staticObj, []byte{1, 2, 3}); err != nil {
+		t.Errorf("unexpected error for unmodified object: %v", err)
+	}
+	if err := dynssz.MarshalSSZWriter(staticObj, &bytes.Buffer{}); err != nil {
+		t.Errorf("unexpected error for unmodified object: %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import "log/slog"
+
+// Option configures a DynSsz instance, see NewDynSsz.
+type Option func(d *DynSsz)
+
+// WithoutFastSSZ disables the use of fastssz methods, all types are handled by the dynamic reflection based code.
+func WithoutFastSSZ() Option {
+	return func(d *DynSsz) {
+		d.NoFastSsz = true
+	}
+}
+
+// WithVerbose enables verbose debug output.
+func WithVerbose() Option {
+	return func(d *DynSsz) {
+		d.Verbose = true
+	}
+}
+
+// WithLogger sets the logger used for trace logging of code path decisions, see SetLogger.
+func WithLogger(logger *slog.Logger) Option {
+	return func(d *DynSsz) {
+		d.SetLogger(logger)
+	}
+}
+
+// WithRequireSpecValues makes size calculation fail if a 'dynssz-size' tag references an unresolvable spec value,
+// instead of falling back to the 'ssz-size' defaults.
+func WithRequireSpecValues() Option {
+	return func(d *DynSsz) {
+		d.RequireSpecValues = true
+	}
+}
+
+// WithStrictVectorLength makes marshalling fail with ErrVectorLength for short vector slices instead of zero padding them.
+func WithStrictVectorLength() Option {
+	return func(d *DynSsz) {
+		d.StrictVectorLength = true
+	}
+}
+
+// WithValidation makes UnmarshalSSZ validate decoded objects with ValidateSSZ.
+func WithValidation() Option {
+	return func(d *DynSsz) {
+		d.ValidateAfterDecode = true
+	}
+}
+
+// WithZeroCopyDecode makes UnmarshalSSZ alias the input buffer for decoded byte lists instead of copying them.
+func WithZeroCopyDecode() Option {
+	return func(d *DynSsz) {
+		d.ZeroCopyDecode = true
+	}
+}
+
+// WithDetectConcurrentModification makes the marshal functions fail with ErrConcurrentModification if the source
+// object is modified while being encoded.
+func WithDetectConcurrentModification() Option {
+	return func(d *DynSsz) {
+		d.DetectConcurrentModification = true
+	}
+}
+
+// WithVerifyRoundTrip makes the marshal and unmarshal functions verify that the data survives a round trip, see
+// VerifyRoundTrip.
+func WithVerifyRoundTrip() Option {
+	return func(d *DynSsz) {
+		d.VerifyRoundTrip = true
+	}
+}
+
+// WithDecodeGuards sets the limits for decoding untrusted input, see DecodeGuards.
+func WithDecodeGuards(guards DecodeGuards) Option {
+	return func(d *DynSsz) {
+		d.DecodeGuards = guards
+	}
+}
+
+// WithBufferSize sets the size of the output buffer used by MarshalSSZWriter and TranscodeSSZToJSON.
+func WithBufferSize(size int) Option {
+	return func(d *DynSsz) {
+		d.StreamBufferSize = size
+	}
+}
+
+// WithDecodeCache enables the decode cache with the given maximum number of entries, see EnableDecodeCache.
+func WithDecodeCache(maxEntries int) Option {
+	return func(d *DynSsz) {
+		d.EnableDecodeCache(maxEntries)
+	}
+}
+
+// WithPathStats enables collecting code path statistics, see EnablePathStats.
+func WithPathStats() Option {
+	return func(d *DynSsz) {
+		d.EnablePathStats()
+	}
+}
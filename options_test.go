@@ -0,0 +1,48 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+func TestOptions(t *testing.T) {
+	dynssz := NewDynSsz(nil,
+		WithoutFastSSZ(),
+		WithVerbose(),
+		WithRequireSpecValues(),
+		WithStrictVectorLength(),
+		WithValidation(),
+		WithZeroCopyDecode(),
+		WithDetectConcurrentModification(),
+		WithVerifyRoundTrip(),
+		WithDecodeGuards(DecodeGuards{MaxListElems: 8}),
+		WithBufferSize(1024),
+		WithPathStats(),
+	)
+
+	if !dynssz.NoFastSsz || !dynssz.Verbose || !dynssz.RequireSpecValues || !dynssz.StrictVectorLength || !dynssz.ValidateAfterDecode || !dynssz.ZeroCopyDecode || !dynssz.DetectConcurrentModification || !dynssz.VerifyRoundTrip {
+		t.Errorf("options not applied: %+v", dynssz)
+	}
+	if dynssz.DecodeGuards.MaxListElems != 8 {
+		t.Errorf("unexpected decode guards: %+v", dynssz.DecodeGuards)
+	}
+	if dynssz.StreamBufferSize != 1024 {
+		t.Errorf("unexpected stream buffer size: %v, wanted 1024", dynssz.StreamBufferSize)
+	}
+
+	if _, err := dynssz.MarshalSSZ(&slug_DynStruct1{F1: true, F2: []uint8{1}}); err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	if len(dynssz.PathStats()) == 0 {
+		t.Errorf("expected path stats to be collected")
+	}
+
+	dynssz = NewDynSsz(nil)
+	if dynssz.NoFastSsz || dynssz.ValidateAfterDecode || dynssz.StreamBufferSize != 0 {
+		t.Errorf("unexpected default settings: %+v", dynssz)
+	}
+}
@@ -0,0 +1,127 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+
+// Package otelssz adds OpenTelemetry tracing to dynssz. It is a separate module, so dynssz itself does not depend on
+// OpenTelemetry. Each call records a span with the type name, the encoded size and the code path (fastssz or
+// dynamic) as attributes, which helps tracking down slow serialization in production services.
+package otelssz
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	dynssz "github.com/pk910/dynamic-ssz"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the tracer created by this package.
+const instrumentationName = "github.com/pk910/dynamic-ssz/otelssz"
+
+// Span attribute keys.
+const (
+	AttributeType           = attribute.Key("ssz.type")
+	AttributeSize           = attribute.Key("ssz.size")
+	AttributeCodePath       = attribute.Key("ssz.code_path")
+	AttributeCodePathReason = attribute.Key("ssz.code_path_reason")
+)
+
+// TracedDynSsz wraps a DynSsz instance and records a span for each call, as child of the span in the given context.
+// A TracedDynSsz is safe for concurrent use.
+type TracedDynSsz struct {
+	dynssz *dynssz.DynSsz
+	tracer trace.Tracer
+}
+
+// New creates a TracedDynSsz for the given DynSsz instance. Spans are created with a tracer of the given provider,
+// or of the global provider if 'provider' is nil.
+func New(ds *dynssz.DynSsz, provider trace.TracerProvider) *TracedDynSsz {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+
+	return &TracedDynSsz{
+		dynssz: ds,
+		tracer: provider.Tracer(instrumentationName),
+	}
+}
+
+// DynSsz returns the wrapped DynSsz instance.
+func (t *TracedDynSsz) DynSsz() *dynssz.DynSsz {
+	return t.dynssz
+}
+
+// MarshalSSZ serializes the given source like DynSsz.MarshalSSZ and records a "dynssz.MarshalSSZ" span.
+func (t *TracedDynSsz) MarshalSSZ(ctx context.Context, source any) ([]byte, error) {
+	_, span := t.startSpan(ctx, "dynssz.MarshalSSZ", source, "marshal")
+
+	ssz, err := t.dynssz.MarshalSSZ(source)
+	endSpan(span, len(ssz), err)
+	return ssz, err
+}
+
+// UnmarshalSSZ decodes the given SSZ data into the target like DynSsz.UnmarshalSSZ and records a
+// "dynssz.UnmarshalSSZ" span.
+func (t *TracedDynSsz) UnmarshalSSZ(ctx context.Context, target any, ssz []byte) error {
+	_, span := t.startSpan(ctx, "dynssz.UnmarshalSSZ", target, "unmarshal")
+
+	err := t.dynssz.UnmarshalSSZ(target, ssz)
+	endSpan(span, len(ssz), err)
+	return err
+}
+
+// HashTreeRoot calculates the hash tree root of the given source and records a "dynssz.HashTreeRoot" span.
+// dynssz does not implement merkleization itself, so the source must implement the fastssz HashTreeRoot method.
+func (t *TracedDynSsz) HashTreeRoot(ctx context.Context, source any) ([32]byte, error) {
+	_, span := t.startSpan(ctx, "dynssz.HashTreeRoot", source, "")
+
+	hashRoot, ok := source.(interface{ HashTreeRoot() ([32]byte, error) })
+	if !ok {
+		err := fmt.Errorf("type %T does not implement HashTreeRoot", source)
+		endSpan(span, 0, err)
+		return [32]byte{}, err
+	}
+
+	root, err := hashRoot.HashTreeRoot()
+	endSpan(span, 0, err)
+	return root, err
+}
+
+// startSpan starts a span for a call with the given object, annotated with the type name and the code path of the
+// operation ("marshal" or "unmarshal", empty to skip the code path).
+func (t *TracedDynSsz) startSpan(ctx context.Context, name string, object any, operation string) (context.Context, trace.Span) {
+	sszType := reflect.TypeOf(object)
+	typeName := "<nil>"
+	if sszType != nil {
+		typeName = sszType.String()
+	}
+
+	ctx, span := t.tracer.Start(ctx, name, trace.WithAttributes(AttributeType.String(typeName)))
+
+	if operation != "" && sszType != nil && span.IsRecording() {
+		if path, reason, err := t.dynssz.CodePathOf(sszType, operation); err == nil {
+			span.SetAttributes(AttributeCodePath.String(path))
+			if reason != "" {
+				span.SetAttributes(AttributeCodePathReason.String(reason))
+			}
+		}
+	}
+
+	return ctx, span
+}
+
+// endSpan records the encoded size and the error of a call and ends its span.
+func endSpan(span trace.Span, size int, err error) {
+	if size > 0 {
+		span.SetAttributes(AttributeSize.Int(size))
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,100 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package otelssz_test
+
+import (
+	"context"
+	"testing"
+
+	dynssz "github.com/pk910/dynamic-ssz"
+	"github.com/pk910/dynamic-ssz/otelssz"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+type slug_TracedStruct struct {
+	F1 uint32
+	F2 []uint8 `ssz-max:"8"`
+}
+
+// recordedSpan records the attributes of a span.
+type recordedSpan struct {
+	noop.Span
+	name  string
+	attrs map[attribute.Key]attribute.Value
+	ended bool
+	err   error
+}
+
+func (s *recordedSpan) IsRecording() bool { return true }
+
+func (s *recordedSpan) SetAttributes(kv ...attribute.KeyValue) {
+	for _, attr := range kv {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s *recordedSpan) RecordError(err error, _ ...trace.EventOption) { s.err = err }
+
+func (s *recordedSpan) End(...trace.SpanEndOption) { s.ended = true }
+
+type recordingTracer struct {
+	noop.Tracer
+	spans []*recordedSpan
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	span := &recordedSpan{name: name, attrs: map[attribute.Key]attribute.Value{}}
+	config := trace.NewSpanStartConfig(opts...)
+	span.SetAttributes(config.Attributes()...)
+	t.spans = append(t.spans, span)
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+type recordingProvider struct {
+	noop.TracerProvider
+	tracer *recordingTracer
+}
+
+func (p *recordingProvider) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return p.tracer
+}
+
+func TestTracedDynSsz(t *testing.T) {
+	provider := &recordingProvider{tracer: &recordingTracer{}}
+	traced := otelssz.New(dynssz.NewDynSsz(nil), provider)
+
+	ssz, err := traced.MarshalSSZ(context.Background(), &slug_TracedStruct{F1: 1, F2: []uint8{1, 2}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := traced.UnmarshalSSZ(context.Background(), &slug_TracedStruct{}, ssz[:4]); err == nil {
+		t.Fatalf("expected error for truncated data")
+	}
+
+	spans := provider.tracer.spans
+	if len(spans) != 2 {
+		t.Fatalf("unexpected span count: %v", len(spans))
+	}
+
+	span := spans[0]
+	if span.name != "dynssz.MarshalSSZ" || !span.ended || span.err != nil {
+		t.Errorf("unexpected marshal span: %v (ended: %v, error: %v)", span.name, span.ended, span.err)
+	}
+	if span.attrs[otelssz.AttributeType].AsString() != "*otelssz_test.slug_TracedStruct" {
+		t.Errorf("unexpected type attribute: %v", span.attrs[otelssz.AttributeType].AsString())
+	}
+	if span.attrs[otelssz.AttributeSize].AsInt64() != int64(len(ssz)) {
+		t.Errorf("unexpected size attribute: %v", span.attrs[otelssz.AttributeSize].AsInt64())
+	}
+	if span.attrs[otelssz.AttributeCodePath].AsString() != "dynamic" {
+		t.Errorf("unexpected code path attribute: %v", span.attrs[otelssz.AttributeCodePath].AsString())
+	}
+
+	span = spans[1]
+	if span.name != "dynssz.UnmarshalSSZ" || !span.ended || span.err == nil {
+		t.Errorf("unexpected unmarshal span: %v (ended: %v, error: %v)", span.name, span.ended, span.err)
+	}
+}
@@ -0,0 +1,133 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// FieldOption overrides the size or limit annotations of struct fields for a single call of MarshalSSZWithOptions or
+// UnmarshalSSZWithOptions, so one-off deviations from the struct tags do not require new types.
+// Fields are referenced either by name, which applies to the fields with that name in all struct types, or as
+// "Type.Field", which only applies to the field of the named struct type.
+type FieldOption func(overrides Schema)
+
+// WithFieldSize replaces the 'ssz-size' tag of the given field.
+func WithFieldSize(field string, sszSize string) FieldOption {
+	return func(overrides Schema) {
+		fieldSchema := overrides[field]
+		fieldSchema.SszSize = sszSize
+		overrides[field] = fieldSchema
+	}
+}
+
+// WithFieldDynSize replaces the 'dynssz-size' tag of the given field.
+func WithFieldDynSize(field string, dynSszSize string) FieldOption {
+	return func(overrides Schema) {
+		fieldSchema := overrides[field]
+		fieldSchema.DynSszSize = dynSszSize
+		overrides[field] = fieldSchema
+	}
+}
+
+// WithFieldMax replaces the 'ssz-max' tag of the given field.
+func WithFieldMax(field string, sszMax string) FieldOption {
+	return func(overrides Schema) {
+		fieldSchema := overrides[field]
+		fieldSchema.SszMax = sszMax
+		overrides[field] = fieldSchema
+	}
+}
+
+// WithFieldDynMax replaces the 'dynssz-max' tag of the given field.
+func WithFieldDynMax(field string, dynSszMax string) FieldOption {
+	return func(overrides Schema) {
+		fieldSchema := overrides[field]
+		fieldSchema.DynSszMax = dynSszMax
+		overrides[field] = fieldSchema
+	}
+}
+
+// MarshalSSZWithOptions serializes the given source like MarshalSSZ, with the size annotations of the fields
+// overridden by the given options. The overrides take precedence over struct tags and SSZSchema annotations.
+// The type information resolved for a set of overrides is cached, so repeated calls with the same overrides are
+// as fast as calls to MarshalSSZ. Types affected by an override are never encoded via fastssz.
+func (d *DynSsz) MarshalSSZWithOptions(source any, opts ...FieldOption) ([]byte, error) {
+	return d.withFieldOverrides(opts).MarshalSSZ(source)
+}
+
+// UnmarshalSSZWithOptions decodes the given SSZ data into the target like UnmarshalSSZ, with the size annotations
+// of the fields overridden by the given options. See MarshalSSZWithOptions for details.
+func (d *DynSsz) UnmarshalSSZWithOptions(target any, ssz []byte, opts ...FieldOption) error {
+	return d.withFieldOverrides(opts).UnmarshalSSZ(target, ssz)
+}
+
+// MaxSizeSSZWithOptions calculates the maximum SSZ encoded size of the given type like MaxSizeSSZ, with the size
+// and limit annotations of the fields overridden by the given options. See MarshalSSZWithOptions for details.
+func (d *DynSsz) MaxSizeSSZWithOptions(t reflect.Type, opts ...FieldOption) (uint64, error) {
+	return d.withFieldOverrides(opts).MaxSizeSSZ(t)
+}
+
+// withFieldOverrides returns the instance that applies the given field overrides on top of the overrides of this
+// instance. Instances are cached by the fingerprint of their overrides, so the resolved type information is reused.
+func (d *DynSsz) withFieldOverrides(opts []FieldOption) *DynSsz {
+	if len(opts) == 0 {
+		return d
+	}
+
+	overrides := Schema{}
+	for field, fieldSchema := range d.fieldOverrides {
+		overrides[field] = fieldSchema
+	}
+	for _, opt := range opts {
+		opt(overrides)
+	}
+
+	fingerprint := getSchemaFingerprint(overrides)
+
+	d.overrideMutex.Lock()
+	defer d.overrideMutex.Unlock()
+
+	if instance := d.overrideInstances[fingerprint]; instance != nil {
+		return instance
+	}
+
+	instance := NewDynSsz(d.specValues)
+	d.inheritSettings(instance)
+	instance.fieldOverrides = overrides
+
+	if d.overrideInstances == nil {
+		d.overrideInstances = map[string]*DynSsz{}
+	}
+	d.overrideInstances[fingerprint] = instance
+
+	return instance
+}
+
+// getFieldOverride returns the call time override of a struct field, preferring overrides for "Type.Field" over
+// overrides for the field name.
+func (d *DynSsz) getFieldOverride(targetType reflect.Type, fieldName string) (SchemaField, bool) {
+	if d.fieldOverrides == nil {
+		return SchemaField{}, false
+	}
+
+	if fieldSchema, hasOverride := d.fieldOverrides[targetType.Name()+"."+fieldName]; hasOverride {
+		return fieldSchema, true
+	}
+	fieldSchema, hasOverride := d.fieldOverrides[fieldName]
+	return fieldSchema, hasOverride
+}
+
+// getSchemaFingerprint returns a canonical string representation of the given schema.
+func getSchemaFingerprint(schema Schema) string {
+	fields := make([]string, 0, len(schema))
+	for field, fieldSchema := range schema {
+		fields = append(fields, fmt.Sprintf("%q:%q:%q:%q:%q", field, fieldSchema.SszSize, fieldSchema.DynSszSize, fieldSchema.SszMax, fieldSchema.DynSszMax))
+	}
+	sort.Strings(fields)
+	return strings.Join(fields, ",")
+}
@@ -0,0 +1,144 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+// slug_OverrideStruct1 implements the fastssz Marshaler interface with a deliberately wrong encoding,
+// so tests can detect whether the fastssz code path has been used.
+type slug_OverrideStruct1 struct {
+	F1 []uint8 `ssz-size:"2"`
+	F2 slug_OverrideStruct2
+}
+
+func (s *slug_OverrideStruct1) MarshalSSZ() ([]byte, error) {
+	return s.MarshalSSZTo(nil)
+}
+
+func (s *slug_OverrideStruct1) MarshalSSZTo(dst []byte) ([]byte, error) {
+	return append(dst, 0xff, 0xff, 0xff, 0xff, 0xff), nil
+}
+
+func (s *slug_OverrideStruct1) SizeSSZ() int {
+	return 5
+}
+
+type slug_OverrideStruct2 struct {
+	F1 []uint16 `ssz-size:"1"`
+	F2 uint8
+}
+
+type slug_OverrideStruct3 struct {
+	F1 uint16
+	F2 []uint32  `ssz-max:"4" dynssz-max:"SPEC_A"`
+	F3 [][]uint8 `ssz-max:"2,8"`
+	F4 []uint64
+}
+
+func TestMarshalSSZWithOptions(t *testing.T) {
+	dynssz := NewDynSsz(map[string]any{"SPEC_A": uint64(3)})
+
+	payload := &slug_OverrideStruct1{[]uint8{1, 2}, slug_OverrideStruct2{[]uint16{3}, 4}}
+
+	buf, err := dynssz.MarshalSSZ(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(buf, fromHex("0xffffffffff")) {
+		t.Errorf("expected fastssz encoding, got 0x%x", buf)
+	}
+
+	testMatrix := []struct {
+		opts     []FieldOption
+		expected []byte
+		decoded  *slug_OverrideStruct1
+	}{
+		{
+			[]FieldOption{WithFieldSize("F1", "3")},
+			fromHex("0x010200" + "030000000000" + "04"),
+			&slug_OverrideStruct1{[]uint8{1, 2, 0}, slug_OverrideStruct2{[]uint16{3, 0, 0}, 4}},
+		},
+		{
+			[]FieldOption{WithFieldSize("slug_OverrideStruct1.F1", "3")},
+			fromHex("0x010200" + "0300" + "04"),
+			&slug_OverrideStruct1{[]uint8{1, 2, 0}, slug_OverrideStruct2{[]uint16{3}, 4}},
+		},
+		{
+			[]FieldOption{WithFieldSize("slug_OverrideStruct2.F1", "2"), WithFieldDynSize("slug_OverrideStruct2.F1", "SPEC_A")},
+			fromHex("0x0102" + "030000000000" + "04"),
+			&slug_OverrideStruct1{[]uint8{1, 2}, slug_OverrideStruct2{[]uint16{3, 0, 0}, 4}},
+		},
+	}
+
+	for idx, test := range testMatrix {
+		for i := 0; i < 2; i++ {
+			buf, err := dynssz.MarshalSSZWithOptions(payload, test.opts...)
+			if err != nil {
+				t.Fatalf("test %v: unexpected error: %v", idx, err)
+			}
+			if !bytes.Equal(buf, test.expected) {
+				t.Errorf("test %v: unexpected encoding: got 0x%x, wanted 0x%x", idx, buf, test.expected)
+			}
+		}
+
+		decoded := &slug_OverrideStruct1{}
+		if err := dynssz.UnmarshalSSZWithOptions(decoded, test.expected, test.opts...); err != nil {
+			t.Fatalf("test %v: unexpected error: %v", idx, err)
+		}
+		if !reflect.DeepEqual(decoded, test.decoded) {
+			t.Errorf("test %v: unexpected decoding result: %v", idx, decoded)
+		}
+	}
+
+	// overrides must not leak into the instance itself
+	buf, err = dynssz.MarshalSSZ(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(buf, fromHex("0xffffffffff")) {
+		t.Errorf("expected fastssz encoding, got 0x%x", buf)
+	}
+}
+
+func TestMaxSizeSSZWithOptions(t *testing.T) {
+	dynssz := NewDynSsz(map[string]any{"SPEC_A": uint64(3)})
+	structType := reflect.TypeOf(slug_OverrideStruct3{})
+
+	// F4 has no limit, so the limit check of MaxSizeSSZ fails without an override
+	if _, err := dynssz.MaxSizeSSZ(structType); err == nil {
+		t.Errorf("expected error for list without ssz-max limit")
+	}
+
+	tests := []struct {
+		opts     []FieldOption
+		expected uint64
+	}{
+		// 2 + 3*4 + 2*(8+4) + 5*8 + 3 offsets
+		{[]FieldOption{WithFieldMax("F4", "5")}, 2 + 12 + 24 + 40 + 12},
+		// the dynssz-max tag still takes precedence over the replaced ssz-max
+		{[]FieldOption{WithFieldMax("F4", "5"), WithFieldMax("F2", "16")}, 2 + 12 + 24 + 40 + 12},
+		{[]FieldOption{WithFieldMax("F4", "5"), WithFieldDynMax("F2", "SPEC_A*2")}, 2 + 24 + 24 + 40 + 12},
+		{[]FieldOption{WithFieldMax("slug_OverrideStruct3.F4", "1"), WithFieldMax("F3", "1,4")}, 2 + 12 + 8 + 8 + 12},
+	}
+
+	for idx, test := range tests {
+		maxSize, err := dynssz.MaxSizeSSZWithOptions(structType, test.opts...)
+		if err != nil {
+			t.Errorf("test %v: unexpected error: %v", idx, err)
+		} else if maxSize != test.expected {
+			t.Errorf("test %v: unexpected max size %v, expected %v", idx, maxSize, test.expected)
+		}
+	}
+
+	// the overrides only apply to the calls they are passed to
+	if _, err := dynssz.MaxSizeSSZ(structType); err == nil {
+		t.Errorf("expected error for list without ssz-max limit after override")
+	}
+}
@@ -0,0 +1,143 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// PatchSSZ rewrites a single static field within the SSZ encoding of a container type in place, without decoding and
+// re-encoding the whole object. The 'path' parameter references the field like FieldOffset, but nested containers may
+// also be dynamic fields, whose positions are resolved via the offsets in the data.
+// The 'value' parameter must be of the field type (or a pointer to it), its encoding replaces the encoded field.
+// The 'targetType' parameter accepts either an instance or a reflect.Type value of the container type.
+// Returns the generalized index of the patched field (see PathToGIndex), which identifies the subtree whose merkle
+// leaves are invalidated by the patch, so cached hashes can be updated selectively.
+// Returns an error if the field can not be resolved, is dynamic, or the data is malformed. The data is only modified
+// if no error is returned.
+func (d *DynSsz) PatchSSZ(targetType any, ssz []byte, path string, value any) (uint64, error) {
+	sszType, ok := targetType.(reflect.Type)
+	if !ok {
+		sszType = reflect.TypeOf(targetType)
+	}
+
+	names := strings.Split(path, ".")
+	containerType := sszType
+	start, end := 0, len(ssz)
+
+	var field reflect.StructField
+	var fieldSizeHints []sszSizeHint
+	for i, name := range names {
+		fieldStart, fieldEnd, err := d.getPatchFieldRange(containerType, name, ssz, start, end)
+		if err != nil {
+			return 0, fmt.Errorf("failed resolving field %v: %v", strings.Join(names[:i+1], "."), err)
+		}
+
+		field, _, _, fieldSizeHints, err = d.getStructFieldOffset(containerType, name)
+		if err != nil {
+			return 0, err
+		}
+
+		containerType = field.Type
+		start, end = fieldStart, fieldEnd
+	}
+
+	fieldSize, _, err := d.getSszSize(field.Type, fieldSizeHints)
+	if err != nil {
+		return 0, err
+	}
+	if fieldSize < 0 {
+		return 0, fmt.Errorf("field %v is dynamic, only static fields can be patched", path)
+	}
+
+	fieldType := field.Type
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	sourceValue := reflect.ValueOf(value)
+	if sourceValue.Kind() == reflect.Ptr && sourceValue.Type().Elem() == fieldType {
+		sourceValue = sourceValue.Elem()
+	}
+	if !sourceValue.IsValid() || sourceValue.Type() != fieldType {
+		return 0, fmt.Errorf("value of type %T can not be assigned to field %v of type %v", value, path, field.Type)
+	}
+
+	fieldSsz, err := d.marshalType(fieldType, sourceValue, make([]byte, 0, fieldSize), fieldSizeHints, 0)
+	if err != nil {
+		return 0, fmt.Errorf("failed encoding value: %v", err)
+	}
+	if len(fieldSsz) != end-start {
+		return 0, fmt.Errorf("encoded value size %v does not match field size %v", len(fieldSsz), end-start)
+	}
+
+	gidx, err := d.PathToGIndex(sszType, names...)
+	if err != nil {
+		return 0, err
+	}
+
+	copy(ssz[start:end], fieldSsz)
+	return gidx, nil
+}
+
+// getPatchFieldRange returns the ssz range of the named field within the encoded container at ssz[start:end].
+func (d *DynSsz) getPatchFieldRange(containerType reflect.Type, name string, ssz []byte, start int, end int) (int, int, error) {
+	if containerType.Kind() == reflect.Ptr {
+		containerType = containerType.Elem()
+	}
+	if containerType.Kind() != reflect.Struct {
+		return 0, 0, fmt.Errorf("type %v is not a container", containerType)
+	}
+
+	offset := start
+	fieldStart, fieldEnd := -1, -1
+	isDynamic := false
+	for i := 0; i < containerType.NumField(); i++ {
+		field := d.getStructField(containerType, i)
+
+		fieldSize, _, _, err := d.getSszFieldSize(&field)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		if fieldSize >= 0 {
+			if field.Name == name {
+				fieldStart, fieldEnd = offset, offset+fieldSize
+			}
+			offset += fieldSize
+			continue
+		}
+
+		if offset+4 > end {
+			return 0, 0, fmt.Errorf("unexpected end of SSZ. dynamic field %v expects %v bytes (offset), got %v", field.Name, 4, end-offset)
+		}
+		fieldOffset := readOffsetInt(ssz[offset : offset+4])
+		if fieldOffset > end-start {
+			return 0, 0, ErrOffset
+		}
+		dynamicStart := start + fieldOffset
+		if fieldStart >= 0 && fieldEnd < 0 {
+			// offset of the next dynamic field marks the end of the requested field
+			fieldEnd = dynamicStart
+		}
+		if field.Name == name {
+			fieldStart = dynamicStart
+			isDynamic = true
+		}
+		offset += 4
+	}
+
+	if fieldStart < 0 {
+		return 0, 0, &UnknownFieldError{Type: containerType, Field: name}
+	}
+	if fieldEnd < 0 {
+		fieldEnd = end
+	}
+	if offset > end || (isDynamic && fieldStart < offset) || fieldEnd < fieldStart || fieldEnd > end {
+		return 0, 0, ErrOffset
+	}
+
+	return fieldStart, fieldEnd, nil
+}
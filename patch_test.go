@@ -0,0 +1,80 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_PatchInner struct {
+	F1 []uint8
+	F2 uint32
+	F3 []uint8
+}
+
+type slug_PatchStruct struct {
+	F1    uint16
+	Inner *slug_PatchInner
+	F2    uint64
+}
+
+func TestPatchSSZ(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+
+	obj := &slug_PatchStruct{
+		F1:    1,
+		Inner: &slug_PatchInner{[]uint8{1, 2}, 3, []uint8{4}},
+		F2:    5,
+	}
+	ssz, err := dynssz.MarshalSSZ(obj)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	gidx, err := dynssz.PatchSSZ(obj, ssz, "Inner.F2", uint32(42))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected, _ := dynssz.PathToGIndex(obj, "Inner", "F2"); gidx != expected {
+		t.Errorf("unexpected gindex: got %v, wanted %v", gidx, expected)
+	}
+
+	value := uint16(7)
+	if _, err := dynssz.PatchSSZ(reflect.TypeOf(obj), ssz, "F1", &value); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	obj.F1 = 7
+	obj.Inner.F2 = 42
+	expected, _ := dynssz.MarshalSSZ(obj)
+	if !bytes.Equal(ssz, expected) {
+		t.Errorf("unexpected patched encoding: got 0x%x, wanted 0x%x", ssz, expected)
+	}
+
+	orig := append([]byte{}, ssz...)
+	errorMatrix := []struct {
+		path  string
+		value any
+	}{
+		{"Inner.F1", []uint8{1}},
+		{"Inner.F4", uint32(1)},
+		{"F1", uint32(1)},
+		{"F1.F1", uint16(1)},
+	}
+	for _, test := range errorMatrix {
+		if _, err := dynssz.PatchSSZ(obj, ssz, test.path, test.value); err == nil {
+			t.Errorf("%v: expected error", test.path)
+		}
+	}
+	if _, err := dynssz.PatchSSZ(obj, ssz[:8], "Inner.F2", uint32(1)); err == nil {
+		t.Errorf("expected error for truncated data")
+	}
+	if !bytes.Equal(ssz, orig) {
+		t.Errorf("data modified by failed patches")
+	}
+}
@@ -0,0 +1,169 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// PathStat holds the number of times the fastssz and the dynamic code path have been used to encode or decode a type.
+//
+// Fields:
+//   - Type: The encoded or decoded type.
+//   - Operation: The operation, either "marshal" or "unmarshal".
+//   - FastSsz: The number of times the fastssz methods of the type have been used.
+//   - Dynamic: The number of times the type has been handled by the reflection based code path.
+//   - FastsszDisabled: The number of dynamic uses because fastssz is disabled via NoFastSsz.
+//   - NoFastsszMethods: The number of dynamic uses because the type does not implement the fastssz methods
+//     (or they have been disabled via RegisterCompatFlag).
+//   - SpecValues: The number of dynamic uses because non-default spec values apply to the type.
+type PathStat struct {
+	Type             reflect.Type
+	Operation        string
+	FastSsz          uint64
+	Dynamic          uint64
+	FastsszDisabled  uint64
+	NoFastsszMethods uint64
+	SpecValues       uint64
+}
+
+type pathStatKey struct {
+	targetType reflect.Type
+	operation  string
+}
+
+// pathStatCounters holds the counters of a PathStat, updated atomically.
+type pathStatCounters struct {
+	fastSsz          atomic.Uint64
+	dynamic          atomic.Uint64
+	fastsszDisabled  atomic.Uint64
+	noFastsszMethods atomic.Uint64
+	specValues       atomic.Uint64
+}
+
+// pathStats collects the code path statistics of a DynSsz instance.
+type pathStats struct {
+	mutex    sync.RWMutex
+	counters map[pathStatKey]*pathStatCounters
+}
+
+// EnablePathStats enables counting which code path (fastssz or dynamic) is used to encode and decode each type and
+// why fastssz could not be used. The statistics help to audit the performance of production workloads, e.g. to find
+// types that unexpectedly fall back to the slower dynamic code path. Counting adds a small overhead to each encoded
+// or decoded value, so it is disabled by default. Calling EnablePathStats again resets the statistics.
+func (d *DynSsz) EnablePathStats() {
+	d.pathStats = &pathStats{
+		counters: map[pathStatKey]*pathStatCounters{},
+	}
+}
+
+// PathStats returns the code path statistics collected since EnablePathStats has been called, sorted by type name
+// and operation. Returns nil if path statistics are not enabled.
+func (d *DynSsz) PathStats() []PathStat {
+	stats := d.pathStats
+	if stats == nil {
+		return nil
+	}
+
+	stats.mutex.RLock()
+	result := make([]PathStat, 0, len(stats.counters))
+	for key, counters := range stats.counters {
+		result = append(result, PathStat{
+			Type:             key.targetType,
+			Operation:        key.operation,
+			FastSsz:          counters.fastSsz.Load(),
+			Dynamic:          counters.dynamic.Load(),
+			FastsszDisabled:  counters.fastsszDisabled.Load(),
+			NoFastsszMethods: counters.noFastsszMethods.Load(),
+			SpecValues:       counters.specValues.Load(),
+		})
+	}
+	stats.mutex.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Type.String() != result[j].Type.String() {
+			return result[i].Type.String() < result[j].Type.String()
+		}
+		return result[i].Operation < result[j].Operation
+	})
+
+	return result
+}
+
+// CodePathOf returns the code path ("fastssz" or "dynamic") used for the top level of the given type by the given
+// operation ("marshal" or "unmarshal"), and the reason if the dynamic code path is used although the type has fastssz
+// methods. This allows instrumentation, like tracing spans, to be annotated with the code path of a call.
+// The 'targetType' parameter accepts either an instance or a reflect.Type value of the type.
+func (d *DynSsz) CodePathOf(targetType any, operation string) (path string, reason string, err error) {
+	sszType, ok := targetType.(reflect.Type)
+	if !ok {
+		sszType = reflect.TypeOf(targetType)
+	}
+	for sszType.Kind() == reflect.Ptr {
+		sszType = sszType.Elem()
+	}
+
+	fastsszCompat, err := d.getFastsszCompatibility(sszType, []sszSizeHint{})
+	if err != nil {
+		return "", "", err
+	}
+
+	var hasFastSszMethods bool
+	switch operation {
+	case "marshal":
+		hasFastSszMethods = fastsszCompat.isMarshaler
+	case "unmarshal":
+		hasFastSszMethods = fastsszCompat.isUnmarshaler
+	default:
+		return "", "", fmt.Errorf("unknown operation %v, expected marshal or unmarshal", operation)
+	}
+
+	useFastSsz := !d.NoFastSsz && hasFastSszMethods && !fastsszCompat.hasDynamicSpecValues
+	if useFastSsz {
+		return "fastssz", "", nil
+	}
+	return "dynamic", d.getCodePathReason(useFastSsz, hasFastSszMethods, fastsszCompat), nil
+}
+
+// countCodePath counts the code path chosen to encode or decode a type, if path statistics are enabled.
+func (d *DynSsz) countCodePath(operation string, targetType reflect.Type, useFastSsz bool, hasFastSszMethods bool, fastsszCompat *fastsszCompatibility) {
+	stats := d.pathStats
+	if stats == nil {
+		return
+	}
+
+	key := pathStatKey{targetType: targetType, operation: operation}
+	stats.mutex.RLock()
+	counters := stats.counters[key]
+	stats.mutex.RUnlock()
+
+	if counters == nil {
+		stats.mutex.Lock()
+		counters = stats.counters[key]
+		if counters == nil {
+			counters = &pathStatCounters{}
+			stats.counters[key] = counters
+		}
+		stats.mutex.Unlock()
+	}
+
+	if useFastSsz {
+		counters.fastSsz.Add(1)
+		return
+	}
+
+	counters.dynamic.Add(1)
+	switch d.getCodePathReason(useFastSsz, hasFastSszMethods, fastsszCompat) {
+	case "fastssz disabled":
+		counters.fastsszDisabled.Add(1)
+	case "no fastssz methods":
+		counters.noFastsszMethods.Add(1)
+	case "spec values applied":
+		counters.specValues.Add(1)
+	}
+}
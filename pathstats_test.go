@@ -0,0 +1,72 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_PathStatsStruct struct {
+	F1 uint16
+	F2 *slug_FastsszStruct1
+}
+
+func TestPathStats(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+	if dynssz.PathStats() != nil {
+		t.Errorf("expected no stats if not enabled")
+	}
+
+	dynssz.EnablePathStats()
+	for i := 0; i < 2; i++ {
+		if _, err := dynssz.MarshalSSZ(&slug_PathStatsStruct{F2: &slug_FastsszStruct1{}}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	stats := map[reflect.Type]PathStat{}
+	for _, stat := range dynssz.PathStats() {
+		if stat.Operation != "marshal" {
+			t.Errorf("unexpected operation %v for type %v", stat.Operation, stat.Type)
+		}
+		stats[stat.Type] = stat
+	}
+
+	if stat := stats[reflect.TypeOf(slug_PathStatsStruct{})]; stat.Dynamic != 2 || stat.NoFastsszMethods != 2 || stat.FastSsz != 0 {
+		t.Errorf("unexpected stats for container: %+v", stat)
+	}
+	if stat := stats[reflect.TypeOf(slug_FastsszStruct1{})]; stat.FastSsz != 2 || stat.Dynamic != 0 {
+		t.Errorf("unexpected stats for fastssz type: %+v", stat)
+	}
+}
+
+func TestCodePathOf(t *testing.T) {
+	testMatrix := []struct {
+		dynssz    *DynSsz
+		operation string
+		path      string
+		reason    string
+	}{
+		{NewDynSsz(nil), "marshal", "fastssz", ""},
+		{NewDynSsz(nil), "unmarshal", "dynamic", "no fastssz methods"},
+		{NewDynSsz(nil, WithoutFastSSZ()), "marshal", "dynamic", "fastssz disabled"},
+	}
+
+	for _, test := range testMatrix {
+		path, reason, err := test.dynssz.CodePathOf(&slug_FastsszStruct1{}, test.operation)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if path != test.path || reason != test.reason {
+			t.Errorf("unexpected code path for %v: %v (%v), wanted %v (%v)", test.operation, path, reason, test.path, test.reason)
+		}
+	}
+
+	if _, _, err := NewDynSsz(nil).CodePathOf(reflect.TypeOf(slug_FastsszStruct1{}), "hash"); err == nil {
+		t.Errorf("expected error for unknown operation")
+	}
+}
@@ -0,0 +1,151 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// UnmarshalSSZProjection decodes only the given top-level fields of the SSZ-encoded data into the target struct.
+// The fixed size part of the container is parsed to locate the requested fields, all other fields are skipped without
+// being decoded and keep their previous value. This cuts decoding time when only a few fields of large containers
+// are needed. The offsets of all dynamic fields are still checked for integrity.
+// The 'target' parameter must be a pointer to a struct. If no fields are given, all fields are decoded.
+// UnmarshalHook implementations are not called and ValidateAfterDecode is not applied, as skipped fields are incomplete.
+// Returns an UnknownFieldError if a requested field does not exist, or an error if decoding fails.
+func (d *DynSsz) UnmarshalSSZProjection(target any, ssz []byte, fields ...string) error {
+	targetValue := reflect.ValueOf(target)
+	if targetValue.Kind() != reflect.Ptr || targetValue.IsNil() || targetValue.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("projection target must be a pointer to a struct, got %v", reflect.TypeOf(target))
+	}
+
+	targetType := targetValue.Elem().Type()
+	selected := map[string]bool{}
+	for _, name := range fields {
+		if _, exists := targetType.FieldByName(name); !exists {
+			return &UnknownFieldError{Type: targetType, Field: name}
+		}
+		selected[name] = true
+	}
+
+	readRange := func(start int, end int) ([]byte, error) {
+		return ssz[start:end], nil
+	}
+
+	return d.unmarshalStructFields(targetType, targetValue.Elem(), len(ssz), readRange, func(field *reflect.StructField) bool {
+		return len(selected) == 0 || selected[field.Name]
+	}, d.newDecodeGuard())
+}
+
+// unmarshalStructFields decodes the selected fields of a struct from an SSZ range of 'sszSize' bytes, which is accessed
+// via 'readRange'. Only the fixed size part of the container and the ranges of the selected dynamic fields are read.
+// The offsets of all dynamic fields are checked for integrity, fields that are not selected are left untouched.
+// The decoded fields are accounted on the given decode guard, which may be nil if no limits are set.
+func (d *DynSsz) unmarshalStructFields(targetType reflect.Type, targetValue reflect.Value, sszSize int, readRange func(start int, end int) ([]byte, error), selectField func(field *reflect.StructField) bool, guard *decodeGuard) error {
+	fields := make([]reflect.StructField, targetType.NumField())
+	fieldSizes := make([]int, targetType.NumField())
+	fieldSizeHints := make([][]sszSizeHint, targetType.NumField())
+	fixedSize := 0
+
+	if guard != nil {
+		if err := guard.enter(targetType); err != nil {
+			return err
+		}
+		defer guard.leave()
+	}
+
+	for i := range fields {
+		fields[i] = d.getStructField(targetType, i)
+
+		fieldSize, _, sizeHints, err := d.getSszFieldSize(&fields[i])
+		if err != nil {
+			return err
+		}
+
+		fieldSizes[i] = fieldSize
+		fieldSizeHints[i] = sizeHints
+		if fieldSize < 0 {
+			// dynamic size field, 4 byte offset in the fixed part
+			fixedSize += 4
+		} else {
+			fixedSize += fieldSize
+		}
+	}
+
+	if fixedSize > sszSize {
+		return fmt.Errorf("unexpected end of SSZ. container expects %v bytes, got %v", fixedSize, sszSize)
+	}
+
+	fixedSsz, err := readRange(0, fixedSize)
+	if err != nil {
+		return err
+	}
+
+	// decode static fields & collect offsets of dynamic fields
+	offset := 0
+	dynamicFields := []int{}
+	dynamicOffsets := []int{}
+	for i := range fields {
+		if fieldSizes[i] < 0 {
+			dynamicFields = append(dynamicFields, i)
+			dynamicOffsets = append(dynamicOffsets, readOffsetInt(fixedSsz[offset:offset+4]))
+			offset += 4
+			continue
+		}
+
+		if selectField(&fields[i]) {
+			fieldSsz := fixedSsz[offset : offset+fieldSizes[i]]
+			consumedBytes, err := d.unmarshalType(fields[i].Type, targetValue.Field(i), fieldSsz, fieldSizeHints[i], guard, 0)
+			if err != nil {
+				return fmt.Errorf("failed decoding field %v: %w", fields[i].Name, err)
+			}
+			if consumedBytes != fieldSizes[i] {
+				return fmt.Errorf("struct field did not consume expected ssz range (consumed: %v, expected: %v)", consumedBytes, fieldSizes[i])
+			}
+		}
+		offset += fieldSizes[i]
+	}
+
+	if len(dynamicFields) == 0 && sszSize != fixedSize {
+		return fmt.Errorf("did not consume full ssz range (consumed: %v, ssz size: %v)", fixedSize, sszSize)
+	}
+	if len(dynamicFields) > 0 && dynamicOffsets[0] != fixedSize {
+		return ErrInvalidVariableOffset
+	}
+
+	// decode selected dynamic fields
+	for i, fieldIdx := range dynamicFields {
+		startOffset := dynamicOffsets[i]
+		endOffset := sszSize
+		if i < len(dynamicFields)-1 {
+			endOffset = dynamicOffsets[i+1]
+		}
+
+		// check offset integrity (not before previous field offset & not after range end)
+		if startOffset > endOffset || endOffset > sszSize {
+			return ErrOffset
+		}
+
+		field := &fields[fieldIdx]
+		if !selectField(field) {
+			continue
+		}
+
+		fieldSsz, err := readRange(startOffset, endOffset)
+		if err != nil {
+			return err
+		}
+
+		consumedBytes, err := d.unmarshalType(field.Type, targetValue.Field(fieldIdx), fieldSsz, fieldSizeHints[fieldIdx], guard, 0)
+		if err != nil {
+			return fmt.Errorf("failed decoding field %v: %w", field.Name, err)
+		}
+		if consumedBytes != endOffset-startOffset {
+			return fmt.Errorf("struct field did not consume expected ssz range (consumed: %v, expected: %v)", consumedBytes, endOffset-startOffset)
+		}
+	}
+
+	return nil
+}
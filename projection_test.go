@@ -0,0 +1,38 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+func TestUnmarshalSSZProjection(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+
+	payload := &slug_ReaderAtStruct{F1: 1, F2: []uint8{2, 3, 4}, F3: [2]uint8{5, 6}, F4: []uint16{7, 8}}
+	ssz, err := dynssz.MarshalSSZ(payload)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+
+	obj := &slug_ReaderAtStruct{}
+	if err := dynssz.UnmarshalSSZProjection(obj, ssz, "F3", "F4"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(obj, &slug_ReaderAtStruct{F3: [2]uint8{5, 6}, F4: []uint16{7, 8}}) {
+		t.Errorf("unexpected result: %+v", obj)
+	}
+
+	err = dynssz.UnmarshalSSZProjection(obj, ssz, "F1", "F9")
+	if fieldErr, ok := err.(*UnknownFieldError); !ok || fieldErr.Field != "F9" {
+		t.Errorf("expected UnknownFieldError for F9, got %v", err)
+	}
+
+	if err := dynssz.UnmarshalSSZProjection(obj, ssz[:8], "F1"); err == nil {
+		t.Errorf("expected error for truncated data")
+	}
+}
@@ -0,0 +1,84 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// UnmarshalSSZReaderAt decodes 'size' bytes of SSZ-encoded data from the random access reader 'ra' into the target
+// object. For struct targets, only the fixed size part of the container is read upfront, the ranges of the dynamic
+// fields are then read one by one via their offsets. Fields listed in 'ignoredFields' are not read at all and keep
+// their previous value, which allows extracting a few fields from huge objects stored on disk or object storage.
+// Targets other than structs are read and decoded completely, in which case 'ignoredFields' must be empty.
+// UnmarshalHook implementations are only called and ValidateAfterDecode is only applied if no fields are ignored.
+// The ranges read from 'ra' count towards the MaxTotalAlloc limit of the DecodeGuards.
+// Returns an UnknownFieldError if an ignored field does not exist, or an error if reading from 'ra' or decoding fails.
+func (d *DynSsz) UnmarshalSSZReaderAt(target any, ra io.ReaderAt, size int, ignoredFields ...string) error {
+	if size < 0 {
+		return fmt.Errorf("invalid ssz size %v", size)
+	}
+
+	guard := d.newDecodeGuard()
+	readRange := func(start int, end int) ([]byte, error) {
+		if start < 0 || end < start {
+			return nil, ErrOffset
+		}
+		if err := guard.alloc(reflect.TypeOf(target), uint64(end-start)); err != nil {
+			return nil, err
+		}
+
+		buf := make([]byte, end-start)
+		// ReaderAt implementations may return io.EOF along with a complete read at the end of the input
+		if n, err := ra.ReadAt(buf, int64(start)); err != nil && (err != io.EOF || n != len(buf)) {
+			return nil, fmt.Errorf("failed reading ssz data: %v", err)
+		}
+		return buf, nil
+	}
+
+	targetValue := reflect.ValueOf(target)
+	if targetValue.Kind() != reflect.Ptr || targetValue.IsNil() || targetValue.Elem().Kind() != reflect.Struct {
+		if len(ignoredFields) > 0 {
+			return fmt.Errorf("ignoring fields is only supported for struct targets")
+		}
+
+		ssz, err := readRange(0, size)
+		if err != nil {
+			return err
+		}
+		return d.UnmarshalSSZ(target, ssz)
+	}
+
+	targetType := targetValue.Elem().Type()
+	ignored := map[string]bool{}
+	for _, name := range ignoredFields {
+		if _, exists := targetType.FieldByName(name); !exists {
+			return &UnknownFieldError{Type: targetType, Field: name}
+		}
+		ignored[name] = true
+	}
+
+	err := d.unmarshalStructFields(targetType, targetValue.Elem(), size, readRange, func(field *reflect.StructField) bool {
+		return !ignored[field.Name]
+	}, guard)
+	if err != nil {
+		return err
+	}
+
+	if len(ignoredFields) > 0 {
+		return nil
+	}
+
+	if err := d.runUnmarshalHooks(targetValue.Type(), targetValue); err != nil {
+		return err
+	}
+
+	if d.ValidateAfterDecode {
+		return d.ValidateSSZ(target)
+	}
+
+	return nil
+}
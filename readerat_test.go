@@ -0,0 +1,123 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_ReaderAtStruct struct {
+	F1 uint16
+	F2 []uint8 `ssz-size:"?" dynssz-size:"?"`
+	F3 [2]uint8
+	F4 []uint16 `ssz-size:"?" dynssz-size:"?"`
+}
+
+// readerAtCounter records the ranges read from the underlying reader.
+type readerAtCounter struct {
+	reader io.ReaderAt
+	reads  [][2]int64
+}
+
+func (r *readerAtCounter) ReadAt(p []byte, off int64) (int, error) {
+	r.reads = append(r.reads, [2]int64{off, off + int64(len(p))})
+	return r.reader.ReadAt(p, off)
+}
+
+// eofReaderAt returns io.EOF along with reads that reach the end of the input, which io.ReaderAt permits.
+type eofReaderAt struct {
+	data []byte
+}
+
+func (r *eofReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n := copy(p, r.data[off:])
+	if int(off)+n == len(r.data) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func TestUnmarshalSSZReaderAt(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+
+	payload := &slug_ReaderAtStruct{F1: 1, F2: []uint8{2, 3, 4}, F3: [2]uint8{5, 6}, F4: []uint16{7, 8}}
+	ssz, err := dynssz.MarshalSSZ(payload)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+
+	obj := &slug_ReaderAtStruct{}
+	if err := dynssz.UnmarshalSSZReaderAt(obj, bytes.NewReader(ssz), len(ssz)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(obj, payload) {
+		t.Errorf("unexpected result: %+v, wanted %+v", obj, payload)
+	}
+
+	// ignored fields are not read
+	reader := &readerAtCounter{reader: bytes.NewReader(ssz)}
+	obj = &slug_ReaderAtStruct{}
+	if err := dynssz.UnmarshalSSZReaderAt(obj, reader, len(ssz), "F2", "F3"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(obj, &slug_ReaderAtStruct{F1: 1, F4: []uint16{7, 8}}) {
+		t.Errorf("unexpected result: %+v", obj)
+	}
+	if expected := [][2]int64{{0, 12}, {15, 19}}; !reflect.DeepEqual(reader.reads, expected) {
+		t.Errorf("unexpected reads: %v, wanted %v", reader.reads, expected)
+	}
+
+	if err := dynssz.UnmarshalSSZReaderAt(obj, bytes.NewReader(ssz), len(ssz), "F9"); err == nil {
+		t.Errorf("expected error for unknown field")
+	}
+
+	// corrupt offset of F4
+	ssz[8] = 0xff
+	if err := dynssz.UnmarshalSSZReaderAt(&slug_ReaderAtStruct{}, bytes.NewReader(ssz), len(ssz), "F4"); err != ErrOffset {
+		t.Errorf("expected ErrOffset, got %v", err)
+	}
+}
+
+func TestUnmarshalSSZReaderAtLimits(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+
+	payload := &slug_ReaderAtStruct{F1: 1, F2: []uint8{2, 3, 4}, F3: [2]uint8{5, 6}, F4: []uint16{7, 8}}
+	ssz, err := dynssz.MarshalSSZ(payload)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+
+	obj := &slug_ReaderAtStruct{}
+	if err := dynssz.UnmarshalSSZReaderAt(obj, &eofReaderAt{data: ssz}, len(ssz)); err != nil {
+		t.Fatalf("unexpected error for io.EOF with a complete read: %v", err)
+	}
+	if !reflect.DeepEqual(obj, payload) {
+		t.Errorf("unexpected result: %+v, wanted %+v", obj, payload)
+	}
+
+	if err := dynssz.UnmarshalSSZReaderAt(&slug_ReaderAtStruct{}, &eofReaderAt{data: ssz}, len(ssz)+1); err == nil {
+		t.Errorf("expected error for short read")
+	}
+	if err := dynssz.UnmarshalSSZReaderAt(&slug_ReaderAtStruct{}, bytes.NewReader(ssz), -1); err == nil {
+		t.Errorf("expected error for negative size")
+	}
+	var list []uint16
+	if err := dynssz.UnmarshalSSZReaderAt(&list, bytes.NewReader(ssz), -1); err == nil {
+		t.Errorf("expected error for negative size")
+	}
+
+	dynssz.DecodeGuards = DecodeGuards{MaxTotalAlloc: 16}
+	if err := dynssz.UnmarshalSSZReaderAt(&list, bytes.NewReader(ssz), 1<<30); !errors.Is(err, ErrDecodeGuard) {
+		t.Errorf("expected ErrDecodeGuard for size above MaxTotalAlloc, got %v", err)
+	}
+	if err := dynssz.UnmarshalSSZReaderAt(&slug_ReaderAtStruct{}, bytes.NewReader(ssz), 1<<30); !errors.Is(err, ErrDecodeGuard) {
+		t.Errorf("expected ErrDecodeGuard for ranges above MaxTotalAlloc, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Reset zeroes all SSZ-visible values of the given object while retaining its memory, so the object can be reused
+// as decoding target (see UnmarshalSSZ). Slices are truncated to length 0 with their capacity retained, after the
+// items have been reset recursively, and pointers keep pointing to their reset values.
+// The 'target' parameter must be a pointer to the object. Returns an error if the target is not a pointer.
+func Reset(target any) error {
+	targetValue := reflect.ValueOf(target)
+	if targetValue.Kind() != reflect.Ptr {
+		return fmt.Errorf("target must be a pointer, got %v", targetValue.Kind())
+	}
+
+	resetValue(targetValue)
+	return nil
+}
+
+// resetValue recursively zeroes the given value, retaining the capacity of slices and the allocations of pointers.
+func resetValue(targetValue reflect.Value) {
+	switch targetValue.Kind() {
+	case reflect.Ptr:
+		if !targetValue.IsNil() {
+			resetValue(targetValue.Elem())
+		}
+	case reflect.Struct:
+		for i := 0; i < targetValue.NumField(); i++ {
+			if field := targetValue.Field(i); field.CanSet() {
+				resetValue(field)
+			}
+		}
+	case reflect.Slice:
+		if targetValue.IsNil() {
+			return
+		}
+		if hasHeapReferences(targetValue.Type().Elem()) {
+			for i := 0; i < targetValue.Len(); i++ {
+				resetValue(targetValue.Index(i))
+			}
+		}
+		targetValue.SetLen(0)
+	case reflect.Array:
+		if hasHeapReferences(targetValue.Type().Elem()) {
+			for i := 0; i < targetValue.Len(); i++ {
+				resetValue(targetValue.Index(i))
+			}
+		} else {
+			targetValue.Set(reflect.Zero(targetValue.Type()))
+		}
+	default:
+		targetValue.Set(reflect.Zero(targetValue.Type()))
+	}
+}
@@ -0,0 +1,74 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_ResetStruct1 struct {
+	F1 uint16
+	F2 []slug_DynStruct1 `ssz-max:"8"`
+	F3 [2]uint8
+	F4 *slug_StaticStruct1
+}
+
+func TestReset(t *testing.T) {
+	obj := &slug_ResetStruct1{
+		F1: 1,
+		F2: []slug_DynStruct1{{true, []uint8{1, 2, 3}}, {true, []uint8{4}}},
+		F3: [2]uint8{5, 6},
+		F4: &slug_StaticStruct1{true, []uint8{7, 8, 9}},
+	}
+	items := obj.F2
+	inner := obj.F4
+
+	if err := Reset(obj); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if obj.F1 != 0 || len(obj.F2) != 0 || cap(obj.F2) != 2 || obj.F3 != [2]uint8{} || obj.F4 != inner {
+		t.Errorf("unexpected reset result: %v", obj)
+	}
+	if items[0].F1 || len(items[0].F2) != 0 || cap(items[0].F2) != 3 {
+		t.Errorf("slice items not reset: %v", items[0])
+	}
+	if inner.F1 || len(inner.F2) != 0 {
+		t.Errorf("pointer value not reset: %v", inner)
+	}
+
+	if err := Reset(*obj); err == nil {
+		t.Errorf("expected error for non-pointer target")
+	}
+}
+
+func TestUnmarshalReuse(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+	dynssz.NoFastSsz = true
+
+	obj := &slug_DynStruct1{}
+	if err := dynssz.UnmarshalSSZ(obj, fromHex("0x010500000001020304")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	buf := obj.F2
+
+	if err := dynssz.UnmarshalSSZ(obj, fromHex("0x00050000000506")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if obj.F1 || !bytes.Equal(obj.F2, []uint8{5, 6}) {
+		t.Errorf("unexpected decoding result: %v", obj)
+	}
+	if &obj.F2[0] != &buf[0] {
+		t.Errorf("expected slice capacity to be reused")
+	}
+
+	if err := dynssz.UnmarshalSSZ(obj, fromHex("0x0105000000010203040506")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !obj.F1 || !bytes.Equal(obj.F2, []uint8{1, 2, 3, 4, 5, 6}) {
+		t.Errorf("unexpected decoding result: %v", obj)
+	}
+}
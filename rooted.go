@@ -0,0 +1,87 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"sync"
+)
+
+// Rooted holds an immutable value together with its SSZ encoding and its hash tree root, so services do not need
+// to re-encode or re-hash objects they just decoded. The root is calculated on first use and cached.
+// The value must not be modified in place, as this would break the consistency with the cached encoding and root.
+// Use Mutate to derive a modified copy instead. A Rooted value is safe for concurrent use.
+type Rooted[T any] struct {
+	codec    *Codec[T]
+	value    *T
+	ssz      []byte
+	rootOnce sync.Once
+	root     [32]byte
+	rootErr  error
+}
+
+// NewRooted encodes the given value and returns it bound to its encoding. The value must not be modified afterwards.
+func (c *Codec[T]) NewRooted(value *T) (*Rooted[T], error) {
+	ssz, err := c.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Rooted[T]{
+		codec: c,
+		value: value,
+		ssz:   ssz,
+	}, nil
+}
+
+// DecodeRooted decodes the given SSZ data and returns the decoded value bound to a copy of the data.
+func (c *Codec[T]) DecodeRooted(ssz []byte) (*Rooted[T], error) {
+	ssz = append([]byte{}, ssz...)
+
+	value := new(T)
+	if err := c.Unmarshal(value, ssz); err != nil {
+		return nil, err
+	}
+
+	return &Rooted[T]{
+		codec: c,
+		value: value,
+		ssz:   ssz,
+	}, nil
+}
+
+// Value returns the value. It must not be modified, use Mutate to derive a modified copy.
+func (r *Rooted[T]) Value() *T {
+	return r.value
+}
+
+// SSZ returns the SSZ encoding of the value. The returned slice must not be modified.
+func (r *Rooted[T]) SSZ() []byte {
+	return r.ssz
+}
+
+// Root returns the hash tree root of the value. The root is calculated via Codec.Root on first use, so the same
+// restrictions apply. The result, including a failure, is cached for subsequent calls.
+func (r *Rooted[T]) Root() ([32]byte, error) {
+	r.rootOnce.Do(func() {
+		r.root, r.rootErr = r.codec.Root(r.value)
+	})
+	return r.root, r.rootErr
+}
+
+// Mutate derives a modified copy of the value. The 'mutate' callback is called with a deep copy of the value,
+// decoded from the cached encoding, and the modified copy is encoded into the returned Rooted value.
+// The receiver is left untouched. Returns the error of the callback, or an error if encoding the copy fails.
+func (r *Rooted[T]) Mutate(mutate func(value *T) error) (*Rooted[T], error) {
+	// decode from a copy, as zero copy decoding would alias the cached encoding
+	value := new(T)
+	if err := r.codec.Unmarshal(value, append([]byte{}, r.ssz...)); err != nil {
+		return nil, err
+	}
+
+	if err := mutate(value); err != nil {
+		return nil, err
+	}
+
+	return r.codec.NewRooted(value)
+}
@@ -0,0 +1,63 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+func TestRooted(t *testing.T) {
+	dynssz := NewDynSsz(nil, WithZeroCopyDecode())
+	codec, err := NewCodec[slug_CodecStruct1](dynssz)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ssz := fromHex("0x010000000800000004080c")
+	rooted, err := codec.DecodeRooted(ssz)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ssz[8] = 0xff
+	if !bytes.Equal(rooted.SSZ(), fromHex("0x010000000800000004080c")) || rooted.Value().F2[0] != 4 {
+		t.Errorf("rooted value aliases the input buffer")
+	}
+
+	mutated, err := rooted.Mutate(func(value *slug_CodecStruct1) error {
+		value.F1 = 2
+		value.F2[0] = 5
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(mutated.SSZ(), fromHex("0x020000000800000005080c")) || mutated.Value().F1 != 2 {
+		t.Errorf("unexpected mutated encoding: 0x%x", mutated.SSZ())
+	}
+	if !bytes.Equal(rooted.SSZ(), fromHex("0x010000000800000004080c")) || rooted.Value().F1 != 1 || rooted.Value().F2[0] != 4 {
+		t.Errorf("mutation modified the original value")
+	}
+
+	if _, err := rooted.Mutate(func(value *slug_CodecStruct1) error {
+		return fmt.Errorf("test error")
+	}); err == nil {
+		t.Errorf("expected callback error")
+	}
+
+	if _, err := rooted.Root(); err == nil {
+		t.Errorf("expected error for type without HashTreeRoot")
+	}
+
+	created, err := codec.NewRooted(&slug_CodecStruct1{F1: 3, F2: []uint8{1}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(created.SSZ(), fromHex("0x030000000800000001")) {
+		t.Errorf("unexpected encoding: 0x%x", created.SSZ())
+	}
+}
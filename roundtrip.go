@@ -0,0 +1,196 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+)
+
+// roundTripWindow is the number of bytes from the first mismatch that are reported in a RoundTripError.
+const roundTripWindow = 16
+
+// RoundTripError is returned by the marshal and unmarshal functions if VerifyRoundTrip is set and the data does not
+// survive a round trip. Op is "unmarshal" if the re-encoding of a decoded object differs from the decoded data, and
+// "marshal" if an encoding does not decode and re-encode to the same data.
+// For mismatches, Offset is the position of the first differing byte, Field the path of the field at that position
+// (e.g. "Message.Body.Deposits[3].Amount", empty if unknown) and Expected and Actual hold the data from that position.
+// Err is set instead if the data could not be re-encoded or re-decoded at all.
+type RoundTripError struct {
+	Type           reflect.Type
+	Op             string
+	Offset         int
+	Field          string
+	Expected       []byte
+	Actual         []byte
+	ExpectedLength int
+	ActualLength   int
+	Err            error
+}
+
+func (e *RoundTripError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%v round trip of type %v failed: %v", e.Op, e.Type, e.Err)
+	}
+
+	field := ""
+	if e.Field != "" {
+		field = fmt.Sprintf(" (field %v)", e.Field)
+	}
+	return fmt.Sprintf("%v round trip of type %v failed: data differs at offset %v%v, expected 0x%x, got 0x%x (length: expected %v, got %v)",
+		e.Op, e.Type, e.Offset, field, e.Expected, e.Actual, e.ExpectedLength, e.ActualLength)
+}
+
+func (e *RoundTripError) Unwrap() error {
+	return e.Err
+}
+
+// verifyUnmarshalRoundTrip re-encodes a decoded object and compares the encoding with the decoded data.
+func (d *DynSsz) verifyUnmarshalRoundTrip(targetType reflect.Type, targetValue reflect.Value, ssz []byte) error {
+	encoded, err := d.marshalType(targetType, targetValue, make([]byte, 0, len(ssz)), []sszSizeHint{}, 0)
+	if err != nil {
+		return &RoundTripError{Type: targetType, Op: "unmarshal", Err: fmt.Errorf("failed re-encoding decoded object: %v", err)}
+	}
+
+	return d.compareRoundTrip(targetType, targetValue, "unmarshal", ssz, encoded)
+}
+
+// verifyMarshalRoundTrip decodes an encoding into a new object of the source type and compares the re-encoding of that
+// object with the original encoding. As the encoding defines the hash tree root, this also guarantees equal roots.
+func (d *DynSsz) verifyMarshalRoundTrip(sourceType reflect.Type, ssz []byte) error {
+	valueType := sourceType
+	if valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+	}
+	decodedValue := reflect.New(valueType)
+
+	consumedBytes, err := d.unmarshalType(decodedValue.Type(), decodedValue, ssz, []sszSizeHint{}, nil, 0)
+	if err == nil && consumedBytes != len(ssz) {
+		err = fmt.Errorf("did not consume full ssz range (consumed: %v, ssz size: %v)", consumedBytes, len(ssz))
+	}
+	if err != nil {
+		return &RoundTripError{Type: sourceType, Op: "marshal", Err: fmt.Errorf("failed decoding encoded object: %v", err)}
+	}
+
+	encoded, err := d.marshalType(decodedValue.Type(), decodedValue, make([]byte, 0, len(ssz)), []sszSizeHint{}, 0)
+	if err != nil {
+		return &RoundTripError{Type: sourceType, Op: "marshal", Err: fmt.Errorf("failed re-encoding decoded object: %v", err)}
+	}
+
+	return d.compareRoundTrip(decodedValue.Type(), decodedValue, "marshal", ssz, encoded)
+}
+
+// compareRoundTrip compares the expected data with the data encoded from the given value, and returns a
+// RoundTripError describing the first mismatch.
+func (d *DynSsz) compareRoundTrip(valueType reflect.Type, value reflect.Value, op string, expected []byte, actual []byte) error {
+	if bytes.Equal(expected, actual) {
+		return nil
+	}
+
+	offset := 0
+	for offset < len(expected) && offset < len(actual) && expected[offset] == actual[offset] {
+		offset++
+	}
+
+	return &RoundTripError{
+		Type:           valueType,
+		Op:             op,
+		Offset:         offset,
+		Field:          d.getFieldPathAt(valueType, value, []sszSizeHint{}, offset),
+		Expected:       getRoundTripWindow(expected, offset),
+		Actual:         getRoundTripWindow(actual, offset),
+		ExpectedLength: len(expected),
+		ActualLength:   len(actual),
+	}
+}
+
+func getRoundTripWindow(data []byte, offset int) []byte {
+	end := offset + roundTripWindow
+	if end > len(data) {
+		end = len(data)
+	}
+	return data[offset:end]
+}
+
+// getFieldPathAt returns the path of the field that covers the given position within the encoding of the value, or an
+// empty string if the position can not be attributed to a field.
+func (d *DynSsz) getFieldPathAt(valueType reflect.Type, value reflect.Value, sizeHints []sszSizeHint, offset int) string {
+	for valueType.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return ""
+		}
+		valueType = valueType.Elem()
+		value = value.Elem()
+	}
+
+	switch valueType.Kind() {
+	case reflect.Struct:
+		position := 0
+		dynamicFields := []int{}
+		dynamicSizeHints := [][]sszSizeHint{}
+		for i := 0; i < valueType.NumField(); i++ {
+			field := d.getStructField(valueType, i)
+			fieldSize, _, fieldSizeHints, err := d.getSszFieldSize(&field)
+			if err != nil {
+				return ""
+			}
+
+			if fieldSize < 0 {
+				dynamicFields = append(dynamicFields, i)
+				dynamicSizeHints = append(dynamicSizeHints, fieldSizeHints)
+
+				// the offset of a dynamic field is attributed to the field itself
+				if offset >= position && offset < position+4 {
+					return field.Name
+				}
+				position += 4
+				continue
+			}
+
+			if offset >= position && offset < position+fieldSize {
+				return joinFieldPath(field.Name, d.getFieldPathAt(field.Type, value.Field(i), fieldSizeHints, offset-position))
+			}
+			position += fieldSize
+		}
+
+		for i, fieldIdx := range dynamicFields {
+			field := d.getStructField(valueType, fieldIdx)
+			fieldSize, err := d.getSszValueSize(field.Type, value.Field(fieldIdx), dynamicSizeHints[i])
+			if err != nil {
+				return ""
+			}
+			if offset >= position && offset < position+fieldSize {
+				return joinFieldPath(field.Name, d.getFieldPathAt(field.Type, value.Field(fieldIdx), dynamicSizeHints[i], offset-position))
+			}
+			position += fieldSize
+		}
+	case reflect.Array, reflect.Slice:
+		childSizeHints := []sszSizeHint{}
+		if len(sizeHints) > 1 {
+			childSizeHints = sizeHints[1:]
+		}
+
+		// only items of static size can be located without walking the offsets
+		itemSize, _, err := d.getSszSize(valueType.Elem(), childSizeHints)
+		if err != nil || itemSize <= 0 {
+			return ""
+		}
+		index := offset / itemSize
+		if index >= value.Len() {
+			return ""
+		}
+		return joinFieldPath(fmt.Sprintf("[%v]", index), d.getFieldPathAt(valueType.Elem(), value.Index(index), childSizeHints, offset-index*itemSize))
+	}
+
+	return ""
+}
+
+// joinFieldPath joins a path step with the path below it, e.g. "Body" and "Deposits[3]" to "Body.Deposits[3]".
+func joinFieldPath(step string, path string) string {
+	if path == "" || path[0] == '[' {
+		return step + path
+	}
+	return step + "." + path
+}
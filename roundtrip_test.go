@@ -0,0 +1,69 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+// slug_LossyBool encodes true as 0x02 via its fastssz method, which decodes to false.
+type slug_LossyBool struct {
+	F1 bool
+}
+
+func (s *slug_LossyBool) MarshalSSZTo(dst []byte) ([]byte, error) {
+	if s.F1 {
+		return append(dst, 2), nil
+	}
+	return append(dst, 0), nil
+}
+
+func (s *slug_LossyBool) MarshalSSZ() ([]byte, error) {
+	return s.MarshalSSZTo(nil)
+}
+
+func (s *slug_LossyBool) SizeSSZ() int {
+	return 1
+}
+
+type slug_LossyContainer struct {
+	F1 uint16
+	F2 []uint8           `ssz-max:"8"`
+	F3 []*slug_LossyBool `ssz-size:"2"`
+}
+
+func TestVerifyRoundTrip(t *testing.T) {
+	dynssz := NewDynSsz(nil, WithVerifyRoundTrip())
+
+	// canonical data passes
+	obj := slug_DynStruct1{}
+	if err := dynssz.UnmarshalSSZ(&obj, fromHex("0x0105000000aabb")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := dynssz.MarshalSSZ(&obj); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// non-canonical bool value decodes to false
+	err := dynssz.UnmarshalSSZ(&slug_DynStruct1{}, fromHex("0x0205000000aabb"))
+	var roundTripErr *RoundTripError
+	if !errors.As(err, &roundTripErr) {
+		t.Fatalf("expected round trip error, got: %v", err)
+	}
+	if roundTripErr.Op != "unmarshal" || roundTripErr.Offset != 0 || roundTripErr.Field != "F1" {
+		t.Errorf("unexpected round trip error: %v", err)
+	}
+
+	// lossy encoding of a nested item
+	_, err = dynssz.MarshalSSZ(&slug_LossyContainer{F1: 1, F3: []*slug_LossyBool{{}, {F1: true}}})
+	if !errors.As(err, &roundTripErr) {
+		t.Fatalf("expected round trip error, got: %v", err)
+	}
+	if roundTripErr.Op != "marshal" || roundTripErr.Offset != 7 || roundTripErr.Field != "F3[1].F1" {
+		t.Errorf("unexpected round trip error: %v", err)
+	}
+}
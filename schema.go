@@ -0,0 +1,90 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// SchemaField holds the size and limit annotations of a single struct field, using the same format as the
+// corresponding struct tags. Empty values leave the struct tag of the field untouched.
+type SchemaField struct {
+	SszSize    string // replaces the 'ssz-size' tag
+	DynSszSize string // replaces the 'dynssz-size' tag
+	SszMax     string // replaces the 'ssz-max' tag
+	DynSszMax  string // replaces the 'dynssz-max' tag
+}
+
+// Schema maps struct field names to their size annotations.
+type Schema map[string]SchemaField
+
+// SchemaProvider is the interface implemented by types that provide their size annotations programmatically.
+// This is useful for types whose struct tags can not be modified (e.g. vendored types) or whose sizes are only known
+// at runtime. Annotations returned by SSZSchema take precedence over the struct tags of the fields.
+// SSZSchema is called once per type on a zero value of the type, the result is cached by the DynSsz instance.
+type SchemaProvider interface {
+	SSZSchema() Schema
+}
+
+var schemaProviderType = reflect.TypeOf((*SchemaProvider)(nil)).Elem()
+
+// getSchema returns the schema provided by the given struct type, or nil if the type does not implement SchemaProvider.
+func (d *DynSsz) getSchema(targetType reflect.Type) Schema {
+	d.schemaMutex.RLock()
+	schema, isCached := d.schemaCache[targetType]
+	d.schemaMutex.RUnlock()
+	if isCached {
+		return schema
+	}
+
+	targetPtrType := reflect.New(targetType).Type()
+	if targetPtrType.Implements(schemaProviderType) {
+		schema = reflect.New(targetType).Interface().(SchemaProvider).SSZSchema()
+	}
+
+	d.schemaMutex.Lock()
+	d.schemaCache[targetType] = schema
+	d.schemaMutex.Unlock()
+
+	return schema
+}
+
+// getStructField returns the field with the given index of a struct type. If the struct type provides a schema via
+// SchemaProvider, the size annotations from the schema are applied to the tags of the returned field, followed by the
+// call time field overrides of the instance.
+func (d *DynSsz) getStructField(targetType reflect.Type, index int) reflect.StructField {
+	field := targetType.Field(index)
+
+	schema := d.getSchema(targetType)
+	if schema == nil && d.fieldOverrides == nil {
+		return field
+	}
+
+	if fieldSchema, hasSchema := schema[field.Name]; hasSchema {
+		applySchemaField(&field, fieldSchema)
+	}
+	if fieldSchema, hasOverride := d.getFieldOverride(targetType, field.Name); hasOverride {
+		applySchemaField(&field, fieldSchema)
+	}
+
+	return field
+}
+
+// applySchemaField applies the size and limit annotations of a schema field to the tags of the struct field.
+func applySchemaField(field *reflect.StructField, fieldSchema SchemaField) {
+	// tag lookups return the first match, so prepending overrides the original annotations
+	if fieldSchema.DynSszSize != "" {
+		field.Tag = reflect.StructTag(fmt.Sprintf("dynssz-size:%q ", fieldSchema.DynSszSize)) + field.Tag
+	}
+	if fieldSchema.SszSize != "" {
+		field.Tag = reflect.StructTag(fmt.Sprintf("ssz-size:%q ", fieldSchema.SszSize)) + field.Tag
+	}
+	if fieldSchema.DynSszMax != "" {
+		field.Tag = reflect.StructTag(fmt.Sprintf("dynssz-max:%q ", fieldSchema.DynSszMax)) + field.Tag
+	}
+	if fieldSchema.SszMax != "" {
+		field.Tag = reflect.StructTag(fmt.Sprintf("ssz-max:%q ", fieldSchema.SszMax)) + field.Tag
+	}
+}
@@ -0,0 +1,57 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_SchemaStruct1 struct {
+	F1 []uint8 `ssz-size:"2"`
+	F2 []uint16
+	F3 uint8
+}
+
+func (s *slug_SchemaStruct1) SSZSchema() Schema {
+	return Schema{
+		"F1": {SszSize: "4"},
+		"F2": {SszSize: "2", DynSszSize: "SPEC_A"},
+		"F4": {SszSize: "1"},
+	}
+}
+
+func TestSchemaProvider(t *testing.T) {
+	dynssz := NewDynSsz(map[string]any{"SPEC_A": uint64(3)})
+
+	payload := &slug_SchemaStruct1{[]uint8{1, 2}, []uint16{3}, 4}
+	expected := fromHex("0x01020000030000000000" + "04")
+
+	buf, err := dynssz.MarshalSSZ(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(buf, expected) {
+		t.Errorf("unexpected encoding: got 0x%x, wanted 0x%x", buf, expected)
+	}
+
+	decoded := &slug_SchemaStruct1{}
+	if err := dynssz.UnmarshalSSZ(decoded, buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, &slug_SchemaStruct1{[]uint8{1, 2, 0, 0}, []uint16{3, 0, 0}, 4}) {
+		t.Errorf("unexpected decoding result: %v", decoded)
+	}
+
+	warnings, err := dynssz.GetTypeWarnings(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(warnings, []string{"slug_SchemaStruct1: SSZSchema references unknown field F4"}) {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+}
@@ -22,11 +22,15 @@ import (
 //   at compile time. This determination is based on the presence of 'dynssz-size' annotations or the inherent variability of the type.
 // - specval: A boolean indicating whether a non-default specification value has been applied to the type or field, typically through
 //   'dynssz-size' annotations, suggesting a deviation from standard size expectations that might influence the encoding or decoding process.
+// - raw: A boolean indicating that the field is annotated with 'ssz-type:"raw"'. The bytes of the field are passed through verbatim
+//   without being interpreted, the only difference to a plain byte field is that raw values of static size are never zero padded.
+//   Only set on the first size hint of a field.
 
 type sszSizeHint struct {
 	size    uint64
 	dynamic bool
 	specval bool
+	raw     bool
 }
 
 // getSszSizeTag parses the 'ssz-size' and 'dynssz-size' tag annotations from a struct field and returns size hints
@@ -84,14 +88,19 @@ func (d *DynSsz) getSszSizeTag(field *reflect.StructField) ([]sszSizeHint, error
 			} else {
 				ok, specVal, err := d.getSpecValue(sszSizeStr)
 				if err != nil {
-					return sszSizes, fmt.Errorf("error parsing dynssz-size tag for '%v' field (%v): %v", field.Name, sszSizeStr, err)
+					return sszSizes, fmt.Errorf("error parsing dynssz-size tag for '%v' field: %w", field.Name, err)
 				}
 				if ok {
 					// dynamic value from spec
 					sszSize.size = specVal
 					sszSize.specval = true
+				} else if d.RequireSpecValues {
+					return sszSizes, fmt.Errorf("unresolved spec value in dynssz-size tag for '%v' field: %w", field.Name, d.newExpressionError(sszSizeStr, ErrUnresolvedExpression))
+				} else if i < len(sszSizes) {
+					// unknown spec value? fallback to the fastssz default of this dimension
+					continue
 				} else {
-					// unknown spec value? fallback to fastssz defaults
+					// unknown spec value without default for this dimension? fallback to fastssz defaults
 					break
 				}
 			}
@@ -105,5 +114,27 @@ func (d *DynSsz) getSszSizeTag(field *reflect.StructField) ([]sszSizeHint, error
 		}
 	}
 
+	if fieldSszType, fieldHasSszType := field.Tag.Lookup("ssz-type"); fieldHasSszType {
+		switch fieldSszType {
+		case "raw":
+			// raw passthrough of an already encoded ssz value
+			if (field.Type.Kind() != reflect.Slice && field.Type.Kind() != reflect.Array) || !isByteType(field.Type.Elem()) {
+				return sszSizes, fmt.Errorf("ssz-type raw is only supported for byte slices or arrays, '%v' field has type %v", field.Name, field.Type)
+			}
+			if len(sszSizes) == 0 {
+				sszSizes = append(sszSizes, sszSizeHint{dynamic: true})
+			}
+			sszSizes[0].raw = true
+		default:
+			return sszSizes, fmt.Errorf("unknown ssz-type '%v' for '%v' field", fieldSszType, field.Name)
+		}
+	}
+
+	for _, sszSize := range sszSizes {
+		if sszSize.size > uint64(maxInt) {
+			return sszSizes, fmt.Errorf("size %v of '%v' field can not be handled on this platform: %w", sszSize.size, field.Name, ErrSizeOverflow)
+		}
+	}
+
 	return sszSizes, nil
 }
@@ -0,0 +1,39 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+
+//go:build 386 || arm || mips || mipsle
+
+package dynssz_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_SizeMath32Bytes struct {
+	F1 []uint8 `ssz-size:"4294967295"`
+}
+
+type slug_SizeMath32Vector struct {
+	F1 []uint64 `ssz-size:"536870912"`
+}
+
+func TestSizeOverflow32(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+
+	// sizes that fit into uint32, but exceed the int range of 32-bit platforms
+	testTypes := []any{
+		slug_SizeMath32Bytes{},
+		slug_SizeMath32Vector{},
+	}
+	for _, testType := range testTypes {
+		_, _, err := dynssz.StaticSizeOf(reflect.TypeOf(testType))
+		if !errors.Is(err, ErrSizeOverflow) {
+			t.Errorf("expected size overflow error for %T, got: %v", testType, err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_SizeMathVector struct {
+	F1 [][]uint64 `ssz-size:"4294967295,4294967295"`
+}
+
+type slug_SizeMathSpecVector struct {
+	F1 [][]uint64 `ssz-size:"4,4" dynssz-size:"HUGE_SIZE,HUGE_SIZE"`
+}
+
+type slug_SizeMathList struct {
+	F1 [][]uint8 `ssz-max:"4,4"`
+}
+
+func TestSizeOverflow(t *testing.T) {
+	dynssz := NewDynSsz(map[string]any{"HUGE_SIZE": uint64(1) << 53})
+
+	testTypes := []any{
+		slug_SizeMathVector{},
+		slug_SizeMathSpecVector{},
+	}
+	for _, testType := range testTypes {
+		_, _, err := dynssz.StaticSizeOf(reflect.TypeOf(testType))
+		if !errors.Is(err, ErrSizeOverflow) {
+			t.Errorf("expected size overflow error for %T, got: %v", testType, err)
+		}
+	}
+}
+
+func TestUnmarshalHugeOffset(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+
+	testCases := []string{
+		// first list item offset far beyond the data
+		"0x04000000f0ffffff0000",
+		// dynamic field offset beyond the int range of 32-bit platforms
+		"0xffffffff",
+	}
+	for _, tc := range testCases {
+		target := slug_SizeMathList{}
+		if err := dynssz.UnmarshalSSZ(&target, fromHex(tc)); err == nil {
+			t.Errorf("expected error for %v, got none", tc)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"strconv"
+)
+
+// SpecBundle holds the spec values of a DynSsz instance together with the library version that wrote them.
+// Storing a bundle alongside SSZ datasets preserves the exact configuration needed to decode them later.
+//
+// Fields:
+//   - Version: The version of the dynssz module that created the bundle, or "(devel)" if unknown.
+//   - Specs: The spec values. Integral numbers are loaded as uint64 (or int64 if negative), other numbers as float64.
+//   - Hash: The hex encoded sha256 hash over the version and the spec values, used to detect modifications.
+type SpecBundle struct {
+	Version string
+	Specs   map[string]any
+	Hash    string
+}
+
+type specBundleJson struct {
+	Version string          `json:"version"`
+	Specs   json.RawMessage `json:"specs"`
+	Hash    string          `json:"hash"`
+}
+
+// SaveSpecBundle writes the spec values of the DynSsz instance as JSON bundle to the file at 'path'.
+// The bundle contains the library version and an integrity hash, which is verified by LoadSpecBundle.
+// Returns an error if the spec values can not be serialized or the file can not be written.
+func (d *DynSsz) SaveSpecBundle(path string) error {
+	specsJson, err := json.Marshal(d.specValues)
+	if err != nil {
+		return fmt.Errorf("failed serializing spec values: %v", err)
+	}
+
+	version := getModuleVersion()
+	bundleJson, err := json.MarshalIndent(&specBundleJson{
+		Version: version,
+		Specs:   specsJson,
+		Hash:    getSpecBundleHash(version, specsJson),
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed serializing spec bundle: %v", err)
+	}
+
+	return os.WriteFile(path, bundleJson, 0o644)
+}
+
+// LoadSpecBundle reads a spec bundle written by SaveSpecBundle from the file at 'path' and verifies its integrity hash.
+// The loaded spec values can be passed to NewDynSsz to decode data with the stored configuration.
+// Returns an error if the file can not be read or parsed, or if the hash does not match the bundle content.
+func LoadSpecBundle(path string) (*SpecBundle, error) {
+	bundleData, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	bundleJson := &specBundleJson{}
+	if err := json.Unmarshal(bundleData, bundleJson); err != nil {
+		return nil, fmt.Errorf("failed parsing spec bundle: %v", err)
+	}
+
+	specsJson := &bytes.Buffer{}
+	if err := json.Compact(specsJson, bundleJson.Specs); err != nil {
+		return nil, fmt.Errorf("failed parsing spec values: %v", err)
+	}
+	if getSpecBundleHash(bundleJson.Version, specsJson.Bytes()) != bundleJson.Hash {
+		return nil, ErrChecksumMismatch
+	}
+
+	decoder := json.NewDecoder(specsJson)
+	decoder.UseNumber()
+	specs := map[string]any{}
+	if err := decoder.Decode(&specs); err != nil {
+		return nil, fmt.Errorf("failed parsing spec values: %v", err)
+	}
+	for name, value := range specs {
+		if number, ok := value.(json.Number); ok {
+			specs[name] = parseSpecNumber(number)
+		}
+	}
+
+	return &SpecBundle{
+		Version: bundleJson.Version,
+		Specs:   specs,
+		Hash:    bundleJson.Hash,
+	}, nil
+}
+
+// getSpecBundleHash calculates the integrity hash of a spec bundle from the version and the compact spec values json.
+func getSpecBundleHash(version string, specsJson []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(version))
+	hash.Write([]byte{'\n'})
+	hash.Write(specsJson)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// parseSpecNumber converts a json number to uint64, int64 or float64, whichever represents it exactly.
+func parseSpecNumber(number json.Number) any {
+	if value, err := strconv.ParseUint(number.String(), 10, 64); err == nil {
+		return value
+	}
+	if value, err := number.Int64(); err == nil {
+		return value
+	}
+	value, _ := number.Float64()
+	return value
+}
+
+// getModuleVersion returns the version of the dynssz module from the build info of the running binary.
+func getModuleVersion() string {
+	buildInfo, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "(devel)"
+	}
+
+	for _, module := range buildInfo.Deps {
+		if module.Path == "github.com/pk910/dynamic-ssz" {
+			return module.Version
+		}
+	}
+	if buildInfo.Main.Path == "github.com/pk910/dynamic-ssz" && buildInfo.Main.Version != "" {
+		return buildInfo.Main.Version
+	}
+
+	return "(devel)"
+}
@@ -0,0 +1,48 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+func TestSpecBundle(t *testing.T) {
+	specs := map[string]any{"SPEC_A": uint64(3), "SPEC_B": uint64(18446744073709551615), "SPEC_C": 0.5}
+	dynssz := NewDynSsz(specs)
+
+	path := filepath.Join(t.TempDir(), "specs.json")
+	if err := dynssz.SaveSpecBundle(path); err != nil {
+		t.Fatalf("unexpected save error: %v", err)
+	}
+
+	bundle, err := LoadSpecBundle(path)
+	if err != nil {
+		t.Fatalf("unexpected load error: %v", err)
+	}
+	if !reflect.DeepEqual(bundle.Specs, specs) {
+		t.Errorf("unexpected spec values: %v, wanted %v", bundle.Specs, specs)
+	}
+	if bundle.Version == "" || bundle.Hash == "" {
+		t.Errorf("expected version and hash to be set")
+	}
+
+	// modified bundles are rejected
+	bundleData, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	bundleData = bytes.Replace(bundleData, []byte(`"SPEC_A": 3`), []byte(`"SPEC_A": 4`), 1)
+	if err := os.WriteFile(path, bundleData, 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := LoadSpecBundle(path); err != ErrChecksumMismatch {
+		t.Errorf("expected ErrChecksumMismatch, got %v", err)
+	}
+}
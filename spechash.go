@@ -0,0 +1,117 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SpecHashOf returns a hash over the effective size annotations of the given type and all types nested in it, with the
+// spec values of this DynSsz instance applied. Instances that encode the type identically have the same hash, while
+// unrelated spec values do not affect it. Storing the hash alongside persisted SSZ data allows DecodeWithSpecCheck to
+// detect data that has been encoded with different spec values (e.g. minimal preset data decoded with a mainnet
+// instance).
+// The 'targetType' parameter accepts either an instance or a reflect.Type value of the type.
+func (d *DynSsz) SpecHashOf(targetType any) ([32]byte, error) {
+	sszType, ok := targetType.(reflect.Type)
+	if !ok {
+		sszType = reflect.TypeOf(targetType)
+	}
+
+	specHash := sha256.New()
+	if err := d.writeSpecHash(specHash, sszType, map[reflect.Type]bool{}); err != nil {
+		return [32]byte{}, err
+	}
+
+	var result [32]byte
+	copy(result[:], specHash.Sum(nil))
+	return result, nil
+}
+
+// DecodeWithSpecCheck decodes the given SSZ data into the target like UnmarshalSSZ, after checking that the spec hash
+// of the target type (see SpecHashOf) matches the spec hash the data has been encoded with.
+// Returns an error wrapping ErrSpecMismatch, listing the spec values this instance resolves for the type, if the hashes
+// differ. The data is not decoded in that case, as it would be silently mis-decoded otherwise.
+func (d *DynSsz) DecodeWithSpecCheck(target any, data []byte, expectedSpecHash [32]byte) error {
+	targetType := reflect.TypeOf(target)
+
+	specHash, err := d.SpecHashOf(targetType)
+	if err != nil {
+		return err
+	}
+
+	if specHash != expectedSpecHash {
+		expressions, err := d.ResolvedExpressions(targetType)
+		if err != nil {
+			return err
+		}
+
+		resolved := make([]string, 0, len(expressions))
+		for expression, value := range expressions {
+			resolved = append(resolved, fmt.Sprintf("%v=%v", expression, value))
+		}
+		sort.Strings(resolved)
+		if len(resolved) == 0 {
+			resolved = append(resolved, "none")
+		}
+
+		return fmt.Errorf("%w: data for type %v has been encoded with spec hash 0x%x, but this instance resolves to spec hash 0x%x (resolved spec values: %v)",
+			ErrSpecMismatch, targetType, expectedSpecHash, specHash, strings.Join(resolved, ", "))
+	}
+
+	return d.UnmarshalSSZ(target, data)
+}
+
+// writeSpecHash writes the effective size and limit annotations of the fields of the given type and the types nested
+// in it to the hash.
+func (d *DynSsz) writeSpecHash(specHash hash.Hash, targetType reflect.Type, visited map[reflect.Type]bool) error {
+	for targetType.Kind() == reflect.Ptr || targetType.Kind() == reflect.Array || targetType.Kind() == reflect.Slice {
+		targetType = targetType.Elem()
+	}
+	if targetType.Kind() != reflect.Struct || visited[targetType] {
+		return nil
+	}
+	visited[targetType] = true
+
+	for i := 0; i < targetType.NumField(); i++ {
+		field := d.getStructField(targetType, i)
+
+		sizeHints, err := d.getSszSizeTag(&field)
+		if err != nil {
+			return err
+		}
+		maxHints, err := d.getSszMaxTag(&field)
+		if err != nil {
+			return err
+		}
+
+		sizes := make([]string, len(sizeHints))
+		for j, sizeHint := range sizeHints {
+			sizes[j] = "?"
+			if !sizeHint.dynamic {
+				sizes[j] = fmt.Sprintf("%v", sizeHint.size)
+			}
+		}
+		maxes := make([]string, len(maxHints))
+		for j, maxHint := range maxHints {
+			maxes[j] = "?"
+			if maxHint.known {
+				maxes[j] = fmt.Sprintf("%v", maxHint.max)
+			}
+		}
+
+		fmt.Fprintf(specHash, "%v.%v:%v|%v\n", getTypePathName(targetType), field.Name, strings.Join(sizes, ","), strings.Join(maxes, ","))
+
+		if err := d.writeSpecHash(specHash, field.Type, visited); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_SpecHashStruct1 struct {
+	F1 []uint8                 `ssz-size:"4" dynssz-size:"SPEC_A"`
+	F2 []*slug_SpecHashStruct2 `ssz-max:"8" dynssz-max:"SPEC_B"`
+}
+
+type slug_SpecHashStruct2 struct {
+	F1 []uint16 `ssz-size:"2" dynssz-size:"SPEC_A/2"`
+}
+
+func TestSpecHashOf(t *testing.T) {
+	getSpecHash := func(specs map[string]any) [32]byte {
+		specHash, err := NewDynSsz(specs).SpecHashOf(&slug_SpecHashStruct1{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return specHash
+	}
+
+	defaultHash := getSpecHash(nil)
+	if specHash := getSpecHash(map[string]any{"SPEC_A": uint64(4), "SPEC_B": uint64(8), "SPEC_C": uint64(1)}); specHash != defaultHash {
+		t.Errorf("spec hash differs for default spec values")
+	}
+	if specHash := getSpecHash(map[string]any{"SPEC_A": uint64(8)}); specHash == defaultHash {
+		t.Errorf("spec hash does not differ for changed size")
+	}
+	if specHash := getSpecHash(map[string]any{"SPEC_B": uint64(16)}); specHash == defaultHash {
+		t.Errorf("spec hash does not differ for changed limit")
+	}
+}
+
+func TestDecodeWithSpecCheck(t *testing.T) {
+	minimal := NewDynSsz(map[string]any{"SPEC_A": uint64(2)})
+	mainnet := NewDynSsz(nil)
+
+	source := &slug_SpecHashStruct1{F1: []uint8{1, 2}, F2: []*slug_SpecHashStruct2{{F1: []uint16{3}}}}
+	data, err := minimal.MarshalSSZ(source)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	specHash, err := minimal.SpecHashOf(source)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	target := &slug_SpecHashStruct1{}
+	if err := minimal.DecodeWithSpecCheck(target, data, specHash); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(target.F1) != 2 || len(target.F2) != 1 || target.F2[0].F1[0] != 3 {
+		t.Errorf("unexpected decoded object: %+v", target)
+	}
+
+	err = mainnet.DecodeWithSpecCheck(&slug_SpecHashStruct1{}, data, specHash)
+	if !errors.Is(err, ErrSpecMismatch) {
+		t.Fatalf("expected ErrSpecMismatch, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "slug_SpecHashStruct1") {
+		t.Errorf("unexpected error message: %v", err)
+	}
+}
@@ -4,7 +4,12 @@
 package dynssz
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
 
 	"gopkg.in/Knetic/govaluate.v3"
 )
@@ -15,19 +20,29 @@ type cachedSpecValue struct {
 }
 
 func (d *DynSsz) getSpecValue(name string) (bool, uint64, error) {
-	if cachedValue := d.specValueCache[name]; cachedValue != nil {
+	d.specValueMutex.RLock()
+	cachedValue := d.specValueCache[name]
+	d.specValueMutex.RUnlock()
+	if cachedValue != nil {
 		return cachedValue.resolved, cachedValue.value, nil
 	}
 
-	cachedValue := &cachedSpecValue{}
-	expression, err := govaluate.NewEvaluableExpression(name)
+	cachedValue = &cachedSpecValue{}
+	parameters := &specParameters{specs: d.specValues}
+	expression, err := newSpecExpression(name, parameters)
 	if err != nil {
-		return false, 0, fmt.Errorf("error parsing dynamic spec expression: %v", err)
+		return false, 0, d.newExpressionError(name, fmt.Errorf("error parsing dynamic spec expression: %v", err))
 	}
 
-	result, err := expression.Evaluate(d.specValues)
+	result, err := expression.Eval(parameters)
+	if parameters.err != nil {
+		return false, 0, d.newExpressionError(name, parameters.err)
+	}
 	if err == nil {
 		value, ok := result.(float64)
+		if ok && (value < 0 || math.IsNaN(value) || math.IsInf(value, 0)) {
+			return false, 0, d.newExpressionError(name, fmt.Errorf("dynamic spec expression resolves to invalid size %v", value))
+		}
 		if ok {
 			cachedValue.resolved = true
 			cachedValue.value = uint64(value)
@@ -40,6 +55,366 @@ func (d *DynSsz) getSpecValue(name string) (bool, uint64, error) {
 
 	// fmt.Printf("spec lookup %v,  ok: %v, value: %v\n", name, cachedValue.resolved, cachedValue.value)
 
+	d.specValueMutex.Lock()
 	d.specValueCache[name] = cachedValue
+	d.specValueMutex.Unlock()
 	return cachedValue.resolved, cachedValue.value, nil
 }
+
+// ResolveExpression evaluates a dynamic spec expression, as used in 'dynssz-size' and 'dynssz-max' tags, with the spec
+// values of this DynSsz instance. This helps debugging size annotations that do not resolve to the expected values.
+// Returns an ExpressionError holding the referenced spec values if the expression can not be evaluated, which wraps
+// ErrUnresolvedExpression if referenced spec values are missing.
+func (d *DynSsz) ResolveExpression(expression string) (uint64, error) {
+	ok, value, err := d.getSpecValue(expression)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, d.newExpressionError(expression, ErrUnresolvedExpression)
+	}
+	return value, nil
+}
+
+// newExpressionError wraps an evaluation error of a spec expression into an ExpressionError with the current values of
+// the spec values referenced by the expression.
+func (d *DynSsz) newExpressionError(expression string, err error) *ExpressionError {
+	specs := map[string]string{}
+	for name := range getSpecExpressionRefs(expression) {
+		if value, found := d.specValues[name]; found {
+			specs[name] = fmt.Sprintf("%v", value)
+		} else {
+			specs[name] = "missing"
+		}
+	}
+
+	return &ExpressionError{
+		Expression: expression,
+		Specs:      specs,
+		Err:        err,
+	}
+}
+
+// newSpecExpression parses a dynamic spec expression. Selectors on compound spec values (lists and maps), like
+// `BLOB_SCHEDULE[FORK].MAX_BLOBS`, are rewritten to calls of the select function, which resolves them with the
+// given parameters.
+func newSpecExpression(name string, parameters *specParameters) (*govaluate.EvaluableExpression, error) {
+	rewritten, err := rewriteSpecSelectors(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return govaluate.NewEvaluableExpressionWithFunctions(rewritten, map[string]govaluate.ExpressionFunction{
+		specSelectFunction: parameters.selectValue,
+	})
+}
+
+// specSelectFunction is the name of the expression function that selectors on compound spec values are rewritten to.
+const specSelectFunction = "spec_select"
+
+// rewriteSpecSelectors rewrites selectors on compound spec values into select function calls, e.g.
+// `BLOB_SCHEDULE[FORK].MAX_BLOBS` becomes `spec_select(BLOB_SCHEDULE, (FORK), 'MAX_BLOBS')`.
+func rewriteSpecSelectors(expression string) (string, error) {
+	var out strings.Builder
+
+	for i := 0; i < len(expression); {
+		char := expression[i]
+		switch {
+		case char == '\'' || char == '"':
+			// copy string literals verbatim
+			end := strings.IndexByte(expression[i+1:], char)
+			if end == -1 {
+				return "", fmt.Errorf("unclosed string literal in expression %v", expression)
+			}
+			out.WriteString(expression[i : i+end+2])
+			i += end + 2
+		case isSpecIdentStart(char):
+			j := i
+			for j < len(expression) && isSpecIdentChar(expression[j]) {
+				j++
+			}
+			if j >= len(expression) || (expression[j] != '[' && expression[j] != '.') {
+				out.WriteString(expression[i:j])
+				i = j
+				continue
+			}
+
+			out.WriteString(specSelectFunction + "(" + expression[i:j])
+			for j < len(expression) {
+				if expression[j] == '[' {
+					end := findClosingBracket(expression, j)
+					if end == -1 {
+						return "", fmt.Errorf("unclosed selector in expression %v", expression)
+					}
+					index, err := rewriteSpecSelectors(expression[j+1 : end])
+					if err != nil {
+						return "", err
+					}
+					out.WriteString(", (" + index + ")")
+					j = end + 1
+				} else if expression[j] == '.' && j+1 < len(expression) && isSpecIdentStart(expression[j+1]) {
+					k := j + 1
+					for k < len(expression) && isSpecIdentChar(expression[k]) {
+						k++
+					}
+					out.WriteString(", '" + expression[j+1:k] + "'")
+					j = k
+				} else {
+					break
+				}
+			}
+			out.WriteString(")")
+			i = j
+		default:
+			out.WriteByte(char)
+			i++
+		}
+	}
+
+	return out.String(), nil
+}
+
+func isSpecIdentStart(char byte) bool {
+	return char == '_' || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z')
+}
+
+func isSpecIdentChar(char byte) bool {
+	return isSpecIdentStart(char) || (char >= '0' && char <= '9')
+}
+
+// findClosingBracket returns the position of the ']' matching the '[' at position 'start', or -1 if there is none.
+func findClosingBracket(expression string, start int) int {
+	depth := 0
+	for i := start; i < len(expression); i++ {
+		switch expression[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// specParameters provides the spec values to the expression evaluator. Spec values are often loaded from JSON or
+// YAML configs, so besides the numeric types, numeric strings (decimal or 0x-prefixed hex) and json.Number values are
+// accepted as well. Compound values (lists and maps) are passed through for the select function.
+// Values that can not be represented as a valid size are reported via 'err'.
+type specParameters struct {
+	specs map[string]any
+	err   error
+}
+
+// specCompoundValue wraps a compound spec value for the select function.
+type specCompoundValue struct {
+	value any
+}
+
+func (p *specParameters) Get(name string) (any, error) {
+	value, found := p.specs[name]
+	if !found {
+		return nil, fmt.Errorf("no spec value '%v' found", name)
+	}
+
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		// wrapped, as the evaluator would otherwise expand lists to function arguments
+		return &specCompoundValue{value: value}, nil
+	}
+
+	number, err := coerceSpecValue(value)
+	if err != nil {
+		p.err = fmt.Errorf("invalid spec value '%v': %v", name, err)
+		return nil, p.err
+	}
+
+	return number, nil
+}
+
+// selectValue implements the select function, which walks a compound spec value along the given list indexes and
+// map keys and returns the selected value.
+func (p *specParameters) selectValue(args ...any) (any, error) {
+	if len(args) < 2 {
+		p.err = fmt.Errorf("invalid spec value selector")
+		return nil, p.err
+	}
+	compoundValue, ok := args[0].(*specCompoundValue)
+	if !ok {
+		p.err = fmt.Errorf("invalid spec value selector: %v is not a list or map", args[0])
+		return nil, p.err
+	}
+
+	value := compoundValue.value
+	for _, key := range args[1:] {
+		selected, err := selectSpecValue(value, key)
+		if err != nil {
+			p.err = fmt.Errorf("invalid spec value selector: %v", err)
+			return nil, p.err
+		}
+		value = selected
+	}
+
+	number, err := coerceSpecValue(value)
+	if err != nil {
+		p.err = fmt.Errorf("invalid selected spec value: %v", err)
+		return nil, p.err
+	}
+
+	return number, nil
+}
+
+// selectSpecValue selects the item with the given index from a list, or the entry with the given key from a map.
+func selectSpecValue(value any, key any) (any, error) {
+	compoundValue := reflect.ValueOf(value)
+
+	switch compoundValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		index, ok := key.(float64)
+		if !ok || index < 0 || index != float64(int(index)) {
+			return nil, fmt.Errorf("invalid list index %v", key)
+		}
+		if int(index) >= compoundValue.Len() {
+			return nil, fmt.Errorf("list index %v out of range (length %v)", index, compoundValue.Len())
+		}
+		return compoundValue.Index(int(index)).Interface(), nil
+	case reflect.Map:
+		keyName, ok := key.(string)
+		if !ok || compoundValue.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("invalid map key %v", key)
+		}
+		entry := compoundValue.MapIndex(reflect.ValueOf(keyName).Convert(compoundValue.Type().Key()))
+		if !entry.IsValid() {
+			return nil, fmt.Errorf("map key %v not found", keyName)
+		}
+		return entry.Interface(), nil
+	default:
+		return nil, fmt.Errorf("can not select %v from %T", key, value)
+	}
+}
+
+// maxExactSpecValue is the largest spec value that can be represented exactly by the float64 based expression evaluator.
+const maxExactSpecValue = 1 << 53
+
+// coerceSpecValue converts a spec value to float64 for the expression evaluator, with range checks.
+func coerceSpecValue(value any) (float64, error) {
+	var number float64
+	switch v := value.(type) {
+	case uint8:
+		number = float64(v)
+	case uint16:
+		number = float64(v)
+	case uint32:
+		number = float64(v)
+	case uint64:
+		if v > maxExactSpecValue {
+			return 0, fmt.Errorf("value %v exceeds maximum of %v", v, uint64(maxExactSpecValue))
+		}
+		number = float64(v)
+	case uint:
+		if uint64(v) > maxExactSpecValue {
+			return 0, fmt.Errorf("value %v exceeds maximum of %v", v, uint64(maxExactSpecValue))
+		}
+		number = float64(v)
+	case int8:
+		number = float64(v)
+	case int16:
+		number = float64(v)
+	case int32:
+		number = float64(v)
+	case int64:
+		number = float64(v)
+	case int:
+		number = float64(v)
+	case float32:
+		number = float64(v)
+	case float64:
+		number = v
+	case json.Number:
+		return coerceSpecValue(v.String())
+	case string:
+		str := strings.TrimSpace(v)
+		if uintValue, err := strconv.ParseUint(str, 0, 64); err == nil {
+			return coerceSpecValue(uintValue)
+		}
+		floatValue, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return 0, fmt.Errorf("string %q is not a number", v)
+		}
+		number = floatValue
+	default:
+		return 0, fmt.Errorf("unsupported type %T", value)
+	}
+
+	if number < 0 || math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, fmt.Errorf("value %v is not a valid size", number)
+	}
+	if number > maxExactSpecValue {
+		return 0, fmt.Errorf("value %v exceeds maximum of %v", number, uint64(maxExactSpecValue))
+	}
+
+	return number, nil
+}
+
+// ResolvedExpressions returns the 'dynssz-size' expressions used by the given type and all types nested in it,
+// mapped to the values they resolve to with the spec values of this DynSsz instance. This allows auditing the
+// effective configuration of an instance, e.g. that SYNC_COMMITTEE_SIZE resolves to 512 for a given preset.
+// Expressions that can not be resolved (and fall back to the 'ssz-size' defaults) are not included.
+// The 'targetType' parameter accepts either an instance or a reflect.Type value of the type.
+// Returns an error if an expression can not be parsed.
+func (d *DynSsz) ResolvedExpressions(targetType any) (map[string]uint64, error) {
+	sszType, ok := targetType.(reflect.Type)
+	if !ok {
+		sszType = reflect.TypeOf(targetType)
+	}
+
+	expressions := map[string]uint64{}
+	if err := d.collectExpressions(sszType, map[reflect.Type]bool{}, expressions); err != nil {
+		return nil, err
+	}
+
+	return expressions, nil
+}
+
+// collectExpressions resolves the 'dynssz-size' expressions of the fields of the given type and recurses into nested struct types.
+// Expressions are resolved via getSpecValue, which guards the shared spec value cache.
+func (d *DynSsz) collectExpressions(targetType reflect.Type, visited map[reflect.Type]bool, expressions map[string]uint64) error {
+	for targetType.Kind() == reflect.Ptr || targetType.Kind() == reflect.Array || targetType.Kind() == reflect.Slice {
+		targetType = targetType.Elem()
+	}
+	if targetType.Kind() != reflect.Struct || visited[targetType] {
+		return nil
+	}
+	visited[targetType] = true
+
+	for i := 0; i < targetType.NumField(); i++ {
+		field := d.getStructField(targetType, i)
+
+		if dynSszSizeStr, hasDynSszSize := field.Tag.Lookup("dynssz-size"); hasDynSszSize {
+			for _, expression := range strings.Split(dynSszSizeStr, ",") {
+				if expression == "?" {
+					continue
+				}
+				if _, err := strconv.ParseUint(expression, 10, 32); err == nil {
+					continue
+				}
+
+				ok, value, err := d.getSpecValue(expression)
+				if err != nil {
+					return fmt.Errorf("error parsing dynssz-size tag for '%v' field: %w", field.Name, err)
+				}
+				if ok {
+					expressions[expression] = value
+				}
+			}
+		}
+
+		if err := d.collectExpressions(field.Type, visited, expressions); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
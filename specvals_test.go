@@ -0,0 +1,223 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_SpecValsStruct1 struct {
+	F1 []uint8                 `ssz-size:"4" dynssz-size:"SPEC_A"`
+	F2 [][]uint8               `ssz-size:"2,8" dynssz-size:"SPEC_A*2,UNKNOWN_SPEC"`
+	F3 []*slug_SpecValsStruct2 `ssz-size:"?" dynssz-size:"?"`
+}
+
+type slug_SpecValsStruct2 struct {
+	F1 []uint16 `ssz-size:"32" dynssz-size:"SPEC_B/2"`
+	F2 []uint8  `ssz-size:"4" dynssz-size:"4"`
+}
+
+func TestResolvedExpressions(t *testing.T) {
+	dynssz := NewDynSsz(map[string]any{"SPEC_A": uint64(3), "SPEC_B": uint64(512)})
+
+	expressions, err := dynssz.ResolvedExpressions(&slug_SpecValsStruct1{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]uint64{
+		"SPEC_A":   3,
+		"SPEC_A*2": 6,
+		"SPEC_B/2": 256,
+	}
+	if !reflect.DeepEqual(expressions, expected) {
+		t.Errorf("unexpected expressions: %v, wanted %v", expressions, expected)
+	}
+}
+
+func TestSpecValueCoercion(t *testing.T) {
+	testMatrix := []struct {
+		value    any
+		expected uint64
+		isError  bool
+	}{
+		{uint64(3), 6, false},
+		{int(3), 6, false},
+		{float64(3), 6, false},
+		{"3", 6, false},
+		{" 0x10 ", 32, false},
+		{json.Number("3"), 6, false},
+		{"2.5", 5, false},
+		{"abc", 0, true},
+		{int64(-1), 0, true},
+		{uint64(1) << 60, 0, true},
+		{true, 0, true},
+	}
+
+	for idx, test := range testMatrix {
+		dynssz := NewDynSsz(map[string]any{"SPEC_A": test.value, "SPEC_B": uint64(512)})
+
+		expressions, err := dynssz.ResolvedExpressions(&slug_SpecValsStruct1{})
+		switch {
+		case test.isError && err == nil:
+			t.Errorf("test %v: expected error for spec value %v", idx, test.value)
+		case !test.isError && err != nil:
+			t.Errorf("test %v: unexpected error: %v", idx, err)
+		case !test.isError && expressions["SPEC_A*2"] != test.expected:
+			t.Errorf("test %v: unexpected value: %v, wanted %v", idx, expressions["SPEC_A*2"], test.expected)
+		}
+	}
+}
+
+type slug_SpecSelectorStruct struct {
+	F1 []uint8 `ssz-size:"4" dynssz-size:"BLOB_SCHEDULE[FORK].MAX_BLOBS"`
+	F2 []uint8 `ssz-size:"4" dynssz-size:"BLOB_SCHEDULE[FORK*0].MAX_BLOBS*2"`
+	F3 []uint8 `ssz-size:"4" dynssz-size:"LIMITS.items[1]"`
+}
+
+func TestSpecValueSelectors(t *testing.T) {
+	specs := map[string]any{
+		"FORK": uint64(1),
+		"BLOB_SCHEDULE": []any{
+			map[string]any{"EPOCH": uint64(0), "MAX_BLOBS": uint64(6)},
+			map[string]any{"EPOCH": uint64(100), "MAX_BLOBS": "9"},
+		},
+		"LIMITS": map[string][]uint64{"items": {1, 2}},
+	}
+	dynssz := NewDynSsz(specs)
+
+	expressions, err := dynssz.ResolvedExpressions(&slug_SpecSelectorStruct{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]uint64{
+		"BLOB_SCHEDULE[FORK].MAX_BLOBS":     9,
+		"BLOB_SCHEDULE[FORK*0].MAX_BLOBS*2": 12,
+		"LIMITS.items[1]":                   2,
+	}
+	if !reflect.DeepEqual(expressions, expected) {
+		t.Errorf("unexpected expressions: %v, wanted %v", expressions, expected)
+	}
+
+	// child instances must not reuse values that depend on overridden compound values
+	child := dynssz.Child(map[string]any{"FORK": uint64(0)})
+	expressions, err = child.ResolvedExpressions(&slug_SpecSelectorStruct{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expressions["BLOB_SCHEDULE[FORK].MAX_BLOBS"] != 6 {
+		t.Errorf("unexpected child value: %v, wanted 6", expressions["BLOB_SCHEDULE[FORK].MAX_BLOBS"])
+	}
+
+	specs["FORK"] = uint64(2)
+	if _, err := NewDynSsz(specs).ResolvedExpressions(&slug_SpecSelectorStruct{}); err == nil {
+		t.Errorf("expected error for list index out of range")
+	}
+}
+
+func TestResolveExpression(t *testing.T) {
+	dynssz := NewDynSsz(map[string]any{"SPEC_A": uint64(3), "SPEC_B": "abc"})
+
+	value, err := dynssz.ResolveExpression("SPEC_A*2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value != 6 {
+		t.Errorf("unexpected value: %v, wanted 6", value)
+	}
+
+	testMatrix := []struct {
+		expression string
+		specs      map[string]string
+		unresolved bool
+	}{
+		{"SPEC_A*UNKNOWN_SPEC", map[string]string{"SPEC_A": "3", "UNKNOWN_SPEC": "missing"}, true},
+		{"SPEC_A+SPEC_B", map[string]string{"SPEC_A": "3", "SPEC_B": "abc"}, false},
+		{"SPEC_A-4", map[string]string{"SPEC_A": "3"}, false},
+	}
+
+	for _, test := range testMatrix {
+		_, err := dynssz.ResolveExpression(test.expression)
+
+		var exprErr *ExpressionError
+		if !errors.As(err, &exprErr) {
+			t.Errorf("expected expression error for %v, got: %v", test.expression, err)
+			continue
+		}
+		if exprErr.Expression != test.expression || !reflect.DeepEqual(exprErr.Specs, test.specs) {
+			t.Errorf("unexpected expression error for %v: %v", test.expression, err)
+		}
+		if errors.Is(err, ErrUnresolvedExpression) != test.unresolved {
+			t.Errorf("unexpected unresolved state for %v: %v", test.expression, err)
+		}
+	}
+
+	// tag errors carry the expression error
+	dynssz.RequireSpecValues = true
+	_, _, err = dynssz.StaticSizeOf(reflect.TypeOf(slug_SpecValsStruct1{}))
+	var exprErr *ExpressionError
+	if !errors.As(err, &exprErr) || exprErr.Specs["UNKNOWN_SPEC"] != "missing" {
+		t.Errorf("expected expression error for unresolved tag, got: %v", err)
+	}
+}
+
+func TestResolveExpressionConcurrent(t *testing.T) {
+	dynssz := NewDynSsz(map[string]any{"SPEC_A": uint64(3), "SPEC_B": uint64(512)})
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if _, err := dynssz.ResolveExpression(fmt.Sprintf("SPEC_A*%v", i*50+j+1)); err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			if _, err := dynssz.MarshalSSZ(&slug_SpecValsStruct1{}); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestResolvedExpressionsConcurrent(t *testing.T) {
+	dynssz := NewDynSsz(map[string]any{"SPEC_A": uint64(3), "SPEC_B": uint64(512)})
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			expressions, err := dynssz.ResolvedExpressions(&slug_SpecValsStruct1{})
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if expressions["SPEC_A"] != 3 || expressions["SPEC_B/2"] != 256 {
+				t.Errorf("unexpected expressions: %v", expressions)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := dynssz.SizeSSZ(&slug_SpecValsStruct1{}); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
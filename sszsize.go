@@ -38,6 +38,12 @@ type cachedSszSize struct {
 // encoding or decoding path is chosen based on the type's nature and any dynamic specifications applied to it.
 
 func (d *DynSsz) getSszSize(targetType reflect.Type, sizeHints []sszSizeHint) (int, bool, error) {
+	return d.resolveSszSize(targetType, sizeHints, nil)
+}
+
+// resolveSszSize implements getSszSize. The 'parentTypes' parameter holds the struct types that are currently being
+// resolved up the recursion, so self-referential types are reported as error instead of recursing endlessly.
+func (d *DynSsz) resolveSszSize(targetType reflect.Type, sizeHints []sszSizeHint, parentTypes []reflect.Type) (int, bool, error) {
 	staticSize := 0
 	hasSpecValue := false
 	isDynamicSize := false
@@ -62,11 +68,27 @@ func (d *DynSsz) getSszSize(targetType reflect.Type, sizeHints []sszSizeHint) (i
 
 	switch targetType.Kind() {
 	case reflect.Struct:
+		for _, parentType := range parentTypes {
+			if parentType == targetType {
+				return 0, false, fmt.Errorf("recursive type %v is not supported, ssz types must not contain themselves", targetType)
+			}
+		}
+		parentTypes = append(parentTypes, targetType)
+
+		if targetType.NumField() == 0 {
+			return 0, false, fmt.Errorf("empty container %v is not supported, ssz containers must have at least one field", targetType)
+		}
+
 		for i := 0; i < targetType.NumField(); i++ {
-			field := targetType.Field(i)
-			size, hasSpecVal, _, err := d.getSszFieldSize(&field)
+			field := d.getStructField(targetType, i)
+			fieldStep := fmt.Sprintf("%v.%v", getTypePathName(targetType), field.Name)
+			sszSizes, err := d.getSszSizeTag(&field)
 			if err != nil {
-				return 0, false, err
+				return 0, false, wrapTypePathError(err, fieldStep)
+			}
+			size, hasSpecVal, err := d.resolveSszSize(field.Type, sszSizes, parentTypes)
+			if err != nil {
+				return 0, false, wrapTypePathError(err, fieldStep)
 			}
 			if size < 0 {
 				isDynamicSize = true
@@ -74,14 +96,25 @@ func (d *DynSsz) getSszSize(targetType reflect.Type, sizeHints []sszSizeHint) (i
 			if hasSpecVal {
 				hasSpecValue = true
 			}
-			staticSize += size
+			if _, hasOverride := d.getFieldOverride(targetType, field.Name); hasOverride {
+				// call time overrides are unknown to the fastssz code of the type
+				hasSpecValue = true
+			}
+			if size >= 0 && !isDynamicSize {
+				if staticSize, err = addSize(staticSize, size); err != nil {
+					return 0, false, wrapTypePathError(err, fieldStep)
+				}
+			}
 		}
 	case reflect.Array:
 		arrLen := targetType.Len()
+		if arrLen == 0 {
+			return 0, false, fmt.Errorf("zero-length vector %v is not supported, ssz vectors must have at least one item", targetType)
+		}
 		fieldType := targetType.Elem()
-		size, hasSpecVal, err := d.getSszSize(fieldType, childSizeHints)
+		size, hasSpecVal, err := d.resolveSszSize(fieldType, childSizeHints, parentTypes)
 		if err != nil {
-			return 0, false, err
+			return 0, false, wrapTypePathError(err, fmt.Sprintf("[%v]", arrLen))
 		}
 		if size < 0 {
 			isDynamicSize = true
@@ -89,12 +122,19 @@ func (d *DynSsz) getSszSize(targetType reflect.Type, sizeHints []sszSizeHint) (i
 		if hasSpecVal {
 			hasSpecValue = true
 		}
-		staticSize += size * arrLen
+		if size >= 0 {
+			if staticSize, err = mulSize(size, uint64(arrLen)); err != nil {
+				return 0, false, wrapTypePathError(err, fmt.Sprintf("[%v]", arrLen))
+			}
+		}
 	case reflect.Slice:
+		if len(sizeHints) > 0 && !sizeHints[0].dynamic && sizeHints[0].size == 0 {
+			return 0, false, fmt.Errorf("zero-length vector %v is not supported, ssz vectors must have at least one item", targetType)
+		}
 		fieldType := targetType.Elem()
-		size, hasSpecVal, err := d.getSszSize(fieldType, childSizeHints)
+		size, hasSpecVal, err := d.resolveSszSize(fieldType, childSizeHints, parentTypes)
 		if err != nil {
-			return 0, false, err
+			return 0, false, wrapTypePathError(err, "[]")
 		}
 		if size < 0 {
 			isDynamicSize = true
@@ -104,7 +144,11 @@ func (d *DynSsz) getSszSize(targetType reflect.Type, sizeHints []sszSizeHint) (i
 		}
 
 		if len(sizeHints) > 0 && sizeHints[0].size > 0 {
-			staticSize += size * int(sizeHints[0].size)
+			if size >= 0 {
+				if staticSize, err = mulSize(size, sizeHints[0].size); err != nil {
+					return 0, false, wrapTypePathError(err, "[]")
+				}
+			}
 		} else {
 			isDynamicSize = true
 		}
@@ -135,6 +179,7 @@ func (d *DynSsz) getSszSize(targetType reflect.Type, sizeHints []sszSizeHint) (i
 			specval: hasSpecValue,
 		}
 		d.typeSizeMutex.Unlock()
+		d.logTypeCache("size", targetType, "size", staticSize, "specvals", hasSpecValue)
 	}
 
 	return staticSize, hasSpecValue, nil
@@ -195,7 +240,12 @@ func (d *DynSsz) getSszValueSize(targetType reflect.Type, targetValue reflect.Va
 
 	if targetType.Kind() == reflect.Ptr {
 		targetType = targetType.Elem()
-		targetValue = targetValue.Elem()
+		if targetValue.IsNil() {
+			// nil pointers are encoded as zero value
+			targetValue = reflect.New(targetType).Elem()
+		} else {
+			targetValue = targetValue.Elem()
+		}
 	}
 
 	// use fastssz to calculate size if:
@@ -228,7 +278,7 @@ func (d *DynSsz) getSszValueSize(targetType reflect.Type, targetValue reflect.Va
 		switch targetType.Kind() {
 		case reflect.Struct:
 			for i := 0; i < targetType.NumField(); i++ {
-				field := targetType.Field(i)
+				field := d.getStructField(targetType, i)
 				fieldValue := targetValue.Field(i)
 
 				fieldTypeSize, _, fieldSizeHints, err := d.getSszFieldSize(&field)
@@ -243,24 +293,45 @@ func (d *DynSsz) getSszValueSize(targetType reflect.Type, targetValue reflect.Va
 					}
 
 					// dynamic field, add 4 bytes for offset
-					staticSize += size + 4
+					if staticSize, err = addSize(staticSize, size+4); err != nil {
+						return 0, err
+					}
 				} else {
 					// static field
-					staticSize += fieldTypeSize
+					if staticSize, err = addSize(staticSize, fieldTypeSize); err != nil {
+						return 0, err
+					}
 				}
 			}
 		case reflect.Array:
 			arrLen := targetType.Len()
 			if arrLen > 0 {
 				fieldType := targetType.Elem()
-				if fieldType == byteType {
+				if isByteType(fieldType) {
 					staticSize = arrLen
 				} else {
-					size, err := d.getSszValueSize(fieldType, targetValue.Index(0), childSizeHints)
+					fieldTypeSize, _, err := d.getSszSize(fieldType, childSizeHints)
 					if err != nil {
 						return 0, err
 					}
-					staticSize = size * arrLen
+
+					if fieldTypeSize < 0 {
+						// array with dynamic size items, so we have to go through each item
+						for i := 0; i < arrLen; i++ {
+							size, err := d.getSszValueSize(fieldType, targetValue.Index(i), childSizeHints)
+							if err != nil {
+								return 0, err
+							}
+							// add 4 bytes for offset in dynamic array
+							if staticSize, err = addSize(staticSize, size+4); err != nil {
+								return 0, err
+							}
+						}
+					} else {
+						if staticSize, err = mulSize(fieldTypeSize, uint64(arrLen)); err != nil {
+							return 0, err
+						}
+					}
 				}
 			}
 		case reflect.Slice:
@@ -273,13 +344,18 @@ func (d *DynSsz) getSszValueSize(targetType reflect.Type, targetValue reflect.Va
 					return 0, ErrListTooBig
 				}
 				if uint64(sliceLen) < sizeHints[0].size {
+					if d.StrictVectorLength || sizeHints[0].raw {
+						return 0, ErrVectorLength
+					}
 					appendZero = int(sizeHints[0].size - uint64(sliceLen))
 				}
 			}
 
-			if sliceLen > 0 {
-				if fieldType == byteType {
-					staticSize = sliceLen + appendZero
+			if sliceLen+appendZero > 0 {
+				if isByteType(fieldType) {
+					if staticSize, err = addSize(sliceLen, appendZero); err != nil {
+						return 0, err
+					}
 				} else {
 					fieldTypeSize, _, err := d.getSszSize(fieldType, childSizeHints)
 					if err != nil {
@@ -294,7 +370,9 @@ func (d *DynSsz) getSszValueSize(targetType reflect.Type, targetValue reflect.Va
 								return 0, err
 							}
 							// add 4 bytes for offset in dynamic slice
-							staticSize += size + 4
+							if staticSize, err = addSize(staticSize, size+4); err != nil {
+								return 0, err
+							}
 						}
 
 						if appendZero > 0 {
@@ -304,10 +382,18 @@ func (d *DynSsz) getSszValueSize(targetType reflect.Type, targetValue reflect.Va
 								return 0, err
 							}
 
-							staticSize += (size + 4) * appendZero
+							zeroSize, err := mulSize(size+4, uint64(appendZero))
+							if err != nil {
+								return 0, err
+							}
+							if staticSize, err = addSize(staticSize, zeroSize); err != nil {
+								return 0, err
+							}
 						}
 					} else {
-						staticSize = fieldTypeSize * (sliceLen + appendZero)
+						if staticSize, err = mulSize(fieldTypeSize, uint64(sliceLen)+uint64(appendZero)); err != nil {
+							return 0, err
+						}
 					}
 				}
 			}
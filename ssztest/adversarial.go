@@ -0,0 +1,182 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package ssztest
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	dynssz "github.com/pk910/dynamic-ssz"
+)
+
+// AdversarialVector is a malformed SSZ encoding that decoders must reject.
+type AdversarialVector struct {
+	// Name describes the malformation, e.g. "overlapping-offsets/Body".
+	Name string
+	// Data is the malformed encoding.
+	Data []byte
+}
+
+// AdversarialVectors derives malformed encodings from the valid encoding of 'obj', which decoders of its type must
+// reject: encodings of static types that are truncated by a byte or to a 2 byte prefix or that are extended, and for containers with dynamic fields encodings that
+// are truncated in the middle of an offset, offsets out of range, overlapping offsets and lists of dynamic items that
+// claim a huge number of items. Lists of dynamic items are only covered if they are not empty in 'obj', so the sample
+// object should fill them.
+// Bitlists are stored as byte lists, which dynssz does not distinguish from other byte lists, so malformed bitlists
+// are not covered.
+// Returns an error if 'obj' can not be encoded.
+func AdversarialVectors(ds *dynssz.DynSsz, obj any) ([]AdversarialVector, error) {
+	ssz, err := ds.MarshalSSZ(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed marshalling %T: %v", obj, err)
+	}
+
+	objType := reflect.TypeOf(obj)
+	objValue := reflect.ValueOf(obj)
+	for objType.Kind() == reflect.Ptr {
+		objType = objType.Elem()
+		objValue = objValue.Elem()
+	}
+
+	// the vectors are copied, so decoders reading beyond the truncated data do not see the original encoding
+	vectors := []AdversarialVector{}
+	if _, static, err := ds.StaticSizeOf(objType); err != nil {
+		return nil, err
+	} else if static {
+		if len(ssz) > 0 {
+			vectors = append(vectors, AdversarialVector{Name: "truncated", Data: append([]byte{}, ssz[:len(ssz)-1]...)})
+		}
+		if len(ssz) > 2 {
+			// decoders must check the length before copying fixed size data, not just the item alignment
+			vectors = append(vectors, AdversarialVector{Name: "truncated-to-prefix", Data: append([]byte{}, ssz[:2]...)})
+		}
+		vectors = append(vectors, AdversarialVector{Name: "trailing-bytes", Data: append(append([]byte{}, ssz...), 0)})
+		return vectors, nil
+	}
+
+	if objType.Kind() != reflect.Struct {
+		return vectors, nil
+	}
+
+	previousOffset := -1
+	for i := 0; i < objType.NumField(); i++ {
+		field := objType.Field(i)
+		position, _, static, err := ds.FieldOffset(objType, field.Name)
+		if err != nil {
+			return nil, err
+		}
+		if static {
+			continue
+		}
+
+		fieldOffset := int(binary.LittleEndian.Uint32(ssz[position : position+4]))
+
+		vectors = append(vectors, AdversarialVector{
+			Name: "truncated-mid-offset/" + field.Name,
+			Data: append([]byte{}, ssz[:position+2]...),
+		})
+		vectors = append(vectors, AdversarialVector{
+			Name: "offset-out-of-range/" + field.Name,
+			Data: withOffset(ssz, position, 0xffffffff),
+		})
+
+		// the first offset must point to the end of the fixed part, later offsets must not precede the previous one
+		overlappingOffset := fieldOffset - 1
+		if previousOffset >= 0 {
+			overlappingOffset = previousOffset - 1
+		}
+		vectors = append(vectors, AdversarialVector{
+			Name: "overlapping-offsets/" + field.Name,
+			Data: withOffset(ssz, position, uint32(overlappingOffset)),
+		})
+		previousOffset = fieldOffset
+
+		// lists of dynamic items derive their length from the first item offset
+		if field.Type.Kind() == reflect.Slice && objValue.Field(i).Len() > 0 && field.Tag.Get("ssz-size") == "" && field.Tag.Get("dynssz-size") == "" {
+			if _, itemStatic, err := ds.StaticSizeOf(field.Type.Elem()); err == nil && !itemStatic {
+				vectors = append(vectors, AdversarialVector{
+					Name: "huge-list-length/" + field.Name,
+					Data: withOffset(ssz, fieldOffset, 0xfffffffc),
+				})
+			}
+		}
+	}
+
+	return vectors, nil
+}
+
+// CheckAdversarial checks that decoding fails for all AdversarialVectors of 'obj' and for all encodings of the
+// regression corpus in the directory '<corpusDir>/<type name>', so inputs that once crashed or were accepted by a
+// decoder stay covered. The corpus files hold hex encoded SSZ data like golden files and must end with ".hex".
+// A missing corpus directory is not an error.
+func CheckAdversarial(t testing.TB, ds *dynssz.DynSsz, obj any, corpusDir string) {
+	t.Helper()
+
+	vectors, err := AdversarialVectors(ds, obj)
+	if err != nil {
+		t.Fatalf("failed deriving adversarial vectors: %v", err)
+	}
+
+	objType := reflect.TypeOf(obj)
+	for objType.Kind() == reflect.Ptr {
+		objType = objType.Elem()
+	}
+
+	// unnamed types (e.g. [32]byte) have no corpus directory
+	corpusFiles := []string{}
+	if objType.Name() != "" {
+		corpusFiles, err = filepath.Glob(filepath.Join(corpusDir, objType.Name(), "*.hex"))
+		if err != nil {
+			t.Fatalf("failed listing corpus files: %v", err)
+		}
+	}
+	sort.Strings(corpusFiles)
+	for _, corpusFile := range corpusFiles {
+		corpusData, err := os.ReadFile(corpusFile)
+		if err != nil {
+			t.Fatalf("failed reading corpus file: %v", err)
+		}
+		ssz, err := parseGolden(corpusData)
+		if err != nil {
+			t.Fatalf("failed parsing corpus file %v: %v", corpusFile, err)
+		}
+		vectors = append(vectors, AdversarialVector{
+			Name: strings.TrimSuffix(filepath.Base(corpusFile), ".hex"),
+			Data: ssz,
+		})
+	}
+
+	for _, vector := range vectors {
+		decoded := reflect.New(objType).Interface()
+		panicValue, err := decodeAdversarial(ds, decoded, vector.Data)
+		if panicValue != nil {
+			t.Errorf("adversarial vector %v of %v made the decoder panic: %v", vector.Name, objType, panicValue)
+		} else if err == nil {
+			t.Errorf("adversarial vector %v of %v decoded without error", vector.Name, objType)
+		}
+	}
+}
+
+// decodeAdversarial decodes an adversarial vector and recovers panics, so a crashing decoder fails the test with the
+// name of the vector instead of aborting it.
+func decodeAdversarial(ds *dynssz.DynSsz, target any, ssz []byte) (panicValue any, err error) {
+	defer func() {
+		panicValue = recover()
+	}()
+
+	return nil, ds.UnmarshalSSZ(target, ssz)
+}
+
+// withOffset returns a copy of the encoding with the 4 byte offset at 'position' replaced.
+func withOffset(ssz []byte, position int, offset uint32) []byte {
+	data := append([]byte{}, ssz...)
+	binary.LittleEndian.PutUint32(data[position:position+4], offset)
+	return data
+}
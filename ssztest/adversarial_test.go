@@ -0,0 +1,77 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package ssztest_test
+
+import (
+	"strings"
+	"testing"
+
+	dynssz "github.com/pk910/dynamic-ssz"
+	"github.com/pk910/dynamic-ssz/ssztest"
+)
+
+type adversarialStruct struct {
+	F1 uint32
+	F2 []*adversarialItem `ssz-max:"16"`
+	F3 []uint8            `ssz-max:"32"`
+}
+
+type adversarialItem struct {
+	F1 []uint16 `ssz-max:"8"`
+}
+
+type adversarialRoot [32]byte
+
+type adversarialStaticStruct struct {
+	F1 uint64
+	F2 [4]byte
+}
+
+func TestAdversarialVectors(t *testing.T) {
+	ds := dynssz.NewDynSsz(nil)
+	obj := &adversarialStruct{F1: 1, F2: []*adversarialItem{{F1: []uint16{1}}}, F3: []uint8{0xaa}}
+
+	vectors, err := ssztest.AdversarialVectors(ds, obj)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	names := make([]string, len(vectors))
+	for i, vector := range vectors {
+		names[i] = vector.Name
+	}
+	expected := "truncated-mid-offset/F2,offset-out-of-range/F2,overlapping-offsets/F2,huge-list-length/F2," +
+		"truncated-mid-offset/F3,offset-out-of-range/F3,overlapping-offsets/F3"
+	if strings.Join(names, ",") != expected {
+		t.Errorf("unexpected vectors: %v", strings.Join(names, ","))
+	}
+
+	vectors, err = ssztest.AdversarialVectors(ds, &adversarialStaticStruct{F1: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(vectors) != 3 || len(vectors[0].Data) != 11 || len(vectors[1].Data) != 2 || len(vectors[2].Data) != 13 {
+		t.Errorf("unexpected vectors for static type: %v", vectors)
+	}
+}
+
+func TestCheckAdversarial(t *testing.T) {
+	ds := dynssz.NewDynSsz(nil)
+
+	ssztest.CheckAdversarial(t, ds, &adversarialStruct{F1: 1, F2: []*adversarialItem{{F1: []uint16{1}}}, F3: []uint8{0xaa}}, "testdata/adversarial")
+	ssztest.CheckAdversarial(t, ds, &adversarialStaticStruct{F1: 1}, "testdata/adversarial")
+	ssztest.CheckAdversarial(t, ds, &testStruct{F1: 1, F2: []uint8{1, 2}}, "testdata/adversarial")
+
+	// truncated fixed size vectors at the top level
+	ssztest.CheckAdversarial(t, ds, &[32]byte{1}, "testdata/adversarial")
+	ssztest.CheckAdversarial(t, ds, &[4]uint64{1, 2, 3, 4}, "testdata/adversarial")
+	ssztest.CheckAdversarial(t, ds, &adversarialRoot{1}, "testdata/adversarial")
+
+	// a corpus entry that is a valid encoding must be reported
+	recorder := &recordingTB{TB: t}
+	ssztest.CheckAdversarial(recorder, ds, &adversarialStruct{}, "testdata/adversarial-invalid")
+	if len(recorder.errors) != 1 || !strings.Contains(recorder.errors[0], "valid-encoding") {
+		t.Errorf("expected error for valid corpus entry, got: %v", recorder.errors)
+	}
+}
@@ -0,0 +1,112 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+
+// Package ssztest provides test helpers for checking SSZ encodings produced with dynssz.
+package ssztest
+
+import (
+	"bytes"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	dynssz "github.com/pk910/dynamic-ssz"
+)
+
+// update makes Golden write the golden files instead of comparing against them.
+var update = flag.Bool("update-golden", false, "update the ssz golden files")
+
+// goldenLineBytes is the number of bytes per line in golden files.
+const goldenLineBytes = 32
+
+// Golden compares the SSZ encoding of 'obj' with the golden file at 'path', so changes to the encoding of a type
+// (e.g. by modified struct tags or spec values) show up as test failures. The golden file holds the hex encoded SSZ
+// bytes, 32 bytes per line, so differences are easy to spot in diffs. The golden encoding is additionally decoded into
+// a new instance of the type and re-encoded, to catch decoding regressions.
+// Run the tests with the '-update-golden' flag to create or update the golden files.
+func Golden(t testing.TB, ds *dynssz.DynSsz, obj any, path string) {
+	t.Helper()
+
+	ssz, err := ds.MarshalSSZ(obj)
+	if err != nil {
+		t.Fatalf("failed marshalling %T: %v", obj, err)
+	}
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed creating golden file directory: %v", err)
+		}
+		if err := os.WriteFile(path, formatGolden(ssz), 0o644); err != nil {
+			t.Fatalf("failed writing golden file: %v", err)
+		}
+		return
+	}
+
+	goldenData, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed reading golden file (run with -update-golden to create it): %v", err)
+	}
+	golden, err := parseGolden(goldenData)
+	if err != nil {
+		t.Fatalf("failed parsing golden file %v: %v", path, err)
+	}
+
+	if !bytes.Equal(ssz, golden) {
+		t.Errorf("ssz encoding of %T does not match golden file %v: %v", obj, path, describeMismatch(ssz, golden))
+		return
+	}
+
+	objType := reflect.TypeOf(obj)
+	if objType.Kind() == reflect.Ptr {
+		objType = objType.Elem()
+	}
+	decoded := reflect.New(objType).Interface()
+	if err := ds.UnmarshalSSZ(decoded, golden); err != nil {
+		t.Errorf("failed unmarshalling golden file %v: %v", path, err)
+		return
+	}
+
+	reencoded, err := ds.MarshalSSZ(decoded)
+	if err != nil {
+		t.Errorf("failed marshalling decoded golden file %v: %v", path, err)
+		return
+	}
+	if !bytes.Equal(reencoded, golden) {
+		t.Errorf("re-encoded golden file %v does not match: %v", path, describeMismatch(reencoded, golden))
+	}
+}
+
+// formatGolden formats ssz bytes as hex lines.
+func formatGolden(ssz []byte) []byte {
+	var out bytes.Buffer
+	for i := 0; i < len(ssz); i += goldenLineBytes {
+		end := i + goldenLineBytes
+		if end > len(ssz) {
+			end = len(ssz)
+		}
+		out.WriteString(hex.EncodeToString(ssz[i:end]))
+		out.WriteByte('\n')
+	}
+	return out.Bytes()
+}
+
+// parseGolden parses the hex lines of a golden file.
+func parseGolden(data []byte) ([]byte, error) {
+	return hex.DecodeString(strings.Join(strings.Fields(string(data)), ""))
+}
+
+// describeMismatch describes the first difference between two encodings.
+func describeMismatch(ssz []byte, golden []byte) string {
+	for i := 0; i < len(ssz) && i < len(golden); i++ {
+		if ssz[i] != golden[i] {
+			return fmt.Sprintf("first difference at byte %v (line %v)", i, i/goldenLineBytes+1)
+		}
+	}
+	return fmt.Sprintf("length differs (got %v bytes, golden %v bytes)", len(ssz), len(golden))
+}
@@ -0,0 +1,66 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package ssztest_test
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	dynssz "github.com/pk910/dynamic-ssz"
+	"github.com/pk910/dynamic-ssz/ssztest"
+)
+
+type testStruct struct {
+	F1 uint64
+	F2 []uint8 `ssz-size:"?" dynssz-size:"?"`
+}
+
+// recordingTB records test failures instead of failing the test.
+type recordingTB struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestGolden(t *testing.T) {
+	ds := dynssz.NewDynSsz(nil)
+	path := filepath.Join(t.TempDir(), "testdata", "obj.golden")
+	obj := &testStruct{F1: 1, F2: make([]uint8, 40)}
+
+	if err := flag.Set("update-golden", "true"); err != nil {
+		t.Fatalf("failed setting flag: %v", err)
+	}
+	ssztest.Golden(t, ds, obj, path)
+	if err := flag.Set("update-golden", "false"); err != nil {
+		t.Fatalf("failed setting flag: %v", err)
+	}
+
+	goldenData, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("golden file not written: %v", err)
+	}
+	expected := "01000000000000000c0000000000000000000000000000000000000000000000\n" +
+		"0000000000000000000000000000000000000000\n"
+	if string(goldenData) != expected {
+		t.Errorf("unexpected golden file content:\n%v", string(goldenData))
+	}
+
+	ssztest.Golden(t, ds, obj, path)
+
+	recorder := &recordingTB{TB: t}
+	obj.F2[20] = 1
+	ssztest.Golden(recorder, ds, obj, path)
+	if len(recorder.errors) != 1 || !strings.Contains(recorder.errors[0], "first difference at byte 32") {
+		t.Errorf("expected mismatch error, got %v", recorder.errors)
+	}
+}
@@ -0,0 +1,28 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+
+// Package sszutils provides small helpers for working with SSZ data.
+// The uint helpers read and write little endian basic values with the semantics of the dynssz encoder and decoder,
+// and return errors on short buffers instead of panicking, so custom codecs can use them on untrusted input.
+// The comparison helpers run in constant time for inputs of the same length, so they can be used to check roots,
+// hashes and signatures in validation paths without leaking the position of the first mismatch via timing.
+package sszutils
+
+import "crypto/subtle"
+
+// EqualRoot reports whether the roots a and b are equal, in constant time.
+func EqualRoot(a, b [32]byte) bool {
+	return subtle.ConstantTimeCompare(a[:], b[:]) == 1
+}
+
+// EqualSignature reports whether the BLS signatures a and b are equal, in constant time.
+func EqualSignature(a, b [96]byte) bool {
+	return subtle.ConstantTimeCompare(a[:], b[:]) == 1
+}
+
+// EqualBytes reports whether a and b are equal, in constant time for inputs of the same length.
+// Inputs of different length return false immediately, so the length itself is not hidden.
+func EqualBytes(a, b []byte) bool {
+	return subtle.ConstantTimeCompare(a, b) == 1
+}
@@ -0,0 +1,49 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package sszutils_test
+
+import (
+	"testing"
+
+	"github.com/pk910/dynamic-ssz/sszutils"
+)
+
+func TestEqualRoot(t *testing.T) {
+	a := [32]byte{1, 2, 3}
+	b := a
+	if !sszutils.EqualRoot(a, b) {
+		t.Errorf("expected equal roots")
+	}
+	b[31] = 1
+	if sszutils.EqualRoot(a, b) {
+		t.Errorf("expected different roots")
+	}
+}
+
+func TestEqualSignature(t *testing.T) {
+	a := [96]byte{1, 2, 3}
+	b := a
+	if !sszutils.EqualSignature(a, b) {
+		t.Errorf("expected equal signatures")
+	}
+	b[95] = 1
+	if sszutils.EqualSignature(a, b) {
+		t.Errorf("expected different signatures")
+	}
+}
+
+func TestEqualBytes(t *testing.T) {
+	if !sszutils.EqualBytes([]byte{1, 2}, []byte{1, 2}) {
+		t.Errorf("expected equal bytes")
+	}
+	if !sszutils.EqualBytes(nil, []byte{}) {
+		t.Errorf("expected nil and empty slices to be equal")
+	}
+	if sszutils.EqualBytes([]byte{1, 2}, []byte{1, 3}) {
+		t.Errorf("expected different bytes")
+	}
+	if sszutils.EqualBytes([]byte{1, 2}, []byte{1, 2, 3}) {
+		t.Errorf("expected different length to be unequal")
+	}
+}
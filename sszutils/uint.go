@@ -0,0 +1,136 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package sszutils
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// ErrShortBuffer is returned by the read and put helpers if the buffer is shorter than the encoded value.
+var ErrShortBuffer = fmt.Errorf("buffer too short")
+
+// checkBuffer returns an error wrapping ErrShortBuffer if buf holds less than 'size' bytes.
+func checkBuffer(buf []byte, size int) error {
+	if len(buf) < size {
+		return fmt.Errorf("%w: expected %v bytes, got %v", ErrShortBuffer, size, len(buf))
+	}
+	return nil
+}
+
+// ReadBool reads a boolean from the first byte of buf. Like the decoder, only 1 is read as true.
+func ReadBool(buf []byte) (bool, error) {
+	if err := checkBuffer(buf, 1); err != nil {
+		return false, err
+	}
+	return buf[0] == 1, nil
+}
+
+// ReadUint8 reads an uint8 from the first byte of buf.
+func ReadUint8(buf []byte) (uint8, error) {
+	if err := checkBuffer(buf, 1); err != nil {
+		return 0, err
+	}
+	return buf[0], nil
+}
+
+// ReadUint16 reads a little endian uint16 from the first 2 bytes of buf.
+func ReadUint16(buf []byte) (uint16, error) {
+	if err := checkBuffer(buf, 2); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint16(buf), nil
+}
+
+// ReadUint32 reads a little endian uint32 from the first 4 bytes of buf.
+func ReadUint32(buf []byte) (uint32, error) {
+	if err := checkBuffer(buf, 4); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(buf), nil
+}
+
+// ReadUint64 reads a little endian uint64 from the first 8 bytes of buf.
+func ReadUint64(buf []byte) (uint64, error) {
+	if err := checkBuffer(buf, 8); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(buf), nil
+}
+
+// PutBool writes a boolean as single byte (0 or 1) to the start of buf.
+func PutBool(buf []byte, value bool) error {
+	if err := checkBuffer(buf, 1); err != nil {
+		return err
+	}
+	buf[0] = 0
+	if value {
+		buf[0] = 1
+	}
+	return nil
+}
+
+// PutUint8 writes an uint8 to the start of buf.
+func PutUint8(buf []byte, value uint8) error {
+	if err := checkBuffer(buf, 1); err != nil {
+		return err
+	}
+	buf[0] = value
+	return nil
+}
+
+// PutUint16 writes a little endian uint16 to the first 2 bytes of buf.
+func PutUint16(buf []byte, value uint16) error {
+	if err := checkBuffer(buf, 2); err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint16(buf, value)
+	return nil
+}
+
+// PutUint32 writes a little endian uint32 to the first 4 bytes of buf.
+func PutUint32(buf []byte, value uint32) error {
+	if err := checkBuffer(buf, 4); err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint32(buf, value)
+	return nil
+}
+
+// PutUint64 writes a little endian uint64 to the first 8 bytes of buf.
+func PutUint64(buf []byte, value uint64) error {
+	if err := checkBuffer(buf, 8); err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint64(buf, value)
+	return nil
+}
+
+// AppendBool appends a boolean as single byte (0 or 1) to dst.
+func AppendBool(dst []byte, value bool) []byte {
+	if value {
+		return append(dst, 1)
+	}
+	return append(dst, 0)
+}
+
+// AppendUint8 appends an uint8 to dst.
+func AppendUint8(dst []byte, value uint8) []byte {
+	return append(dst, value)
+}
+
+// AppendUint16 appends a little endian uint16 to dst.
+func AppendUint16(dst []byte, value uint16) []byte {
+	return binary.LittleEndian.AppendUint16(dst, value)
+}
+
+// AppendUint32 appends a little endian uint32 to dst.
+func AppendUint32(dst []byte, value uint32) []byte {
+	return binary.LittleEndian.AppendUint32(dst, value)
+}
+
+// AppendUint64 appends a little endian uint64 to dst.
+func AppendUint64(dst []byte, value uint64) []byte {
+	return binary.LittleEndian.AppendUint64(dst, value)
+}
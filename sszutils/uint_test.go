@@ -0,0 +1,78 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package sszutils_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/pk910/dynamic-ssz/sszutils"
+)
+
+func TestReadUint(t *testing.T) {
+	buf := []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}
+
+	if v, err := sszutils.ReadUint8(buf); err != nil || v != 0x01 {
+		t.Errorf("ReadUint8 = %v, %v", v, err)
+	}
+	if v, err := sszutils.ReadUint16(buf); err != nil || v != 0x0201 {
+		t.Errorf("ReadUint16 = %x, %v", v, err)
+	}
+	if v, err := sszutils.ReadUint32(buf); err != nil || v != 0x04030201 {
+		t.Errorf("ReadUint32 = %x, %v", v, err)
+	}
+	if v, err := sszutils.ReadUint64(buf); err != nil || v != 0x0807060504030201 {
+		t.Errorf("ReadUint64 = %x, %v", v, err)
+	}
+	if v, err := sszutils.ReadBool(buf); err != nil || !v {
+		t.Errorf("ReadBool = %v, %v", v, err)
+	}
+
+	if _, err := sszutils.ReadUint64(buf[:7]); !errors.Is(err, sszutils.ErrShortBuffer) {
+		t.Errorf("expected ErrShortBuffer, got: %v", err)
+	}
+	if _, err := sszutils.ReadUint32(buf[:3]); !errors.Is(err, sszutils.ErrShortBuffer) {
+		t.Errorf("expected ErrShortBuffer, got: %v", err)
+	}
+	if _, err := sszutils.ReadBool(nil); !errors.Is(err, sszutils.ErrShortBuffer) {
+		t.Errorf("expected ErrShortBuffer, got: %v", err)
+	}
+}
+
+func TestPutUint(t *testing.T) {
+	buf := make([]byte, 8)
+	if err := sszutils.PutUint64(buf, 0x0807060504030201); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(buf, []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}) {
+		t.Errorf("unexpected PutUint64 result: %x", buf)
+	}
+	if err := sszutils.PutUint16(buf, 0x0a09); err != nil || buf[0] != 0x09 || buf[1] != 0x0a {
+		t.Errorf("unexpected PutUint16 result: %x, %v", buf, err)
+	}
+	if err := sszutils.PutBool(buf, false); err != nil || buf[0] != 0 {
+		t.Errorf("unexpected PutBool result: %x, %v", buf, err)
+	}
+
+	if err := sszutils.PutUint64(buf[:4], 1); !errors.Is(err, sszutils.ErrShortBuffer) {
+		t.Errorf("expected ErrShortBuffer, got: %v", err)
+	}
+	if err := sszutils.PutUint8(nil, 1); !errors.Is(err, sszutils.ErrShortBuffer) {
+		t.Errorf("expected ErrShortBuffer, got: %v", err)
+	}
+}
+
+func TestAppendUint(t *testing.T) {
+	buf := sszutils.AppendBool(nil, true)
+	buf = sszutils.AppendUint8(buf, 0x02)
+	buf = sszutils.AppendUint16(buf, 0x0403)
+	buf = sszutils.AppendUint32(buf, 0x08070605)
+	buf = sszutils.AppendUint64(buf, 0x100f0e0d0c0b0a09)
+
+	expected := []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10}
+	if !bytes.Equal(buf, expected) {
+		t.Errorf("unexpected append result: %x", buf)
+	}
+}
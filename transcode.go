@@ -0,0 +1,450 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pk910/dynamic-ssz/sszutils"
+)
+
+// transcodeChunkSize is the maximum number of bytes read at once when streaming byte lists.
+const transcodeChunkSize = 32 * 1024
+
+// TranscodeSSZToJSON converts SSZ-encoded data of the given type from r to JSON and writes it to w, without building
+// the Go object. The SSZ data is walked sequentially by following the offsets, so only the fixed size parts of the
+// containers need to be held in memory at a time. This allows converting very large structures (e.g. BeaconStates)
+// for inspection with bounded memory.
+// The 'targetType' parameter accepts either an instance or a reflect.Type value of the type the SSZ data represents.
+// Byte arrays and byte lists are written as 0x-prefixed hex strings, integers as JSON numbers and field names follow
+// the 'json' struct tags if present.
+func (d *DynSsz) TranscodeSSZToJSON(targetType any, r io.Reader, w io.Writer) error {
+	sszType, ok := targetType.(reflect.Type)
+	if !ok {
+		sszType = reflect.TypeOf(targetType)
+	}
+
+	transcoder := &sszJsonTranscoder{
+		dynssz: d,
+		reader: r,
+		writer: bufio.NewWriterSize(w, d.getStreamBufferSize()),
+	}
+
+	if _, err := transcoder.transcodeType(sszType, []sszSizeHint{}, -1); err != nil {
+		return err
+	}
+
+	return transcoder.writer.Flush()
+}
+
+type sszJsonTranscoder struct {
+	dynssz *DynSsz
+	reader io.Reader
+	writer *bufio.Writer
+}
+
+// transcodeType transcodes a value of the given type from the stream. The 'length' parameter is the size of the ssz
+// range of the value, or -1 if the value extends to the end of the stream. Returns the number of consumed bytes.
+func (t *sszJsonTranscoder) transcodeType(targetType reflect.Type, sizeHints []sszSizeHint, length int) (int, error) {
+	if targetType.Kind() == reflect.Ptr {
+		targetType = targetType.Elem()
+	}
+
+	size, _, err := t.dynssz.getSszSize(targetType, sizeHints)
+	if err != nil {
+		return 0, err
+	}
+
+	if size >= 0 {
+		if length >= 0 && length != size {
+			return 0, fmt.Errorf("invalid ssz range for static type %v (expected: %v, got: %v)", targetType, size, length)
+		}
+
+		ssz := make([]byte, size)
+		if _, err := io.ReadFull(t.reader, ssz); err != nil {
+			return 0, err
+		}
+
+		if err := t.writeStatic(targetType, sizeHints, ssz); err != nil {
+			return 0, err
+		}
+		return size, nil
+	}
+
+	switch targetType.Kind() {
+	case reflect.Struct:
+		return t.transcodeStruct(targetType, length)
+	case reflect.Array, reflect.Slice:
+		return t.transcodeSlice(targetType, sizeHints, length)
+	default:
+		return 0, fmt.Errorf("unknown dynamic type: %v", targetType)
+	}
+}
+
+// transcodeStruct transcodes a dynamic size container by reading its fixed part first and following the offsets
+// of the dynamic fields afterwards.
+func (t *sszJsonTranscoder) transcodeStruct(targetType reflect.Type, length int) (int, error) {
+	fieldCount := targetType.NumField()
+	fieldSizes := make([]int, fieldCount)
+	fieldSizeHints := make([][]sszSizeHint, fieldCount)
+	fixedSize := 0
+
+	for i := 0; i < fieldCount; i++ {
+		field := t.dynssz.getStructField(targetType, i)
+		fieldSize, _, sizeHints, err := t.dynssz.getSszFieldSize(&field)
+		if err != nil {
+			return 0, err
+		}
+
+		fieldSizes[i] = fieldSize
+		fieldSizeHints[i] = sizeHints
+		if fieldSize < 0 {
+			fixedSize += 4
+		} else {
+			fixedSize += fieldSize
+		}
+	}
+
+	if length >= 0 && length < fixedSize {
+		return 0, fmt.Errorf("unexpected end of SSZ. container %v expects %v bytes, got %v", targetType, fixedSize, length)
+	}
+
+	fixedSsz := make([]byte, fixedSize)
+	if _, err := io.ReadFull(t.reader, fixedSsz); err != nil {
+		return 0, err
+	}
+
+	// collect offsets of the dynamic fields
+	dynamicOffsets := []int{}
+	offset := 0
+	for i := 0; i < fieldCount; i++ {
+		if fieldSizes[i] < 0 {
+			dynamicOffsets = append(dynamicOffsets, readOffsetInt(fixedSsz[offset:offset+4]))
+			offset += 4
+		} else {
+			offset += fieldSizes[i]
+		}
+	}
+
+	t.writer.WriteString("{")
+
+	consumed := fixedSize
+	offset = 0
+	dynamicIdx := 0
+	for i := 0; i < fieldCount; i++ {
+		field := t.dynssz.getStructField(targetType, i)
+		if i > 0 {
+			t.writer.WriteString(",")
+		}
+		t.writeFieldName(&field)
+
+		if fieldSizes[i] >= 0 {
+			if err := t.writeStatic(field.Type, fieldSizeHints[i], fixedSsz[offset:offset+fieldSizes[i]]); err != nil {
+				return 0, fmt.Errorf("failed transcoding field %v: %v", field.Name, err)
+			}
+			offset += fieldSizes[i]
+			continue
+		}
+
+		startOffset := dynamicOffsets[dynamicIdx]
+		endOffset := length
+		if dynamicIdx < len(dynamicOffsets)-1 {
+			endOffset = dynamicOffsets[dynamicIdx+1]
+		}
+		if startOffset != consumed || (endOffset >= 0 && endOffset < startOffset) || (length >= 0 && endOffset > length) {
+			return 0, ErrOffset
+		}
+
+		fieldLength := -1
+		if endOffset >= 0 {
+			fieldLength = endOffset - startOffset
+		}
+
+		fieldConsumed, err := t.transcodeType(field.Type, fieldSizeHints[i], fieldLength)
+		if err != nil {
+			return 0, fmt.Errorf("failed transcoding field %v: %v", field.Name, err)
+		}
+
+		consumed += fieldConsumed
+		offset += 4
+		dynamicIdx++
+	}
+
+	t.writer.WriteString("}")
+
+	if length >= 0 && consumed != length {
+		return 0, fmt.Errorf("container %v did not consume expected ssz range (consumed: %v, expected: %v)", targetType, consumed, length)
+	}
+
+	return consumed, nil
+}
+
+// transcodeSlice transcodes a list with a dynamic length or a vector with dynamic size items. Byte lists are streamed in
+// chunks, lists with static size items are read item by item and lists with dynamic size items follow the item offsets.
+func (t *sszJsonTranscoder) transcodeSlice(targetType reflect.Type, sizeHints []sszSizeHint, length int) (int, error) {
+	childSizeHints := []sszSizeHint{}
+	if len(sizeHints) > 1 {
+		childSizeHints = sizeHints[1:]
+	}
+
+	fieldType := targetType.Elem()
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+
+	if isByteType(fieldType) {
+		return t.transcodeByteList(length)
+	}
+
+	itemSize := -1
+	if len(sizeHints) <= 1 || !sizeHints[1].dynamic {
+		size, _, err := t.dynssz.getSszSize(fieldType, childSizeHints)
+		if err != nil {
+			return 0, err
+		}
+		itemSize = size
+	}
+
+	t.writer.WriteString("[")
+	consumed := 0
+
+	if itemSize > 0 {
+		// list with static size items
+		if length >= 0 && length%itemSize != 0 {
+			return 0, fmt.Errorf("invalid slice length, expected multiple of %v, got %v", itemSize, length)
+		}
+
+		itemSsz := make([]byte, itemSize)
+		for i := 0; length < 0 || consumed < length; i++ {
+			if _, err := io.ReadFull(t.reader, itemSsz); err != nil {
+				if err == io.EOF && length < 0 {
+					break
+				}
+				return 0, err
+			}
+
+			if i > 0 {
+				t.writer.WriteString(",")
+			}
+			if err := t.writeStatic(fieldType, childSizeHints, itemSsz); err != nil {
+				return 0, err
+			}
+			consumed += itemSize
+		}
+	} else if length != 0 {
+		// list with dynamic size items
+		offsetSsz := make([]byte, 4)
+		if _, err := io.ReadFull(t.reader, offsetSsz); err != nil {
+			if err == io.EOF && length < 0 {
+				t.writer.WriteString("]")
+				return 0, nil
+			}
+			return 0, err
+		}
+
+		firstOffset := readOffsetInt(offsetSsz)
+		if firstOffset%4 != 0 || firstOffset == 0 || (length >= 0 && firstOffset > length) {
+			return 0, ErrOffset
+		}
+
+		itemCount := firstOffset / 4
+		if targetType.Kind() == reflect.Array && itemCount != targetType.Len() {
+			return 0, ErrOffset
+		}
+
+		// the item count is taken from untrusted input, so the offsets are read in chunks and the offset list only
+		// grows with the data actually read from the stream
+		itemOffsets := []int{firstOffset}
+		offsetsSsz := make([]byte, min((itemCount-1)*4, transcodeChunkSize))
+		for len(itemOffsets) < itemCount {
+			readLen := (itemCount - len(itemOffsets)) * 4
+			if readLen > len(offsetsSsz) {
+				readLen = len(offsetsSsz)
+			}
+			if _, err := io.ReadFull(t.reader, offsetsSsz[:readLen]); err != nil {
+				return 0, err
+			}
+			for i := 0; i < readLen; i += 4 {
+				itemOffsets = append(itemOffsets, readOffsetInt(offsetsSsz[i:i+4]))
+			}
+		}
+
+		consumed = firstOffset
+		for i := 0; i < itemCount; i++ {
+			endOffset := length
+			if i < itemCount-1 {
+				endOffset = itemOffsets[i+1]
+			}
+			if itemOffsets[i] != consumed || (endOffset >= 0 && endOffset < itemOffsets[i]) || (length >= 0 && endOffset > length) {
+				return 0, ErrOffset
+			}
+
+			itemLength := -1
+			if endOffset >= 0 {
+				itemLength = endOffset - itemOffsets[i]
+			}
+
+			if i > 0 {
+				t.writer.WriteString(",")
+			}
+			itemConsumed, err := t.transcodeType(fieldType, childSizeHints, itemLength)
+			if err != nil {
+				return 0, err
+			}
+			consumed += itemConsumed
+		}
+	}
+
+	t.writer.WriteString("]")
+	return consumed, nil
+}
+
+// transcodeByteList streams a byte list as hex string in chunks of transcodeChunkSize bytes.
+func (t *sszJsonTranscoder) transcodeByteList(length int) (int, error) {
+	t.writer.WriteString("\"0x")
+
+	chunk := make([]byte, transcodeChunkSize)
+	hexChunk := make([]byte, transcodeChunkSize*2)
+	consumed := 0
+	for length < 0 || consumed < length {
+		readLen := transcodeChunkSize
+		if length >= 0 && length-consumed < readLen {
+			readLen = length - consumed
+		}
+
+		n, err := io.ReadFull(t.reader, chunk[:readLen])
+		if n > 0 {
+			hex.Encode(hexChunk, chunk[:n])
+			t.writer.Write(hexChunk[:n*2])
+			consumed += n
+		}
+		if err != nil {
+			if length < 0 && (err == io.EOF || err == io.ErrUnexpectedEOF) {
+				break
+			}
+			return 0, err
+		}
+	}
+
+	t.writer.WriteString("\"")
+	return consumed, nil
+}
+
+// writeStatic writes the JSON representation of a static size value from its ssz range.
+func (t *sszJsonTranscoder) writeStatic(targetType reflect.Type, sizeHints []sszSizeHint, ssz []byte) error {
+	if targetType.Kind() == reflect.Ptr {
+		targetType = targetType.Elem()
+	}
+
+	childSizeHints := []sszSizeHint{}
+	if len(sizeHints) > 1 {
+		childSizeHints = sizeHints[1:]
+	}
+
+	switch targetType.Kind() {
+	case reflect.Struct:
+		t.writer.WriteString("{")
+		offset := 0
+		for i := 0; i < targetType.NumField(); i++ {
+			field := t.dynssz.getStructField(targetType, i)
+			fieldSize, _, fieldSizeHints, err := t.dynssz.getSszFieldSize(&field)
+			if err != nil {
+				return err
+			}
+
+			if i > 0 {
+				t.writer.WriteString(",")
+			}
+			t.writeFieldName(&field)
+			if err := t.writeStatic(field.Type, fieldSizeHints, ssz[offset:offset+fieldSize]); err != nil {
+				return fmt.Errorf("failed transcoding field %v: %v", field.Name, err)
+			}
+			offset += fieldSize
+		}
+		t.writer.WriteString("}")
+	case reflect.Array, reflect.Slice:
+		fieldType := targetType.Elem()
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		if isByteType(fieldType) {
+			t.writer.WriteString("\"0x")
+			t.writer.WriteString(hex.EncodeToString(ssz))
+			t.writer.WriteString("\"")
+			return nil
+		}
+
+		itemSize, _, err := t.dynssz.getSszSize(fieldType, childSizeHints)
+		if err != nil {
+			return err
+		}
+
+		t.writer.WriteString("[")
+		for i := 0; itemSize > 0 && i*itemSize < len(ssz); i++ {
+			if i > 0 {
+				t.writer.WriteString(",")
+			}
+			if err := t.writeStatic(fieldType, childSizeHints, ssz[i*itemSize:(i+1)*itemSize]); err != nil {
+				return err
+			}
+		}
+		t.writer.WriteString("]")
+	case reflect.Bool:
+		value, err := sszutils.ReadBool(ssz)
+		if err != nil {
+			return err
+		}
+		t.writer.WriteString(strconv.FormatBool(value))
+	case reflect.Uint8:
+		value, err := sszutils.ReadUint8(ssz)
+		if err != nil {
+			return err
+		}
+		t.writer.WriteString(strconv.FormatUint(uint64(value), 10))
+	case reflect.Uint16:
+		value, err := sszutils.ReadUint16(ssz)
+		if err != nil {
+			return err
+		}
+		t.writer.WriteString(strconv.FormatUint(uint64(value), 10))
+	case reflect.Uint32:
+		value, err := sszutils.ReadUint32(ssz)
+		if err != nil {
+			return err
+		}
+		t.writer.WriteString(strconv.FormatUint(uint64(value), 10))
+	case reflect.Uint64:
+		value, err := sszutils.ReadUint64(ssz)
+		if err != nil {
+			return err
+		}
+		t.writer.WriteString(strconv.FormatUint(value, 10))
+	default:
+		return fmt.Errorf("unknown type: %v", targetType)
+	}
+
+	return nil
+}
+
+// writeFieldName writes the quoted JSON name of a struct field, using the name from the 'json' tag if present.
+func (t *sszJsonTranscoder) writeFieldName(field *reflect.StructField) {
+	name := field.Name
+	if jsonTag, ok := field.Tag.Lookup("json"); ok {
+		if tagName := strings.Split(jsonTag, ",")[0]; tagName != "" && tagName != "-" {
+			name = tagName
+		}
+	}
+
+	nameJson, _ := json.Marshal(name)
+	t.writer.Write(nameJson)
+	t.writer.WriteString(":")
+}
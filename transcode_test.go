@@ -0,0 +1,110 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"bytes"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+var transcodeTestMatrix = []struct {
+	payload  any
+	ssz      []byte
+	expected string
+}{
+	{uint64(848028848028), fromHex("0x9c4f7572c5000000"), `848028848028`},
+	{[]uint8{1, 2, 3, 4, 5}, fromHex("0x0102030405"), `"0x0102030405"`},
+	{[]uint16{1, 2}, fromHex("0x01000200"), `[1,2]`},
+	{
+		struct {
+			F1 bool
+			F2 []uint8  `json:"f2"`
+			F3 []uint16 `ssz-size:"5"`
+			F4 uint32
+		}{},
+		fromHex("0x0113000000020002000200020000000300000001010101"),
+		`{"F1":true,"f2":"0x01010101","F3":[2,2,2,2,0],"F4":3}`,
+	},
+	{
+		struct {
+			F1 uint8
+			F2 [][]uint8 `ssz-size:"?,2"`
+			F3 uint8
+		}{},
+		fromHex("0x2a060000002b02020300"),
+		`{"F1":42,"F2":["0x0202","0x0300"],"F3":43}`,
+	},
+	{
+		struct {
+			F1 uint8
+			F2 []slug_DynStruct1 `ssz-size:"3"`
+			F3 uint8
+		}{},
+		fromHex("0x2a060000002b0c000000120000001a00000001050000000401050000000408040005000000"),
+		`{"F1":42,"F2":[{"F1":true,"F2":"0x04"},{"F1":true,"F2":"0x040804"},{"F1":false,"F2":"0x"}],"F3":43}`,
+	},
+	{
+		[]slug_DynStruct1{},
+		fromHex("0x080000000e000000010500000004010500000008"),
+		`[{"F1":true,"F2":"0x04"},{"F1":true,"F2":"0x08"}]`,
+	},
+	{
+		[2]*slug_DynStruct1{},
+		fromHex("0x080000000e0000000105000000040005000000"),
+		`[{"F1":true,"F2":"0x04"},{"F1":false,"F2":"0x"}]`,
+	},
+	{
+		struct {
+			F1 uint8
+			F2 []uint8
+		}{},
+		fromHex("0x2a0600000001"), // offset points into fixed part
+		"",
+	},
+	{
+		[][]uint8{},
+		fromHex("0xfcffff3f"), // first offset announces ~1 billion items without data
+		"",
+	},
+}
+
+func TestTranscodeSSZToJSON(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+
+	for idx, test := range transcodeTestMatrix {
+		out := strings.Builder{}
+		err := dynssz.TranscodeSSZToJSON(test.payload, bytes.NewReader(test.ssz), &out)
+
+		switch {
+		case test.expected == "" && err != nil:
+			// expected error
+		case err != nil:
+			t.Errorf("test %v error: %v", idx, err)
+		case out.String() != test.expected:
+			t.Errorf("test %v failed: got %v, wanted %v", idx, out.String(), test.expected)
+		}
+	}
+}
+
+func TestTranscodeHugeOffsetCount(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+
+	// the first offset announces ~1 billion items, which must not be allocated upfront
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	err := dynssz.TranscodeSSZToJSON([][]uint8{}, bytes.NewReader(fromHex("0xfcffff3f")), io.Discard)
+	runtime.ReadMemStats(&after)
+
+	if err == nil {
+		t.Fatalf("expected error for truncated offsets")
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1024*1024 {
+		t.Errorf("unexpected allocation of %v bytes for truncated offsets", allocated)
+	}
+}
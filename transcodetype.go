@@ -0,0 +1,127 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Transcode converts the given source value into a new value of type 'dstType'. This eases upgrading persisted custom
+// types between schema versions (e.g. across forks), where both versions share most of their fields.
+// Struct fields are matched by name: fields that only exist in the destination type are left at their zero value,
+// fields that only exist in the source type are dropped. Matching fields are converted recursively, so nested
+// containers and lists of containers may differ between both versions as well.
+// Returns the converted value (a pointer if 'dstType' is a pointer type), or an error if a matching field can not be
+// converted, e.g. because of incompatible kinds, integer overflows or vectors that are too short to hold the non-zero
+// items of the source data.
+// The converted value is checked against the size annotations of the destination type before it is returned.
+func (d *DynSsz) Transcode(src any, dstType reflect.Type) (any, error) {
+	dstValue := reflect.New(dstType).Elem()
+	if err := d.transcodeValue(reflect.ValueOf(src), dstValue); err != nil {
+		return nil, err
+	}
+
+	result := dstValue.Interface()
+	if _, err := d.SizeSSZ(result); err != nil {
+		return nil, fmt.Errorf("converted value does not fit type %v: %v", dstType, err)
+	}
+
+	return result, nil
+}
+
+// transcodeValue recursively converts the source value into the destination value.
+func (d *DynSsz) transcodeValue(srcValue reflect.Value, dstValue reflect.Value) error {
+	for srcValue.Kind() == reflect.Ptr || srcValue.Kind() == reflect.Interface {
+		if srcValue.IsNil() {
+			return nil
+		}
+		srcValue = srcValue.Elem()
+	}
+	if !srcValue.IsValid() {
+		return nil
+	}
+
+	dstType := dstValue.Type()
+	if dstType.Kind() == reflect.Ptr {
+		newValue := reflect.New(dstType.Elem())
+		if err := d.transcodeValue(srcValue, newValue.Elem()); err != nil {
+			return err
+		}
+		dstValue.Set(newValue)
+		return nil
+	}
+
+	srcType := srcValue.Type()
+	if srcType == dstType {
+		dstValue.Set(cloneValue(srcValue))
+		return nil
+	}
+
+	switch dstType.Kind() {
+	case reflect.Struct:
+		if srcType.Kind() != reflect.Struct {
+			return fmt.Errorf("can not convert %v to %v", srcType, dstType)
+		}
+		for i := 0; i < dstType.NumField(); i++ {
+			field := dstType.Field(i)
+			srcField := srcValue.FieldByName(field.Name)
+			if !srcField.IsValid() {
+				continue
+			}
+			if err := d.transcodeValue(srcField, dstValue.Field(i)); err != nil {
+				return fmt.Errorf("failed converting field %v: %v", field.Name, err)
+			}
+		}
+	case reflect.Array:
+		if srcType.Kind() != reflect.Array && srcType.Kind() != reflect.Slice {
+			return fmt.Errorf("can not convert %v to %v", srcType, dstType)
+		}
+		for i := 0; i < srcValue.Len(); i++ {
+			if i >= dstType.Len() {
+				// shrinking vectors is fine as long as no data gets lost
+				if !srcValue.Index(i).IsZero() {
+					return fmt.Errorf("vector too short for source data (length: %v, items: %v)", dstType.Len(), srcValue.Len())
+				}
+				continue
+			}
+			if err := d.transcodeValue(srcValue.Index(i), dstValue.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		if srcType.Kind() != reflect.Array && srcType.Kind() != reflect.Slice {
+			return fmt.Errorf("can not convert %v to %v", srcType, dstType)
+		}
+		if srcType.Kind() == reflect.Slice && srcValue.IsNil() {
+			return nil
+		}
+		newValue := reflect.MakeSlice(dstType, srcValue.Len(), srcValue.Len())
+		for i := 0; i < srcValue.Len(); i++ {
+			if err := d.transcodeValue(srcValue.Index(i), newValue.Index(i)); err != nil {
+				return err
+			}
+		}
+		dstValue.Set(newValue)
+	case reflect.Bool:
+		if srcType.Kind() != reflect.Bool {
+			return fmt.Errorf("can not convert %v to %v", srcType, dstType)
+		}
+		dstValue.SetBool(srcValue.Bool())
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch srcType.Kind() {
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		default:
+			return fmt.Errorf("can not convert %v to %v", srcType, dstType)
+		}
+		if dstValue.OverflowUint(srcValue.Uint()) {
+			return fmt.Errorf("value %v overflows %v", srcValue.Uint(), dstType)
+		}
+		dstValue.SetUint(srcValue.Uint())
+	default:
+		return fmt.Errorf("unknown type: %v", dstType)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_TranscodeItemV1 struct {
+	A uint32
+}
+
+type slug_TranscodeItemV2 struct {
+	A uint64
+	B bool
+}
+
+type slug_TranscodeV1 struct {
+	F1 uint16
+	F2 []*slug_TranscodeItemV1
+	F3 [2]uint8
+	F4 uint8
+}
+
+type slug_TranscodeV2 struct {
+	F1 uint16
+	F2 []slug_TranscodeItemV2
+	F3 [4]uint8
+	F5 []uint8
+}
+
+func TestTranscode(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+
+	src := &slug_TranscodeV1{
+		F1: 1,
+		F2: []*slug_TranscodeItemV1{{A: 2}, {A: 3}},
+		F3: [2]uint8{4, 5},
+		F4: 6,
+	}
+	expected := &slug_TranscodeV2{
+		F1: 1,
+		F2: []slug_TranscodeItemV2{{A: 2}, {A: 3}},
+		F3: [4]uint8{4, 5, 0, 0},
+	}
+
+	res, err := dynssz.Transcode(src, reflect.TypeOf(expected))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("unexpected result: %+v, wanted %+v", res, expected)
+	}
+
+	// converting back drops the added fields and fails if the shorter vector would lose data
+	_, err = dynssz.Transcode(&slug_TranscodeV2{F3: [4]uint8{1, 2, 3, 4}}, reflect.TypeOf(slug_TranscodeV1{}))
+	if err == nil {
+		t.Errorf("expected error for too short vector")
+	}
+
+	res, err = dynssz.Transcode(expected, reflect.TypeOf(slug_TranscodeV1{}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(res, slug_TranscodeV1{F1: 1, F2: src.F2, F3: src.F3}) {
+		t.Errorf("unexpected result: %+v", res)
+	}
+
+	// integer overflows are reported
+	_, err = dynssz.Transcode(&slug_TranscodeItemV2{A: 1 << 40}, reflect.TypeOf(slug_TranscodeItemV1{}))
+	if err == nil {
+		t.Errorf("expected error for integer overflow")
+	}
+}
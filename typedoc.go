@@ -0,0 +1,139 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// WriteTypeDocs writes a human-readable markdown documentation of the SSZ wire format of the given types to w.
+// The documentation is generated with the spec values of this DynSsz instance applied, so the same types can be
+// documented for different presets. For each struct type, a table with the fields, their byte offsets within the
+// fixed part of the encoding, their sizes and the resolved size hints is written. Nested struct types are documented
+// in separate sections.
+// The 'types' parameter accepts either instances or reflect.Type values of the types to document.
+func (d *DynSsz) WriteTypeDocs(w io.Writer, types ...any) error {
+	documented := map[reflect.Type]bool{}
+	queue := []reflect.Type{}
+
+	for _, t := range types {
+		targetType, ok := t.(reflect.Type)
+		if !ok {
+			targetType = reflect.TypeOf(t)
+		}
+		queue = append(queue, targetType)
+	}
+
+	for len(queue) > 0 {
+		targetType := queue[0]
+		queue = queue[1:]
+
+		for targetType.Kind() == reflect.Ptr {
+			targetType = targetType.Elem()
+		}
+		if documented[targetType] {
+			continue
+		}
+		documented[targetType] = true
+
+		nestedTypes, err := d.writeTypeDoc(w, targetType)
+		if err != nil {
+			return err
+		}
+		queue = append(queue, nestedTypes...)
+	}
+
+	return nil
+}
+
+// writeTypeDoc writes the documentation section for a single type and returns the nested struct types that are referenced by it.
+func (d *DynSsz) writeTypeDoc(w io.Writer, targetType reflect.Type) ([]reflect.Type, error) {
+	size, _, err := d.getSszSize(targetType, []sszSizeHint{})
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(w, "## %v\n\n", getTypeDocName(targetType))
+	if size < 0 {
+		fmt.Fprintf(w, "Size: dynamic\n\n")
+	} else {
+		fmt.Fprintf(w, "Size: %v bytes (fixed)\n\n", size)
+	}
+
+	if targetType.Kind() != reflect.Struct {
+		return getNestedStructTypes(targetType), nil
+	}
+
+	nestedTypes := []reflect.Type{}
+	fmt.Fprintf(w, "| Field | Type | Offset | Size | Size Hints |\n")
+	fmt.Fprintf(w, "|-------|------|--------|------|------------|\n")
+
+	offset := 0
+	for i := 0; i < targetType.NumField(); i++ {
+		field := d.getStructField(targetType, i)
+
+		fieldSize, _, sizeHints, err := d.getSszFieldSize(&field)
+		if err != nil {
+			return nil, err
+		}
+
+		sizeStr := ""
+		if fieldSize < 0 {
+			sizeStr = "4 (offset to dynamic data)"
+			fieldSize = 4
+		} else {
+			sizeStr = fmt.Sprintf("%v", fieldSize)
+		}
+
+		fmt.Fprintf(w, "| %v | %v | %v | %v | %v |\n", field.Name, getTypeDocName(field.Type), offset, sizeStr, getSizeHintsDoc(sizeHints))
+
+		offset += fieldSize
+		nestedTypes = append(nestedTypes, getNestedStructTypes(field.Type)...)
+	}
+	fmt.Fprintf(w, "\n")
+
+	return nestedTypes, nil
+}
+
+// getTypeDocName returns a readable name for the given type, falling back to the type literal for unnamed types.
+func getTypeDocName(targetType reflect.Type) string {
+	if targetType.Name() != "" {
+		return targetType.Name()
+	}
+	return strings.ReplaceAll(targetType.String(), "|", "\\|")
+}
+
+// getSizeHintsDoc formats size hints for the documentation tables.
+func getSizeHintsDoc(sizeHints []sszSizeHint) string {
+	if len(sizeHints) == 0 {
+		return "-"
+	}
+
+	hints := make([]string, len(sizeHints))
+	for i, hint := range sizeHints {
+		switch {
+		case hint.dynamic:
+			hints[i] = "?"
+		case hint.specval:
+			hints[i] = fmt.Sprintf("%v (spec)", hint.size)
+		default:
+			hints[i] = fmt.Sprintf("%v", hint.size)
+		}
+	}
+	return strings.Join(hints, ", ")
+}
+
+// getNestedStructTypes returns the struct type referenced by the given type, if any.
+func getNestedStructTypes(targetType reflect.Type) []reflect.Type {
+	for targetType.Kind() == reflect.Ptr || targetType.Kind() == reflect.Array || targetType.Kind() == reflect.Slice {
+		targetType = targetType.Elem()
+	}
+	if targetType.Kind() == reflect.Struct && targetType.Name() != "" {
+		return []reflect.Type{targetType}
+	}
+	return nil
+}
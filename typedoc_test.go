@@ -0,0 +1,38 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_DocStruct1 struct {
+	F1 uint16
+	F2 []slug_StaticStruct1 `ssz-size:"4" dynssz-size:"MAX_ITEMS"`
+	F3 []uint8
+}
+
+func TestWriteTypeDocs(t *testing.T) {
+	dynssz := NewDynSsz(map[string]any{"MAX_ITEMS": uint64(2)})
+
+	doc := strings.Builder{}
+	if err := dynssz.WriteTypeDocs(&doc, slug_DocStruct1{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, expected := range []string{
+		"## slug_DocStruct1\n\nSize: dynamic\n",
+		"| F1 | uint16 | 0 | 2 | - |",
+		"| F2 | []dynssz_test.slug_StaticStruct1 | 2 | 8 | 2 (spec) |",
+		"| F3 | []uint8 | 10 | 4 (offset to dynamic data) | - |",
+		"## slug_StaticStruct1\n\nSize: 4 bytes (fixed)\n",
+	} {
+		if !strings.Contains(doc.String(), expected) {
+			t.Errorf("missing %q in documentation:\n%v", expected, doc.String())
+		}
+	}
+}
@@ -0,0 +1,325 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// WriteTypeScriptTypes writes TypeScript type definitions for the given types to w, using the type classes of the
+// @chainsafe/ssz library. WriteRustTypes writes the same definitions as Rust structs for the ethereum_ssz derive
+// macros and the ssz_types list types.
+// Like WriteTypeDocs, the definitions are generated with the spec values of this DynSsz instance applied, so vector
+// sizes and list limits are fully resolved. List limits are taken from the 'ssz-max' and 'dynssz-max' tags, lists
+// without limit are reported as error. Nested struct types are defined before the types referencing them.
+// The 'types' parameter accepts either instances or reflect.Type values of the types to export.
+func (d *DynSsz) WriteTypeScriptTypes(w io.Writer, types ...any) error {
+	builder, err := d.buildTypeStubs(types)
+	if err != nil {
+		return err
+	}
+
+	imports := map[string]bool{"ContainerType": true}
+	body := strings.Builder{}
+	for _, container := range builder.containers {
+		fmt.Fprintf(&body, "\nexport const %v = new ContainerType(\n  {\n", container.name)
+		for _, field := range container.fields {
+			fmt.Fprintf(&body, "    %v: %v,\n", field.name, field.stub.typeScript(imports))
+		}
+		fmt.Fprintf(&body, "  },\n  {typeName: %q}\n);\n", container.name)
+	}
+
+	importNames := make([]string, 0, len(imports))
+	for name := range imports {
+		importNames = append(importNames, name)
+	}
+	sort.Strings(importNames)
+
+	fmt.Fprintf(w, "// Generated by dynssz, do not edit.\n")
+	fmt.Fprintf(w, "import {%v} from \"@chainsafe/ssz\";\n", strings.Join(importNames, ", "))
+	_, err = io.WriteString(w, body.String())
+	return err
+}
+
+// WriteRustTypes writes Rust type definitions for the given types to w, see WriteTypeScriptTypes for details.
+// Vector sizes and list limits are written as typenum::U<N> aliases, which require the "const-generics" feature of
+// the typenum crate.
+func (d *DynSsz) WriteRustTypes(w io.Writer, types ...any) error {
+	builder, err := d.buildTypeStubs(types)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "// Generated by dynssz, do not edit.\n")
+	fmt.Fprintf(w, "use ssz_derive::{Decode, Encode};\n")
+	fmt.Fprintf(w, "use ssz_types::{typenum, FixedVector, VariableList};\n")
+	for _, container := range builder.containers {
+		fmt.Fprintf(w, "\n#[derive(Debug, Clone, PartialEq, Encode, Decode)]\npub struct %v {\n", container.name)
+		for _, field := range container.fields {
+			fmt.Fprintf(w, "    pub %v: %v,\n", getRustFieldName(field.name), field.stub.rust())
+		}
+		fmt.Fprintf(w, "}\n")
+	}
+
+	return nil
+}
+
+type typeStubKind int
+
+const (
+	typeStubBool typeStubKind = iota
+	typeStubUint
+	typeStubVector
+	typeStubList
+	typeStubContainer
+)
+
+// typeStub is the language independent description of a type for the type definition exporters.
+type typeStub struct {
+	kind   typeStubKind
+	size   int    // byte size of uints
+	length uint64 // vector length or list limit
+	elem   *typeStub
+	name   string // name of the container type
+}
+
+type typeStubField struct {
+	name string
+	stub *typeStub
+}
+
+type typeStubDefinition struct {
+	name   string
+	fields []typeStubField
+}
+
+// typeStubBuilder collects the container types of the exported types, nested types first.
+type typeStubBuilder struct {
+	dynssz     *DynSsz
+	containers []*typeStubDefinition
+	built      map[reflect.Type]bool
+}
+
+func (d *DynSsz) buildTypeStubs(types []any) (*typeStubBuilder, error) {
+	builder := &typeStubBuilder{
+		dynssz: d,
+		built:  map[reflect.Type]bool{},
+	}
+
+	for _, t := range types {
+		targetType, ok := t.(reflect.Type)
+		if !ok {
+			targetType = reflect.TypeOf(t)
+		}
+		for targetType.Kind() == reflect.Ptr {
+			targetType = targetType.Elem()
+		}
+		if targetType.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("type %v is not a container, only struct types can be exported", targetType)
+		}
+
+		if _, err := builder.buildType(targetType, []sszSizeHint{}, []sszMaxHint{}); err != nil {
+			return nil, err
+		}
+	}
+
+	return builder, nil
+}
+
+// buildType returns the type stub of the given type, and collects the containers referenced by it.
+func (b *typeStubBuilder) buildType(targetType reflect.Type, sizeHints []sszSizeHint, maxHints []sszMaxHint) (*typeStub, error) {
+	if targetType.Kind() == reflect.Ptr {
+		targetType = targetType.Elem()
+	}
+
+	// resolve the size first, so invalid and recursive types are reported with their type path
+	if _, _, err := b.dynssz.getSszSize(targetType, sizeHints); err != nil {
+		return nil, err
+	}
+
+	childSizeHints := []sszSizeHint{}
+	if len(sizeHints) > 1 {
+		childSizeHints = sizeHints[1:]
+	}
+	childMaxHints := []sszMaxHint{}
+	if len(maxHints) > 1 {
+		childMaxHints = maxHints[1:]
+	}
+
+	switch targetType.Kind() {
+	case reflect.Struct:
+		if err := b.buildContainer(targetType); err != nil {
+			return nil, err
+		}
+		return &typeStub{kind: typeStubContainer, name: targetType.Name()}, nil
+	case reflect.Array, reflect.Slice:
+		stub := &typeStub{kind: typeStubVector}
+		if targetType.Kind() == reflect.Array {
+			stub.length = uint64(targetType.Len())
+		} else if len(sizeHints) > 0 && !sizeHints[0].dynamic {
+			stub.length = sizeHints[0].size
+		} else if len(maxHints) > 0 && maxHints[0].known {
+			stub.kind = typeStubList
+			stub.length = maxHints[0].max
+		} else {
+			return nil, fmt.Errorf("list %v has no ssz-max limit", targetType)
+		}
+
+		elem, err := b.buildType(targetType.Elem(), childSizeHints, childMaxHints)
+		if err != nil {
+			return nil, err
+		}
+		stub.elem = elem
+		return stub, nil
+	case reflect.Bool:
+		return &typeStub{kind: typeStubBool}, nil
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &typeStub{kind: typeStubUint, size: int(targetType.Size())}, nil
+	default:
+		return nil, fmt.Errorf("unhandled reflection kind in type export: %v", targetType.Kind())
+	}
+}
+
+// buildContainer collects the container definition of a struct type after the definitions of its nested types.
+func (b *typeStubBuilder) buildContainer(targetType reflect.Type) error {
+	if b.built[targetType] {
+		return nil
+	}
+	b.built[targetType] = true
+
+	if targetType.Name() == "" {
+		return fmt.Errorf("unnamed struct type %v can not be exported, type definitions require a name", targetType)
+	}
+
+	container := &typeStubDefinition{
+		name: targetType.Name(),
+	}
+	for i := 0; i < targetType.NumField(); i++ {
+		field := b.dynssz.getStructField(targetType, i)
+
+		sizeHints, err := b.dynssz.getSszSizeTag(&field)
+		if err != nil {
+			return err
+		}
+		maxHints, err := b.dynssz.getSszMaxTag(&field)
+		if err != nil {
+			return err
+		}
+
+		stub, err := b.buildType(field.Type, sizeHints, maxHints)
+		if err != nil {
+			return fmt.Errorf("failed exporting field %v.%v: %v", targetType.Name(), field.Name, err)
+		}
+		container.fields = append(container.fields, typeStubField{
+			name: field.Name,
+			stub: stub,
+		})
+	}
+
+	b.containers = append(b.containers, container)
+	return nil
+}
+
+// isBasic reports whether the type is a basic SSZ type (bool or uint).
+func (s *typeStub) isBasic() bool {
+	return s.kind == typeStubBool || s.kind == typeStubUint
+}
+
+// typeScript returns the @chainsafe/ssz type expression of the type and adds the used type classes to 'imports'.
+func (s *typeStub) typeScript(imports map[string]bool) string {
+	var className, expression string
+
+	switch s.kind {
+	case typeStubBool:
+		className = "BooleanType"
+		expression = "new BooleanType()"
+	case typeStubUint:
+		className = "UintNumberType"
+		if s.size == 8 {
+			className = "UintBigintType"
+		}
+		expression = fmt.Sprintf("new %v(%v)", className, s.size)
+	case typeStubVector, typeStubList:
+		isVector := s.kind == typeStubVector
+		switch {
+		case s.elem.kind == typeStubUint && s.elem.size == 1 && isVector:
+			className = "ByteVectorType"
+		case s.elem.kind == typeStubUint && s.elem.size == 1:
+			className = "ByteListType"
+		case s.elem.isBasic() && isVector:
+			className = "VectorBasicType"
+		case s.elem.isBasic():
+			className = "ListBasicType"
+		case isVector:
+			className = "VectorCompositeType"
+		default:
+			className = "ListCompositeType"
+		}
+
+		if className == "ByteVectorType" || className == "ByteListType" {
+			expression = fmt.Sprintf("new %v(%v)", className, s.length)
+		} else {
+			expression = fmt.Sprintf("new %v(%v, %v)", className, s.elem.typeScript(imports), s.length)
+		}
+	case typeStubContainer:
+		return s.name
+	}
+
+	imports[className] = true
+	return expression
+}
+
+// rust returns the Rust type of the type.
+func (s *typeStub) rust() string {
+	switch s.kind {
+	case typeStubBool:
+		return "bool"
+	case typeStubUint:
+		return fmt.Sprintf("u%v", s.size*8)
+	case typeStubVector:
+		return fmt.Sprintf("FixedVector<%v, typenum::U<%v>>", s.elem.rust(), s.length)
+	case typeStubList:
+		return fmt.Sprintf("VariableList<%v, typenum::U<%v>>", s.elem.rust(), s.length)
+	default:
+		return s.name
+	}
+}
+
+// rustKeywords holds the Rust keywords that need to be escaped as raw identifiers when used as field names.
+var rustKeywords = map[string]bool{
+	"as": true, "async": true, "await": true, "break": true, "const": true, "continue": true, "crate": true,
+	"dyn": true, "else": true, "enum": true, "extern": true, "false": true, "fn": true, "for": true, "if": true,
+	"impl": true, "in": true, "let": true, "loop": true, "match": true, "mod": true, "move": true, "mut": true,
+	"pub": true, "ref": true, "return": true, "static": true, "struct": true, "trait": true, "true": true,
+	"type": true, "unsafe": true, "use": true, "where": true, "while": true,
+}
+
+// getRustFieldName converts a Go field name to a snake case Rust field name, keeping acronyms together
+// (e.g. "BLSToExecutionChanges" becomes "bls_to_execution_changes").
+func getRustFieldName(name string) string {
+	runes := []rune(name)
+	builder := strings.Builder{}
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			prevLower := i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]))
+			nextLower := i > 0 && i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsLower(runes[i+1])
+			if prevLower || nextLower {
+				builder.WriteRune('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		builder.WriteRune(r)
+	}
+
+	fieldName := builder.String()
+	if rustKeywords[fieldName] {
+		fieldName = "r#" + fieldName
+	}
+	return fieldName
+}
@@ -0,0 +1,109 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_StubInner struct {
+	Flag  bool
+	Value uint64
+}
+
+type slug_StubOuter struct {
+	BLSChanges []slug_StubInner `ssz-max:"16"`
+	StateRoot  [32]byte
+	ExtraData  []uint8  `ssz-max:"32"`
+	Balances   []uint64 `ssz-max:"8" dynssz-max:"SPEC_MAX"`
+	Counts     []uint16 `ssz-size:"4"`
+	Inner      *slug_StubInner
+	Type       uint32
+}
+
+type slug_StubUnlimited struct {
+	F1 []uint8
+}
+
+func TestWriteTypeScriptTypes(t *testing.T) {
+	dynssz := NewDynSsz(map[string]any{"SPEC_MAX": uint64(1024)})
+
+	out := strings.Builder{}
+	if err := dynssz.WriteTypeScriptTypes(&out, &slug_StubOuter{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `// Generated by dynssz, do not edit.
+import {BooleanType, ByteListType, ByteVectorType, ContainerType, ListBasicType, ListCompositeType, UintBigintType, UintNumberType, VectorBasicType} from "@chainsafe/ssz";
+
+export const slug_StubInner = new ContainerType(
+  {
+    Flag: new BooleanType(),
+    Value: new UintBigintType(8),
+  },
+  {typeName: "slug_StubInner"}
+);
+
+export const slug_StubOuter = new ContainerType(
+  {
+    BLSChanges: new ListCompositeType(slug_StubInner, 16),
+    StateRoot: new ByteVectorType(32),
+    ExtraData: new ByteListType(32),
+    Balances: new ListBasicType(new UintBigintType(8), 1024),
+    Counts: new VectorBasicType(new UintNumberType(2), 4),
+    Inner: slug_StubInner,
+    Type: new UintNumberType(4),
+  },
+  {typeName: "slug_StubOuter"}
+);
+`
+	if out.String() != expected {
+		t.Errorf("unexpected output:\n%v", out.String())
+	}
+
+	if err := dynssz.WriteTypeScriptTypes(&out, &slug_StubUnlimited{}); err == nil {
+		t.Errorf("expected error for list without limit")
+	}
+}
+
+func TestWriteRustTypes(t *testing.T) {
+	dynssz := NewDynSsz(map[string]any{"SPEC_MAX": uint64(1024)})
+
+	out := strings.Builder{}
+	if err := dynssz.WriteRustTypes(&out, &slug_StubOuter{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `// Generated by dynssz, do not edit.
+use ssz_derive::{Decode, Encode};
+use ssz_types::{typenum, FixedVector, VariableList};
+
+#[derive(Debug, Clone, PartialEq, Encode, Decode)]
+pub struct slug_StubInner {
+    pub flag: bool,
+    pub value: u64,
+}
+
+#[derive(Debug, Clone, PartialEq, Encode, Decode)]
+pub struct slug_StubOuter {
+    pub bls_changes: VariableList<slug_StubInner, typenum::U<16>>,
+    pub state_root: FixedVector<u8, typenum::U<32>>,
+    pub extra_data: VariableList<u8, typenum::U<32>>,
+    pub balances: VariableList<u64, typenum::U<1024>>,
+    pub counts: FixedVector<u16, typenum::U<4>>,
+    pub inner: slug_StubInner,
+    pub r#type: u32,
+}
+`
+	if out.String() != expected {
+		t.Errorf("unexpected output:\n%v", out.String())
+	}
+
+	if err := dynssz.WriteRustTypes(&out, uint64(0)); err == nil {
+		t.Errorf("expected error for non-container type")
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+
+	"github.com/pk910/dynamic-ssz/sszutils"
 )
 
 // unmarshalType decodes SSZ-encoded data into a Go value based on reflection. It serves as the
@@ -22,6 +24,7 @@ import (
 //   within the SSZ data. These hints are populated from 'ssz-size' and 'dynssz-size' tag annotations
 //   from parent structures, which are crucial for correctly decoding types like slices and arrays
 //   with dynamic lengths.
+// - guard: The decodeGuard that enforces the DecodeGuards for the current decode, nil if no guards are set.
 // - idt: An indentation level used for debugging or logging purposes, helping track the recursion depth.
 //
 // Returns:
@@ -36,7 +39,7 @@ import (
 // to navigate and decode nested structures, ensuring every part of the targetValue is correctly populated
 // with data from the SSZ input.
 
-func (d *DynSsz) unmarshalType(targetType reflect.Type, targetValue reflect.Value, ssz []byte, sizeHints []sszSizeHint, idt int) (int, error) {
+func (d *DynSsz) unmarshalType(targetType reflect.Type, targetValue reflect.Value, ssz []byte, sizeHints []sszSizeHint, guard *decodeGuard, idt int) (int, error) {
 	consumedBytes := 0
 
 	if targetType.Kind() == reflect.Ptr {
@@ -44,6 +47,9 @@ func (d *DynSsz) unmarshalType(targetType reflect.Type, targetValue reflect.Valu
 		targetType = targetType.Elem()
 		if targetValue.IsNil() {
 			// create new instance of target type for null pointers
+			if err := guard.alloc(targetType, uint64(targetType.Size())); err != nil {
+				return 0, err
+			}
 			newValue := reflect.New(targetType)
 			targetValue.Set(newValue)
 		}
@@ -63,6 +69,8 @@ func (d *DynSsz) unmarshalType(targetType reflect.Type, targetValue reflect.Valu
 	if d.Verbose {
 		fmt.Printf("%stype: %s\t kind: %v\t fastssz: %v (compat: %v/ dynamic: %v)\n", strings.Repeat(" ", idt), targetType.Name(), targetType.Kind(), useFastSsz, fastsszCompat.isUnmarshaler, fastsszCompat.hasDynamicSpecValues)
 	}
+	d.logCodePath("unmarshal", targetType, useFastSsz, fastsszCompat.isUnmarshaler, fastsszCompat)
+	d.countCodePath("unmarshal", targetType, useFastSsz, fastsszCompat.isUnmarshaler, fastsszCompat)
 
 	if useFastSsz {
 		unmarshaller, ok := targetValue.Addr().Interface().(fastsszUnmarshaler)
@@ -80,21 +88,28 @@ func (d *DynSsz) unmarshalType(targetType reflect.Type, targetValue reflect.Valu
 
 	if !useFastSsz {
 		// can't use fastssz, use dynamic unmarshaling
+		if guard != nil && (targetType.Kind() == reflect.Struct || targetType.Kind() == reflect.Array || targetType.Kind() == reflect.Slice) {
+			if err := guard.enter(targetType); err != nil {
+				return 0, err
+			}
+			defer guard.leave()
+		}
+
 		switch targetType.Kind() {
 		case reflect.Struct:
-			consumed, err := d.unmarshalStruct(targetType, targetValue, ssz, idt)
+			consumed, err := d.unmarshalStruct(targetType, targetValue, ssz, guard, idt)
 			if err != nil {
 				return 0, err
 			}
 			consumedBytes = consumed
 		case reflect.Array:
-			consumed, err := d.unmarshalArray(targetType, targetValue, ssz, sizeHints, idt)
+			consumed, err := d.unmarshalArray(targetType, targetValue, ssz, sizeHints, guard, idt)
 			if err != nil {
 				return 0, err
 			}
 			consumedBytes = consumed
 		case reflect.Slice:
-			consumed, err := d.unmarshalSlice(targetType, targetValue, ssz, sizeHints, idt)
+			consumed, err := d.unmarshalSlice(targetType, targetValue, ssz, sizeHints, guard, idt)
 			if err != nil {
 				return 0, err
 			}
@@ -102,19 +117,39 @@ func (d *DynSsz) unmarshalType(targetType reflect.Type, targetValue reflect.Valu
 
 		// primitive types
 		case reflect.Bool:
-			targetValue.SetBool(unmarshalBool(ssz))
+			value, err := sszutils.ReadBool(ssz)
+			if err != nil {
+				return 0, err
+			}
+			targetValue.SetBool(value)
 			consumedBytes = 1
 		case reflect.Uint8:
-			targetValue.SetUint(uint64(unmarshallUint8(ssz)))
+			value, err := sszutils.ReadUint8(ssz)
+			if err != nil {
+				return 0, err
+			}
+			targetValue.SetUint(uint64(value))
 			consumedBytes = 1
 		case reflect.Uint16:
-			targetValue.SetUint(uint64(unmarshallUint16(ssz)))
+			value, err := sszutils.ReadUint16(ssz)
+			if err != nil {
+				return 0, err
+			}
+			targetValue.SetUint(uint64(value))
 			consumedBytes = 2
 		case reflect.Uint32:
-			targetValue.SetUint(uint64(unmarshallUint32(ssz)))
+			value, err := sszutils.ReadUint32(ssz)
+			if err != nil {
+				return 0, err
+			}
+			targetValue.SetUint(uint64(value))
 			consumedBytes = 4
 		case reflect.Uint64:
-			targetValue.SetUint(uint64(unmarshallUint64(ssz)))
+			value, err := sszutils.ReadUint64(ssz)
+			if err != nil {
+				return 0, err
+			}
+			targetValue.SetUint(value)
 			consumedBytes = 8
 
 		default:
@@ -143,7 +178,7 @@ func (d *DynSsz) unmarshalType(targetType reflect.Type, targetValue reflect.Valu
 // The function's core responsibility is to navigate the struct's layout in the SSZ-encoded data, adjusting SSZ slices for each field and
 // invoking unmarshalType with these parameters. This strategy efficiently decouples structural navigation from type-specific decoding logic.
 
-func (d *DynSsz) unmarshalStruct(targetType reflect.Type, targetValue reflect.Value, ssz []byte, idt int) (int, error) {
+func (d *DynSsz) unmarshalStruct(targetType reflect.Type, targetValue reflect.Value, ssz []byte, guard *decodeGuard, idt int) (int, error) {
 	offset := 0
 	dynamicFields := []*reflect.StructField{}
 	dynamicOffsets := []int{}
@@ -151,7 +186,7 @@ func (d *DynSsz) unmarshalStruct(targetType reflect.Type, targetValue reflect.Va
 	sszSize := len(ssz)
 
 	for i := 0; i < targetType.NumField(); i++ {
-		field := targetType.Field(i)
+		field := d.getStructField(targetType, i)
 
 		fieldSize, _, sizeHints, err := d.getSszFieldSize(&field)
 		if err != nil {
@@ -168,9 +203,9 @@ func (d *DynSsz) unmarshalStruct(targetType reflect.Type, targetValue reflect.Va
 
 			fieldSsz := ssz[offset : offset+fieldSize]
 			fieldValue := targetValue.Field(i)
-			consumedBytes, err := d.unmarshalType(field.Type, fieldValue, fieldSsz, sizeHints, idt+2)
+			consumedBytes, err := d.unmarshalType(field.Type, fieldValue, fieldSsz, sizeHints, guard, idt+2)
 			if err != nil {
-				return 0, fmt.Errorf("failed decoding field %v: %v", field.Name, err)
+				return 0, fmt.Errorf("failed decoding field %v: %w", field.Name, err)
 			}
 			if consumedBytes != fieldSize {
 				return 0, fmt.Errorf("struct field did not consume expected ssz range (consumed: %v, expected: %v)", consumedBytes, fieldSize)
@@ -183,13 +218,13 @@ func (d *DynSsz) unmarshalStruct(targetType reflect.Type, targetValue reflect.Va
 			if offset+fieldSize > sszSize {
 				return 0, fmt.Errorf("unexpected end of SSZ. dynamic field %v expects %v bytes (offset), got %v", field.Name, fieldSize, sszSize-offset)
 			}
-			fieldOffset := readOffset(ssz[offset : offset+fieldSize])
+			fieldOffset := readOffsetInt(ssz[offset : offset+fieldSize])
 
 			// fmt.Printf("%sfield %d:\t offset [%v:%v] %v\t %v \t %v\n", strings.Repeat(" ", idt+1), i, offset, offset+fieldSize, fieldSize, field.Name, fieldOffset)
 
 			// store dynamic fields for later
 			dynamicFields = append(dynamicFields, &field)
-			dynamicOffsets = append(dynamicOffsets, int(fieldOffset))
+			dynamicOffsets = append(dynamicOffsets, fieldOffset)
 			dynamicSizeHints = append(dynamicSizeHints, sizeHints)
 		}
 		offset += fieldSize
@@ -221,9 +256,9 @@ func (d *DynSsz) unmarshalStruct(targetType reflect.Type, targetValue reflect.Va
 		}
 
 		fieldValue := targetValue.Field(field.Index[0])
-		consumedBytes, err := d.unmarshalType(field.Type, fieldValue, fieldSsz, dynamicSizeHints[i], idt+2)
+		consumedBytes, err := d.unmarshalType(field.Type, fieldValue, fieldSsz, dynamicSizeHints[i], guard, idt+2)
 		if err != nil {
-			return 0, fmt.Errorf("failed decoding field %v: %v", field.Name, err)
+			return 0, fmt.Errorf("failed decoding field %v: %w", field.Name, err)
 		}
 		if consumedBytes != endOffset-startOffset {
 			return 0, fmt.Errorf("struct field did not consume expected ssz range (consumed: %v, expected: %v)", consumedBytes, endOffset-startOffset)
@@ -257,7 +292,7 @@ func (d *DynSsz) unmarshalStruct(targetType reflect.Type, targetValue reflect.Va
 // invoking unmarshalType with these parameters for decoding. This division of tasks allows unmarshalArray to focus
 // on the structural navigation within the SSZ data, while unmarshalType applies the specific decoding logic for the type of each element.
 
-func (d *DynSsz) unmarshalArray(targetType reflect.Type, targetValue reflect.Value, ssz []byte, sizeHints []sszSizeHint, idt int) (int, error) {
+func (d *DynSsz) unmarshalArray(targetType reflect.Type, targetValue reflect.Value, ssz []byte, sizeHints []sszSizeHint, guard *decodeGuard, idt int) (int, error) {
 	var consumedBytes int
 
 	childSizeHints := []sszSizeHint{}
@@ -272,26 +307,54 @@ func (d *DynSsz) unmarshalArray(targetType reflect.Type, targetValue reflect.Val
 	}
 
 	arrLen := targetType.Len()
-	if fieldType == byteType {
+	if !fieldIsPtr && isByteType(fieldType) {
 		// shortcut for performance: use copy on []byte arrays
-		reflect.Copy(targetValue, reflect.ValueOf(ssz[0:arrLen]))
+		if len(ssz) < arrLen {
+			return 0, fmt.Errorf("unexpected end of SSZ. array expects %v bytes, got %v", arrLen, len(ssz))
+		}
+		copy(targetValue.Bytes(), ssz[0:arrLen])
 		consumedBytes = arrLen
+	} else if !fieldIsPtr && isByteArrayType(fieldType) {
+		// shortcut for performance: copy byte arrays (roots, addresses, ...) directly
+		itemSize := fieldType.Len()
+		if len(ssz) < arrLen*itemSize {
+			return 0, fmt.Errorf("unexpected end of SSZ. array expects %v bytes, got %v", arrLen*itemSize, len(ssz))
+		}
+		for i := 0; i < arrLen; i++ {
+			copy(targetValue.Index(i).Bytes(), ssz[i*itemSize:(i+1)*itemSize])
+		}
+		consumedBytes = arrLen * itemSize
 	} else {
+		size, _, err := d.getSszSize(fieldType, childSizeHints)
+		if err != nil {
+			return 0, err
+		}
+		if size < 0 {
+			// vector with dynamic size items, decode with offsets like a fixed size slice
+			return d.unmarshalDynamicItems(targetType, targetValue, ssz, childSizeHints, guard, idt)
+		}
+
+		var itemPool reflect.Value
+		if fieldIsPtr {
+			if err := guard.alloc(targetType, uint64(arrLen)*uint64(fieldType.Size())); err != nil {
+				return 0, err
+			}
+			itemPool = newPointerItemPool(fieldType, targetValue)
+		}
+
 		offset := 0
 		itemSize := len(ssz) / arrLen
 		for i := 0; i < arrLen; i++ {
 			var itemVal reflect.Value
 			if fieldIsPtr {
-				// fmt.Printf("new array item %v\n", fieldType.Name())
-				itemVal = reflect.New(fieldType).Elem()
-				targetValue.Index(i).Set(itemVal.Addr())
+				itemVal = itemPool.Index(i)
 			} else {
 				itemVal = targetValue.Index(i)
 			}
 
 			itemSsz := ssz[offset : offset+itemSize]
 
-			consumed, err := d.unmarshalType(fieldType, itemVal, itemSsz, childSizeHints, idt+2)
+			consumed, err := d.unmarshalType(fieldType, itemVal, itemSsz, childSizeHints, guard, idt+2)
 			if err != nil {
 				return 0, err
 			}
@@ -332,7 +395,7 @@ func (d *DynSsz) unmarshalArray(targetType reflect.Type, targetValue reflect.Val
 // element, and invoking unmarshalType for the decoding. When faced with elements of dynamic size, it seamlessly transitions to
 // unmarshalDynamicSlice, ensuring all elements, regardless of their size variability, are accurately decoded.
 
-func (d *DynSsz) unmarshalSlice(targetType reflect.Type, targetValue reflect.Value, ssz []byte, sizeHints []sszSizeHint, idt int) (int, error) {
+func (d *DynSsz) unmarshalSlice(targetType reflect.Type, targetValue reflect.Value, ssz []byte, sizeHints []sszSizeHint, guard *decodeGuard, idt int) (int, error) {
 	var consumedBytes int
 
 	childSizeHints := []sszSizeHint{}
@@ -363,37 +426,59 @@ func (d *DynSsz) unmarshalSlice(targetType reflect.Type, targetValue reflect.Val
 		}
 	} else if len(ssz) > 0 {
 		// slice with dynamic size items
-		return d.unmarshalDynamicSlice(targetType, targetValue, ssz, childSizeHints, idt)
+		return d.unmarshalDynamicSlice(targetType, targetValue, ssz, childSizeHints, guard, idt)
+	}
+
+	if err := guard.allocList(targetType, sliceLen); err != nil {
+		return 0, err
 	}
 
 	// slice with static size items
 	// fmt.Printf("new slice %v  %v\n", fieldType.Name(), sliceLen)
-	newValue := reflect.MakeSlice(targetType, sliceLen, sliceLen)
+	if d.ZeroCopyDecode && !fieldIsPtr && isByteType(fieldType) {
+		// alias the input buffer, the capacity is limited so appends to the slice can not overwrite the input
+		targetValue.SetBytes(ssz[0:sliceLen:sliceLen])
+		return sliceLen, nil
+	}
+
+	newValue := reuseSlice(targetType, targetValue, sliceLen)
 	targetValue.Set(newValue)
 
-	if fieldType == byteType {
+	if !fieldIsPtr && isByteType(fieldType) {
 		// shortcut for performance: use copy on []byte arrays
-		reflect.Copy(newValue, reflect.ValueOf(ssz[0:sliceLen]))
+		copy(newValue.Bytes(), ssz[0:sliceLen])
 		consumedBytes = sliceLen
+	} else if !fieldIsPtr && isByteArrayType(fieldType) {
+		// shortcut for performance: copy byte arrays (roots, addresses, ...) directly
+		for i := 0; i < sliceLen; i++ {
+			copy(newValue.Index(i).Bytes(), ssz[i*size:(i+1)*size])
+		}
+		consumedBytes = sliceLen * size
 	} else {
 		offset := 0
 		if sliceLen > 0 {
 			itemSize := sszLen / sliceLen
 
+			var itemPool reflect.Value
+			if fieldIsPtr {
+				if err := guard.alloc(targetType, uint64(sliceLen)*uint64(fieldType.Size())); err != nil {
+					return 0, err
+				}
+				itemPool = newPointerItemPool(fieldType, newValue)
+			}
+
 			// decode slice items
 			for i := 0; i < sliceLen; i++ {
 				var itemVal reflect.Value
 				if fieldIsPtr {
-					// fmt.Printf("new slice item %v\n", fieldType.Name())
-					itemVal = reflect.New(fieldType).Elem()
-					newValue.Index(i).Set(itemVal.Addr())
+					itemVal = itemPool.Index(i)
 				} else {
 					itemVal = newValue.Index(i)
 				}
 
 				itemSsz := ssz[offset : offset+itemSize]
 
-				consumed, err := d.unmarshalType(fieldType, itemVal, itemSsz, childSizeHints, idt+2)
+				consumed, err := d.unmarshalType(fieldType, itemVal, itemSsz, childSizeHints, guard, idt+2)
 				if err != nil {
 					return 0, err
 				}
@@ -438,16 +523,44 @@ func (d *DynSsz) unmarshalSlice(targetType reflect.Type, targetValue reflect.Val
 // within a dynamic slice. This method efficiently handles the complexity of variable-sized elements, ensuring the integrity and
 // intended structure of the decoded data are maintained.
 
-func (d *DynSsz) unmarshalDynamicSlice(targetType reflect.Type, targetValue reflect.Value, ssz []byte, sizeHints []sszSizeHint, idt int) (int, error) {
+func (d *DynSsz) unmarshalDynamicSlice(targetType reflect.Type, targetValue reflect.Value, ssz []byte, sizeHints []sszSizeHint, guard *decodeGuard, idt int) (int, error) {
 	// derive number of items from first item offset
-	firstOffset := readOffset(ssz[0:4])
-	sliceLen := int(firstOffset / 4)
-
-	// read all item offsets
-	sliceOffsets := make([]int, sliceLen)
-	sliceOffsets[0] = int(firstOffset)
-	for i := 1; i < sliceLen; i++ {
-		sliceOffsets[i] = int(readOffset(ssz[i*4 : (i+1)*4]))
+	if len(ssz) < 4 {
+		return 0, fmt.Errorf("unexpected end of SSZ. dynamic slice expects at least 4 bytes (offset), got %v", len(ssz))
+	}
+	// check the first offset before allocating, so malformed data can not trigger huge allocations
+	firstOffset := readOffsetInt(ssz[0:4])
+	if firstOffset > len(ssz) {
+		return 0, fmt.Errorf("unexpected end of SSZ. dynamic list expects %v bytes (offsets), got %v", firstOffset, len(ssz))
+	}
+	sliceLen := firstOffset / 4
+	if err := guard.allocList(targetType, sliceLen); err != nil {
+		return 0, err
+	}
+
+	// fmt.Printf("new dynamic slice %v  %v\n", targetType.Elem().Name(), sliceLen)
+	newValue := reuseSlice(targetType, targetValue, sliceLen)
+	targetValue.Set(newValue)
+
+	return d.unmarshalDynamicItems(targetType, newValue, ssz, sizeHints, guard, idt)
+}
+
+// unmarshalDynamicItems decodes the dynamic size items of a slice or array from the SSZ-encoded data, using the offset
+// table at the start of the data. The number of items to decode is given by the length of targetValue, which must match
+// the number of offsets in the table.
+func (d *DynSsz) unmarshalDynamicItems(targetType reflect.Type, targetValue reflect.Value, ssz []byte, sizeHints []sszSizeHint, guard *decodeGuard, idt int) (int, error) {
+	itemCount := targetValue.Len()
+	sszLen := len(ssz)
+	if sszLen < 4*itemCount {
+		return 0, fmt.Errorf("unexpected end of SSZ. dynamic list expects %v bytes (offsets), got %v", 4*itemCount, sszLen)
+	}
+	if itemCount == 0 {
+		return 0, nil
+	}
+
+	firstOffset := readOffsetInt(ssz[0:4])
+	if firstOffset != 4*itemCount {
+		return 0, ErrOffset
 	}
 
 	fieldType := targetType.Elem()
@@ -456,50 +569,64 @@ func (d *DynSsz) unmarshalDynamicSlice(targetType reflect.Type, targetValue refl
 		fieldType = fieldType.Elem()
 	}
 
-	// fmt.Printf("new dynamic slice %v  %v\n", fieldType.Name(), sliceLen)
-	newValue := reflect.MakeSlice(targetType, sliceLen, sliceLen)
-	targetValue.Set(newValue)
-
-	offset := int(firstOffset)
-	sszLen := len(ssz)
-	if sliceLen > 0 {
-		// decode slice items
-		for i := 0; i < sliceLen; i++ {
-			var itemVal reflect.Value
-			if fieldIsPtr {
-				// fmt.Printf("new slice item %v\n", fieldType.Name())
-				itemVal = reflect.New(fieldType).Elem()
-				newValue.Index(i).Set(itemVal.Addr())
-			} else {
-				itemVal = newValue.Index(i)
-			}
-
-			startOffset := sliceOffsets[i]
-			endOffset := 0
-			if i == sliceLen-1 {
-				endOffset = sszLen
-			} else {
-				endOffset = sliceOffsets[i+1]
-			}
-			itemSize := endOffset - startOffset
-			if itemSize < 0 || endOffset > sszLen {
-				return 0, ErrOffset
-			}
+	var itemPool reflect.Value
+	if fieldIsPtr {
+		if err := guard.alloc(targetType, uint64(itemCount)*uint64(fieldType.Size())); err != nil {
+			return 0, err
+		}
+		itemPool = newPointerItemPool(fieldType, targetValue)
+	}
 
-			itemSsz := ssz[startOffset:endOffset]
+	offset := firstOffset
+	for i := 0; i < itemCount; i++ {
+		var itemVal reflect.Value
+		if fieldIsPtr {
+			itemVal = itemPool.Index(i)
+		} else {
+			itemVal = targetValue.Index(i)
+		}
 
-			consumed, err := d.unmarshalType(fieldType, itemVal, itemSsz, sizeHints, idt+2)
-			if err != nil {
-				return 0, err
-			}
-			if consumed != itemSize {
-				return 0, fmt.Errorf("dynamic slice item did not consume expected ssz range (consumed: %v, expected: %v)", consumed, itemSize)
-			}
+		startOffset := readOffsetInt(ssz[i*4 : (i+1)*4])
+		endOffset := sszLen
+		if i < itemCount-1 {
+			endOffset = readOffsetInt(ssz[(i+1)*4 : (i+2)*4])
+		}
+		itemSize := endOffset - startOffset
+		if startOffset != offset || itemSize < 0 || endOffset > sszLen {
+			return 0, ErrOffset
+		}
 
-			offset += itemSize
+		consumed, err := d.unmarshalType(fieldType, itemVal, ssz[startOffset:endOffset], sizeHints, guard, idt+2)
+		if err != nil {
+			return 0, err
+		}
+		if consumed != itemSize {
+			return 0, fmt.Errorf("dynamic slice item did not consume expected ssz range (consumed: %v, expected: %v)", consumed, itemSize)
 		}
+
+		offset += itemSize
 	}
 
 	return offset, nil
+}
+
+// reuseSlice returns the slice of targetValue resized to the given length if its capacity suffices, so repeated
+// decodes into the same object reuse the allocated memory. Otherwise a new slice is allocated.
+func reuseSlice(targetType reflect.Type, targetValue reflect.Value, length int) reflect.Value {
+	if !targetValue.IsNil() && targetValue.Cap() >= length {
+		return targetValue.Slice(0, length)
+	}
+	return reflect.MakeSlice(targetType, length, length)
+}
 
+// newPointerItemPool allocates the items of a slice or array of pointers in a single backing slice and points the
+// items of targetValue to them, so decoding a list of pointers does not need a separate allocation for each item.
+// Returns the backing slice, with the item values to be decoded at the same indexes as in targetValue.
+func newPointerItemPool(itemType reflect.Type, targetValue reflect.Value) reflect.Value {
+	itemCount := targetValue.Len()
+	itemPool := reflect.MakeSlice(reflect.SliceOf(itemType), itemCount, itemCount)
+	for i := 0; i < itemCount; i++ {
+		targetValue.Index(i).Set(itemPool.Index(i).Addr())
+	}
+	return itemPool
 }
@@ -5,11 +5,14 @@ package dynssz_test
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"reflect"
 	"testing"
 
 	. "github.com/pk910/dynamic-ssz"
+	"github.com/pk910/dynamic-ssz/sszutils"
 )
 
 var unmarshalTestMatrix = []struct {
@@ -37,6 +40,14 @@ var unmarshalTestMatrix = []struct {
 	{[]uint8{1, 2, 3, 4, 5}, fromHex("0x0102030405")},
 	{[5]uint8{1, 2, 3, 4, 5}, fromHex("0x0102030405")},
 	{[10]uint8{1, 2, 3, 4, 5}, fromHex("0x01020304050000000000")},
+	{[]slug_Byte{1, 2, 3}, fromHex("0x010203")},
+	{[3]slug_Byte{1, 2, 3}, fromHex("0x010203")},
+	{[]slug_Root4{{1, 2, 3, 4}, {5, 6, 7, 8}}, fromHex("0x0102030405060708")},
+	{[2]slug_Root4{{1, 2, 3, 4}, {5, 6, 7, 8}}, fromHex("0x0102030405060708")},
+	{[][20]byte{{1}, {2}}, fromHex("0x01000000000000000000000000000000000000000200000000000000000000000000000000000000")},
+	{[2]slug_DynStruct1{{true, []uint8{4}}, {false, []uint8{}}}, fromHex("0x080000000e0000000105000000040005000000")},
+	{[2]*slug_DynStruct1{{true, []uint8{4}}, {false, []uint8{}}}, fromHex("0x080000000e0000000105000000040005000000")},
+	{[]*slug_DynStruct1{{true, []uint8{4}}, {false, []uint8{}}}, fromHex("0x080000000e0000000105000000040005000000")},
 
 	// complex types
 	{
@@ -83,6 +94,12 @@ var unmarshalTestMatrix = []struct {
 		}{42, []*slug_StaticStruct1{{false, []uint8{0, 0, 0}}, {true, []uint8{4, 8, 4}}, {false, []uint8{0, 0, 0}}}, 43},
 		fromHex("0x2a0000000001040804000000002b"),
 	},
+	{
+		struct {
+			F1 []slug_Root4 `ssz-size:"3,4"`
+		}{[]slug_Root4{{1, 2, 3, 4}, {}, {}}},
+		fromHex("0x010203040000000000000000"),
+	},
 }
 
 func TestUnmarshal(t *testing.T) {
@@ -118,3 +135,172 @@ func TestUnmarshal(t *testing.T) {
 		}
 	}
 }
+
+func TestUnmarshalReaderVerify(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+	dynssz.NoFastSsz = true
+
+	ssz := fromHex("0x01050000000408")
+	expectedHash := sha256.Sum256(ssz)
+
+	obj := slug_DynStruct1{}
+	if err := dynssz.UnmarshalSSZReaderVerify(&obj, bytes.NewReader(ssz), len(ssz), expectedHash); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !obj.F1 || !bytes.Equal(obj.F2, []uint8{4, 8}) {
+		t.Errorf("unexpected decoding result: %v", obj)
+	}
+
+	obj = slug_DynStruct1{}
+	expectedHash[0] ^= 0xff
+	if err := dynssz.UnmarshalSSZReaderVerify(&obj, bytes.NewReader(ssz), len(ssz), expectedHash); err != ErrChecksumMismatch {
+		t.Errorf("expected ErrChecksumMismatch, got %v", err)
+	}
+	if obj.F1 || obj.F2 != nil {
+		t.Errorf("target modified despite checksum mismatch: %v", obj)
+	}
+
+	if err := dynssz.UnmarshalSSZReader(&obj, bytes.NewReader(ssz), len(ssz)+1); err == nil {
+		t.Errorf("expected error for truncated reader")
+	}
+	if err := dynssz.UnmarshalSSZReader(&obj, bytes.NewReader(ssz), -1); err == nil {
+		t.Errorf("expected error for negative size")
+	}
+	if err := dynssz.UnmarshalSSZReaderVerify(&obj, bytes.NewReader(ssz), -1, expectedHash); err == nil {
+		t.Errorf("expected error for negative size")
+	}
+
+	dynssz.DecodeGuards = DecodeGuards{MaxTotalAlloc: 4}
+	if err := dynssz.UnmarshalSSZReader(&obj, bytes.NewReader(ssz), 1<<30); !errors.Is(err, ErrDecodeGuard) {
+		t.Errorf("expected ErrDecodeGuard for size above MaxTotalAlloc, got %v", err)
+	}
+}
+
+type slug_RecursiveStruct struct {
+	F1 uint16
+	F2 []*slug_RecursiveStruct `ssz-size:"?" dynssz-size:"?"`
+}
+
+func TestRecursiveTypeError(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+
+	if _, err := dynssz.MarshalSSZ(&slug_RecursiveStruct{}); err == nil {
+		t.Errorf("expected error for recursive type")
+	}
+	if err := dynssz.UnmarshalSSZ(&slug_RecursiveStruct{}, fromHex("0x010006000000")); err == nil {
+		t.Errorf("expected error for recursive type")
+	}
+}
+
+type slug_EmptyContainer struct{}
+
+type slug_EmptyContainerField struct {
+	F1 uint16
+	F2 slug_EmptyContainer
+}
+
+type slug_ZeroLengthVector struct {
+	F1 uint16
+	F2 [0]uint32
+}
+
+type slug_ZeroLengthVectorTag struct {
+	F1 uint16
+	F2 []uint32 `ssz-size:"0"`
+}
+
+type slug_ZeroLengthVectorSpec struct {
+	F1 uint16
+	F2 []uint32 `ssz-size:"4" dynssz-size:"ZERO_SIZE"`
+}
+
+func TestZeroSizeTypeErrors(t *testing.T) {
+	dynssz := NewDynSsz(map[string]any{
+		"ZERO_SIZE": uint64(0),
+	})
+
+	testMatrix := []any{
+		&slug_EmptyContainer{},
+		&slug_EmptyContainerField{},
+		&slug_ZeroLengthVector{},
+		&slug_ZeroLengthVectorTag{},
+		&slug_ZeroLengthVectorSpec{},
+		&[0]uint8{},
+		&[]slug_EmptyContainer{{}},
+	}
+
+	for idx, test := range testMatrix {
+		if _, err := dynssz.MarshalSSZ(test); err == nil {
+			t.Errorf("test %v: expected marshal error for zero size type", idx)
+		}
+		if _, err := dynssz.SizeSSZ(test); err == nil {
+			t.Errorf("test %v: expected size error for zero size type", idx)
+		}
+		if err := dynssz.UnmarshalSSZ(test, fromHex("0x0100")); err == nil {
+			t.Errorf("test %v: expected unmarshal error for zero size type", idx)
+		}
+	}
+}
+
+func TestUnmarshalShortBasicValue(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+
+	testMatrix := []any{
+		new(bool),
+		new(uint16),
+		new(uint32),
+		new(uint64),
+	}
+
+	for idx, test := range testMatrix {
+		if err := dynssz.UnmarshalSSZ(test, []byte{}); !errors.Is(err, sszutils.ErrShortBuffer) {
+			t.Errorf("test %v: expected ErrShortBuffer, got: %v", idx, err)
+		}
+	}
+}
+
+func TestUnmarshalTruncatedArray(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+
+	testMatrix := []struct {
+		target any
+		ssz    []byte
+	}{
+		{new([32]byte), []byte{1, 2}},
+		{new([2][4]byte), make([]byte, 7)},
+		{new([4]uint64), make([]byte, 31)},
+		{&struct{ F1 [32]byte }{}, []byte{1, 2}},
+	}
+
+	for idx, test := range testMatrix {
+		if err := dynssz.UnmarshalSSZ(test.target, test.ssz); err == nil {
+			t.Errorf("test %v: expected error for truncated input, got none", idx)
+		}
+	}
+}
+
+func TestUnmarshalPointerItems(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+	dynssz.NoFastSsz = true
+
+	obj := struct {
+		F1 [2]*slug_DynStruct1
+		F2 []*slug_StaticStruct1 `ssz-size:"2"`
+	}{}
+	ssz := fromHex("0x0c0000000101020300000000080000000e0000000105000000040005000000")
+	if err := dynssz.UnmarshalSSZ(&obj, ssz); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if obj.F1[0] == nil || obj.F1[1] == nil || obj.F1[0] == obj.F1[1] || obj.F2[0] == obj.F2[1] {
+		t.Fatalf("expected distinct items, got %v", obj)
+	}
+	if !obj.F1[0].F1 || !bytes.Equal(obj.F1[0].F2, []uint8{4}) || obj.F1[1].F1 || len(obj.F1[1].F2) != 0 {
+		t.Errorf("unexpected array items: %v, %v", obj.F1[0], obj.F1[1])
+	}
+
+	// the offset table of a vector must match the vector length
+	arr := [2]*slug_DynStruct1{}
+	if err := dynssz.UnmarshalSSZ(&arr, fromHex("0x0400000001050000000408")); err != ErrOffset {
+		t.Errorf("expected ErrOffset for short offset table, got %v", err)
+	}
+}
@@ -1,113 +1,171 @@
 package dynssz
 
 import (
-	"encoding/binary"
 	"fmt"
-	"time"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pk910/dynamic-ssz/sszutils"
 )
 
 var (
-	ErrOffset                = fmt.Errorf("incorrect offset")
-	ErrSize                  = fmt.Errorf("incorrect size")
-	ErrBytesLength           = fmt.Errorf("bytes array does not have the correct length")
-	ErrVectorLength          = fmt.Errorf("vector does not have the correct length")
-	ErrListTooBig            = fmt.Errorf("list length is higher than max value")
-	ErrEmptyBitlist          = fmt.Errorf("bitlist is empty")
-	ErrInvalidVariableOffset = fmt.Errorf("invalid ssz encoding. first variable element offset indexes into fixed value data")
+	ErrOffset                 = fmt.Errorf("incorrect offset")
+	ErrSize                   = fmt.Errorf("incorrect size")
+	ErrBytesLength            = fmt.Errorf("bytes array does not have the correct length")
+	ErrVectorLength           = fmt.Errorf("vector does not have the correct length")
+	ErrListTooBig             = fmt.Errorf("list length is higher than max value")
+	ErrEmptyBitlist           = fmt.Errorf("bitlist is empty")
+	ErrInvalidVariableOffset  = fmt.Errorf("invalid ssz encoding. first variable element offset indexes into fixed value data")
+	ErrChecksumMismatch       = fmt.Errorf("ssz data does not match expected checksum")
+	ErrConcurrentModification = fmt.Errorf("source object has been modified while being encoded")
+	ErrSizeOverflow           = fmt.Errorf("ssz size exceeds the addressable size of the platform")
+	ErrOffsetOverflow         = fmt.Errorf("ssz offset exceeds the 4 byte offset range")
+	ErrUnresolvedExpression   = fmt.Errorf("expression can not be resolved with the given spec values")
+	ErrSpecMismatch           = fmt.Errorf("ssz data has been encoded with different spec values")
+	ErrDecodeGuard            = fmt.Errorf("ssz data exceeds the decode guards")
 )
 
-// ---- Unmarshal functions ----
-
-// unmarshallUint64 unmarshals a little endian uint64 from the src input
-func unmarshallUint64(src []byte) uint64 {
-	return binary.LittleEndian.Uint64(src)
+// UnknownFieldError is returned if a field that is referenced by name does not exist in the struct type.
+type UnknownFieldError struct {
+	Type  reflect.Type
+	Field string
 }
 
-// unmarshallUint32 unmarshals a little endian uint32 from the src input
-func unmarshallUint32(src []byte) uint32 {
-	return binary.LittleEndian.Uint32(src[:4])
+func (e *UnknownFieldError) Error() string {
+	return fmt.Sprintf("unknown field %v in type %v", e.Field, e.Type)
 }
 
-// unmarshallUint16 unmarshals a little endian uint16 from the src input
-func unmarshallUint16(src []byte) uint16 {
-	return binary.LittleEndian.Uint16(src[:2])
+// TypePathError is returned if the ssz layout of a nested type can not be resolved. Path holds the steps from the
+// outermost type down to the failing type, with container fields as "Type.Field" and list or vector items as "[]" or
+// "[N]", and Err the underlying error.
+type TypePathError struct {
+	Path []string
+	Err  error
 }
 
-// unmarshallUint8 unmarshals a little endian uint8 from the src input
-func unmarshallUint8(src []byte) uint8 {
-	return uint8(src[0])
+func (e *TypePathError) Error() string {
+	return fmt.Sprintf("%v: %v", strings.Join(e.Path, "→"), e.Err)
 }
 
-// unmarshalBool unmarshals a boolean from the src input
-func unmarshalBool(src []byte) bool {
-	if src[0] == 1 {
-		return true
-	}
-	return false
+func (e *TypePathError) Unwrap() error {
+	return e.Err
 }
 
-// unmarshalTime unmarshals a time.Time from the src input
-func unmarshalTime(src []byte) time.Time {
-	return time.Unix(int64(unmarshallUint64(src)), 0).UTC()
+// ExpressionError is returned if a dynamic spec expression of a 'dynssz-size' or 'dynssz-max' tag can not be
+// evaluated. Specs holds the values of the spec values referenced by the expression at the time of the evaluation,
+// with "missing" for spec values that are not set, and Err the underlying error.
+type ExpressionError struct {
+	Expression string
+	Specs      map[string]string
+	Err        error
 }
 
-// ---- Marshal functions ----
+func (e *ExpressionError) Error() string {
+	names := make([]string, 0, len(e.Specs))
+	for name := range e.Specs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	specs := make([]string, len(names))
+	for i, name := range names {
+		specs[i] = fmt.Sprintf("%v=%v", name, e.Specs[name])
+	}
 
-// marshalUint64 marshals a little endian uint64 to dst
-func marshalUint64(dst []byte, i uint64) []byte {
-	buf := make([]byte, 8)
-	binary.LittleEndian.PutUint64(buf, i)
-	dst = append(dst, buf...)
-	return dst
+	if len(specs) == 0 {
+		return fmt.Sprintf("expression %q: %v", e.Expression, e.Err)
+	}
+	return fmt.Sprintf("expression %q (%v): %v", e.Expression, strings.Join(specs, ", "), e.Err)
 }
 
-// marshalUint32 marshals a little endian uint32 to dst
-func marshalUint32(dst []byte, i uint32) []byte {
-	buf := make([]byte, 4)
-	binary.LittleEndian.PutUint32(buf, i)
-	dst = append(dst, buf...)
-	return dst
+func (e *ExpressionError) Unwrap() error {
+	return e.Err
 }
 
-// marshalUint16 marshals a little endian uint16 to dst
-func marshalUint16(dst []byte, i uint16) []byte {
-	buf := make([]byte, 2)
-	binary.LittleEndian.PutUint16(buf, i)
-	dst = append(dst, buf...)
-	return dst
+// DecodeGuardError is returned by the unmarshal functions if the decoded data exceeds one of the DecodeGuards. Guard is
+// the name of the exceeded limit (e.g. "MaxListElems"), Type the type that was being decoded, Limit the configured
+// limit and Value the value that exceeded it.
+type DecodeGuardError struct {
+	Guard string
+	Type  reflect.Type
+	Limit uint64
+	Value uint64
 }
 
-// marshalUint8 marshals a little endian uint8 to dst
-func marshalUint8(dst []byte, i uint8) []byte {
-	dst = append(dst, byte(i))
-	return dst
+func (e *DecodeGuardError) Error() string {
+	return fmt.Sprintf("%v: %v exceeded while decoding %v (%v > %v)", ErrDecodeGuard, e.Guard, e.Type, e.Value, e.Limit)
 }
 
-// marshalBool marshals a boolean to dst
-func marshalBool(dst []byte, b bool) []byte {
-	if b {
-		dst = append(dst, 1)
-	} else {
-		dst = append(dst, 0)
-	}
-	return dst
+func (e *DecodeGuardError) Unwrap() error {
+	return ErrDecodeGuard
 }
 
-// marshalTime marshals a time to dst
-func marshalTime(dst []byte, t time.Time) []byte {
-	return marshalUint64(dst, uint64(t.Unix()))
+// wrapTypePathError prepends the given step to the path of a TypePathError, or wraps err into a new TypePathError.
+func wrapTypePathError(err error, step string) error {
+	if pathErr, ok := err.(*TypePathError); ok {
+		return &TypePathError{
+			Path: append([]string{step}, pathErr.Path...),
+			Err:  pathErr.Err,
+		}
+	}
+	return &TypePathError{
+		Path: []string{step},
+		Err:  err,
+	}
 }
 
 // ---- offset functions ----
 
+// maxOffset is the highest offset that can be encoded in a 4 byte ssz offset.
+const maxOffset = math.MaxUint32
+
 // WriteOffset writes an offset to dst
 func writeOffset(dst []byte, i int) []byte {
-	return marshalUint32(dst, uint32(i))
+	return sszutils.AppendUint32(dst, uint32(i))
 }
 
 // ReadOffset reads an offset from buf
 func readOffset(buf []byte) uint64 {
-	return uint64(binary.LittleEndian.Uint32(buf))
+	offset, err := sszutils.ReadUint32(buf)
+	if err != nil {
+		// short buffers yield an offset beyond any ssz buffer, which fails the offset checks of the callers
+		return math.MaxUint64
+	}
+	return uint64(offset)
+}
+
+// readOffsetInt reads an offset from buf as int. On 32-bit platforms, offsets beyond the int range are clamped to
+// maxInt, which is out of range for any addressable ssz buffer and fails the offset checks of the callers.
+func readOffsetInt(buf []byte) int {
+	offset := readOffset(buf)
+	if offset > uint64(maxInt) {
+		return maxInt
+	}
+	return int(offset)
+}
+
+// ---- size functions ----
+
+// maxInt is the highest int value of the platform (2^31-1 on 32-bit platforms).
+const maxInt = int(^uint(0) >> 1)
+
+// addSize adds two ssz sizes, returning ErrSizeOverflow if the result exceeds the int range of the platform.
+func addSize(a, b int) (int, error) {
+	if a > maxInt-b {
+		return 0, ErrSizeOverflow
+	}
+	return a + b, nil
+}
+
+// mulSize multiplies an ssz size by an item count, returning ErrSizeOverflow if the result exceeds the int range of
+// the platform.
+func mulSize(size int, count uint64) (int, error) {
+	if count > 0 && uint64(size) > uint64(maxInt)/count {
+		return 0, ErrSizeOverflow
+	}
+	return size * int(count), nil
 }
 
 // DivideInt divides the int fully
@@ -12,6 +12,10 @@ type slug_StaticStruct1 struct {
 	F2 []uint8 `ssz-size:"3"`
 }
 
+type slug_Byte uint8
+
+type slug_Root4 [4]byte
+
 // FromHex returns the bytes represented by the hexadecimal string s.
 // s may be prefixed with "0x".
 func fromHex(s string) []byte {
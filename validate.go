@@ -0,0 +1,301 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Validator is the interface implemented by types that can validate their own protocol level invariants.
+// ValidateSSZ is called by DynSsz.ValidateSSZ after all fields of the value have been validated.
+type Validator interface {
+	ValidateSSZ() error
+}
+
+var validatorType = reflect.TypeOf((*Validator)(nil)).Elem()
+
+// sszValidationRules holds the parsed rules of a 'ssz-validate' tag annotation.
+type sszValidationRules struct {
+	nonzero  bool
+	hasRange bool
+	rangeMin uint64
+	rangeMax uint64
+}
+
+// cachedValidation holds the parsed validation rules for the fields of a struct type.
+type cachedValidation struct {
+	needsValidation bool
+	fieldRules      []*sszValidationRules
+}
+
+// ValidateSSZ validates the given value against the 'ssz-validate' tag annotations of its fields and calls the
+// ValidateSSZ method of all nested values implementing the Validator interface.
+// Supported tag rules are:
+//   - range=min:max: the unsigned integer value must be within [min, max]. Bounds can be given as decimal numbers
+//     or powers like 2^53.
+//   - nonzero: the value must not be the zero value (zero numbers, all-zero byte arrays and empty lists are rejected).
+//
+// Multiple rules are separated by commas, e.g. `ssz-validate:"range=0:2^53,nonzero"`.
+// Returns the first validation error, prefixed with the path to the affected field.
+func (d *DynSsz) ValidateSSZ(source any) error {
+	sourceValue := reflect.ValueOf(source)
+	if sourceValue.Kind() != reflect.Ptr {
+		// copy to an addressable value, so Validator implementations with pointer receivers can be called
+		sourceValPtr := reflect.New(sourceValue.Type())
+		sourceValPtr.Elem().Set(sourceValue)
+		sourceValue = sourceValPtr
+	}
+
+	return d.validateValue(sourceValue.Type(), sourceValue, "")
+}
+
+// validateValue validates a value and all values nested in it.
+func (d *DynSsz) validateValue(sourceType reflect.Type, sourceValue reflect.Value, path string) error {
+	needsValidation, err := d.needsValidation(sourceType, map[reflect.Type]bool{})
+	if err != nil {
+		return err
+	}
+	if !needsValidation {
+		return nil
+	}
+
+	if sourceType.Kind() == reflect.Ptr {
+		if sourceValue.IsNil() {
+			return nil
+		}
+		sourceType = sourceType.Elem()
+		sourceValue = sourceValue.Elem()
+	}
+
+	switch sourceType.Kind() {
+	case reflect.Struct:
+		validation, err := d.getValidation(sourceType)
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i < sourceType.NumField(); i++ {
+			field := sourceType.Field(i)
+			fieldValue := sourceValue.Field(i)
+			fieldPath := field.Name
+			if path != "" {
+				fieldPath = path + "." + field.Name
+			}
+
+			if rules := validation.fieldRules[i]; rules != nil {
+				if err := rules.validate(fieldValue); err != nil {
+					return fmt.Errorf("validation failed for field %v: %v", fieldPath, err)
+				}
+			}
+
+			if err := d.validateValue(field.Type, fieldValue, fieldPath); err != nil {
+				return err
+			}
+		}
+	case reflect.Array, reflect.Slice:
+		for i := 0; i < sourceValue.Len(); i++ {
+			if err := d.validateValue(sourceType.Elem(), sourceValue.Index(i), fmt.Sprintf("%v[%v]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if reflect.PointerTo(sourceType).Implements(validatorType) && sourceValue.CanAddr() {
+		if err := sourceValue.Addr().Interface().(Validator).ValidateSSZ(); err != nil {
+			if path == "" {
+				return fmt.Errorf("validation failed: %v", err)
+			}
+			return fmt.Errorf("validation failed for field %v: %v", path, err)
+		}
+	}
+
+	return nil
+}
+
+// needsValidation checks if the given type or any type nested in it has validation rules or implements the Validator interface.
+func (d *DynSsz) needsValidation(targetType reflect.Type, visited map[reflect.Type]bool) (bool, error) {
+	for targetType.Kind() == reflect.Ptr {
+		targetType = targetType.Elem()
+	}
+	if visited[targetType] {
+		return false, nil
+	}
+	visited[targetType] = true
+
+	if reflect.PointerTo(targetType).Implements(validatorType) {
+		return true, nil
+	}
+
+	switch targetType.Kind() {
+	case reflect.Struct:
+		d.validationMutex.RLock()
+		cached := d.validationCache[targetType]
+		d.validationMutex.RUnlock()
+		if cached != nil {
+			return cached.needsValidation, nil
+		}
+		return d.fieldsNeedValidation(targetType, visited)
+	case reflect.Array, reflect.Slice:
+		return d.needsValidation(targetType.Elem(), visited)
+	default:
+		return false, nil
+	}
+}
+
+// getValidation returns the parsed validation rules for the fields of a struct type.
+func (d *DynSsz) getValidation(targetType reflect.Type) (*cachedValidation, error) {
+	d.validationMutex.RLock()
+	cached := d.validationCache[targetType]
+	d.validationMutex.RUnlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	cached = &cachedValidation{
+		fieldRules: make([]*sszValidationRules, targetType.NumField()),
+	}
+	for i := 0; i < targetType.NumField(); i++ {
+		field := targetType.Field(i)
+
+		if validateTag, hasValidateTag := field.Tag.Lookup("ssz-validate"); hasValidateTag {
+			rules, err := parseValidationRules(validateTag)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing ssz-validate tag for '%v' field: %v", field.Name, err)
+			}
+			cached.fieldRules[i] = rules
+			cached.needsValidation = true
+		}
+	}
+	if !cached.needsValidation {
+		needsValidation, err := d.fieldsNeedValidation(targetType, map[reflect.Type]bool{targetType: true})
+		if err != nil {
+			return nil, err
+		}
+		cached.needsValidation = needsValidation
+	}
+
+	d.validationMutex.Lock()
+	d.validationCache[targetType] = cached
+	d.validationMutex.Unlock()
+
+	return cached, nil
+}
+
+// fieldsNeedValidation checks if any field of the given struct type has validation rules or a type that needs
+// validation. The visited set is shared across the whole walk, so mutually recursive types are only checked once.
+// The result is not cached, as it is incomplete for types that are still being checked further up the walk.
+func (d *DynSsz) fieldsNeedValidation(targetType reflect.Type, visited map[reflect.Type]bool) (bool, error) {
+	for i := 0; i < targetType.NumField(); i++ {
+		field := targetType.Field(i)
+		if _, hasValidateTag := field.Tag.Lookup("ssz-validate"); hasValidateTag {
+			return true, nil
+		}
+
+		needsValidation, err := d.needsValidation(field.Type, visited)
+		if err != nil {
+			return false, err
+		}
+		if needsValidation {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// parseValidationRules parses the rules of a 'ssz-validate' tag annotation.
+func parseValidationRules(tag string) (*sszValidationRules, error) {
+	rules := &sszValidationRules{}
+
+	for _, rule := range strings.Split(tag, ",") {
+		ruleName, ruleArgs, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch ruleName {
+		case "nonzero":
+			rules.nonzero = true
+		case "range":
+			minStr, maxStr, ok := strings.Cut(ruleArgs, ":")
+			if !ok {
+				return nil, fmt.Errorf("invalid range rule '%v', expected range=min:max", rule)
+			}
+			minVal, err := parseValidationNumber(minStr)
+			if err != nil {
+				return nil, err
+			}
+			maxVal, err := parseValidationNumber(maxStr)
+			if err != nil {
+				return nil, err
+			}
+			rules.hasRange = true
+			rules.rangeMin = minVal
+			rules.rangeMax = maxVal
+		default:
+			return nil, fmt.Errorf("unknown validation rule '%v'", rule)
+		}
+	}
+
+	return rules, nil
+}
+
+// parseValidationNumber parses a decimal number or a power like 2^53.
+func parseValidationNumber(str string) (uint64, error) {
+	base, exponent, isPower := strings.Cut(str, "^")
+	if !isPower {
+		return strconv.ParseUint(str, 10, 64)
+	}
+
+	baseVal, err := strconv.ParseUint(base, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	exponentVal, err := strconv.ParseUint(exponent, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	result := new(big.Int).Exp(new(big.Int).SetUint64(baseVal), new(big.Int).SetUint64(exponentVal), nil)
+	if !result.IsUint64() {
+		return 0, fmt.Errorf("number %v exceeds uint64 range", str)
+	}
+	return result.Uint64(), nil
+}
+
+// validate checks the given field value against the rules.
+func (r *sszValidationRules) validate(value reflect.Value) error {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			if r.nonzero {
+				return fmt.Errorf("value is nil")
+			}
+			return nil
+		}
+		value = value.Elem()
+	}
+
+	if r.nonzero {
+		isZero := value.IsZero()
+		if value.Kind() == reflect.Slice {
+			isZero = value.Len() == 0
+		}
+		if isZero {
+			return fmt.Errorf("value is zero")
+		}
+	}
+
+	if r.hasRange {
+		switch value.Kind() {
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if value.Uint() < r.rangeMin || value.Uint() > r.rangeMax {
+				return fmt.Errorf("value %v out of range [%v, %v]", value.Uint(), r.rangeMin, r.rangeMax)
+			}
+		default:
+			return fmt.Errorf("range rule not supported for type %v", value.Type())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_ValidateStruct1 struct {
+	F1 uint64   `ssz-validate:"range=1:2^8"`
+	F2 [4]uint8 `ssz-validate:"nonzero"`
+	F3 []*slug_ValidateStruct2
+}
+
+type slug_ValidateStruct2 struct {
+	F1 uint16
+	F2 uint16
+}
+
+func (s *slug_ValidateStruct2) ValidateSSZ() error {
+	if s.F1 > s.F2 {
+		return fmt.Errorf("F1 must not exceed F2")
+	}
+	return nil
+}
+
+type slug_ValidateRecursiveA struct {
+	F1 uint64 `ssz-validate:"nonzero"`
+	F2 *slug_ValidateRecursiveB
+}
+
+type slug_ValidateRecursiveB struct {
+	F1 *slug_ValidateRecursiveA
+}
+
+type slug_ValidateRecursiveC struct {
+	F1 uint64
+	F2 *slug_ValidateRecursiveD
+}
+
+type slug_ValidateRecursiveD struct {
+	F1 *slug_ValidateRecursiveC
+}
+
+func TestValidateSSZ(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+
+	tests := []struct {
+		payload  any
+		expected string
+	}{
+		{slug_ValidateStruct1{1, [4]uint8{1}, nil}, ""},
+		{&slug_ValidateStruct1{256, [4]uint8{1}, []*slug_ValidateStruct2{{1, 2}, nil}}, ""},
+		{slug_ValidateStruct1{0, [4]uint8{1}, nil}, "validation failed for field F1: value 0 out of range [1, 256]"},
+		{slug_ValidateStruct1{257, [4]uint8{1}, nil}, "validation failed for field F1: value 257 out of range [1, 256]"},
+		{slug_ValidateStruct1{1, [4]uint8{}, nil}, "validation failed for field F2: value is zero"},
+		{slug_ValidateStruct1{1, [4]uint8{1}, []*slug_ValidateStruct2{{1, 2}, {3, 2}}}, "validation failed for field F3[1]: F1 must not exceed F2"},
+		{slug_ValidateStruct2{3, 2}, "validation failed: F1 must not exceed F2"},
+		{&slug_ValidateRecursiveD{}, ""},
+		{&slug_ValidateRecursiveC{F2: &slug_ValidateRecursiveD{}}, ""},
+		{&slug_ValidateRecursiveB{}, ""},
+		{&slug_ValidateRecursiveB{F1: &slug_ValidateRecursiveA{F1: 1, F2: &slug_ValidateRecursiveB{}}}, ""},
+		{&slug_ValidateRecursiveB{F1: &slug_ValidateRecursiveA{}}, "validation failed for field F1.F1: value is zero"},
+		{&slug_ValidateRecursiveA{F2: &slug_ValidateRecursiveB{}}, "validation failed for field F1: value is zero"},
+		{struct {
+			F1 uint8 `ssz-validate:"unknown"`
+		}{}, "error parsing ssz-validate tag for 'F1' field: unknown validation rule 'unknown'"},
+	}
+
+	for idx, test := range tests {
+		err := dynssz.ValidateSSZ(test.payload)
+		switch {
+		case test.expected == "" && err != nil:
+			t.Errorf("test %v error: %v", idx, err)
+		case test.expected != "" && (err == nil || err.Error() != test.expected):
+			t.Errorf("test %v failed: got %v, wanted %v", idx, err, test.expected)
+		}
+	}
+}
+
+func TestValidateAfterDecode(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+	dynssz.ValidateAfterDecode = true
+
+	ssz, err := dynssz.MarshalSSZ(&slug_ValidateStruct1{1, [4]uint8{1}, []*slug_ValidateStruct2{{3, 2}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = dynssz.UnmarshalSSZ(&slug_ValidateStruct1{}, ssz)
+	if err == nil || !strings.Contains(err.Error(), "F1 must not exceed F2") {
+		t.Errorf("expected validation error, got %v", err)
+	}
+}
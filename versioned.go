@@ -0,0 +1,90 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// MarshalVersioned serializes the fork specific object held by a versioned wrapper type, like the go-eth2-client
+// spec.VersionedSignedBeaconBlock. Versioned wrappers are structs with a 'Version' field implementing fmt.Stringer and
+// one field per fork, named like the string representation of the version (e.g. Version "deneb" selects the field
+// 'Deneb'). This replaces the per fork switch statements otherwise needed to encode versioned objects.
+// Returns an error if the wrapper has no field for its version, the field is nil or encoding fails.
+func (d *DynSsz) MarshalVersioned(versioned any) ([]byte, error) {
+	field, err := getVersionedField(reflect.ValueOf(versioned))
+	if err != nil {
+		return nil, err
+	}
+
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return nil, fmt.Errorf("versioned object for version %v is nil", getVersionName(reflect.ValueOf(versioned)))
+		}
+		return d.MarshalSSZ(field.Interface())
+	}
+
+	return d.MarshalSSZ(field.Addr().Interface())
+}
+
+// UnmarshalVersioned decodes the given SSZ-encoded data into the fork specific field of a versioned wrapper type, see
+// MarshalVersioned. The 'Version' field of the wrapper must be set before calling UnmarshalVersioned, as the SSZ
+// encoding itself does not contain the version. Nil pointer fields are allocated as needed.
+// Returns an error if the wrapper has no field for its version or decoding fails.
+func (d *DynSsz) UnmarshalVersioned(versioned any, ssz []byte) error {
+	field, err := getVersionedField(reflect.ValueOf(versioned))
+	if err != nil {
+		return err
+	}
+
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		return d.UnmarshalSSZ(field.Interface(), ssz)
+	}
+
+	return d.UnmarshalSSZ(field.Addr().Interface(), ssz)
+}
+
+// getVersionedField returns the field of a versioned wrapper that matches the version of the wrapper.
+func getVersionedField(versionedValue reflect.Value) (reflect.Value, error) {
+	if versionedValue.Kind() != reflect.Ptr || versionedValue.IsNil() || versionedValue.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("versioned object must be a pointer to a struct, got %v", versionedValue.Type())
+	}
+
+	versionName := getVersionName(versionedValue)
+	if versionName == "" {
+		return reflect.Value{}, fmt.Errorf("type %v has no Version field implementing fmt.Stringer", versionedValue.Type().Elem())
+	}
+
+	structValue := versionedValue.Elem()
+	structType := structValue.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.Name != "Version" && strings.EqualFold(field.Name, versionName) {
+			return structValue.Field(i), nil
+		}
+	}
+
+	return reflect.Value{}, fmt.Errorf("type %v has no field for version %v", structType, versionName)
+}
+
+// getVersionName returns the string representation of the 'Version' field of a versioned wrapper, or an empty string
+// if the wrapper has no such field.
+func getVersionName(versionedValue reflect.Value) string {
+	versionField := versionedValue.Elem().FieldByName("Version")
+	if !versionField.IsValid() || !versionField.CanInterface() {
+		return ""
+	}
+
+	version, ok := versionField.Interface().(fmt.Stringer)
+	if !ok {
+		return ""
+	}
+
+	return version.String()
+}
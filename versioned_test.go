@@ -0,0 +1,58 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_DataVersion uint64
+
+func (v slug_DataVersion) String() string {
+	return []string{"unknown", "phase0", "altair"}[v]
+}
+
+type slug_VersionedStruct struct {
+	Version slug_DataVersion
+	Phase0  *slug_StaticStruct1
+	Altair  *slug_DynStruct1
+}
+
+func TestVersioned(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+
+	obj := &slug_VersionedStruct{
+		Version: 2,
+		Altair:  &slug_DynStruct1{F1: true, F2: []uint8{4, 8}},
+	}
+	ssz, err := dynssz.MarshalVersioned(obj)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	if !bytes.Equal(ssz, fromHex("0x01050000000408")) {
+		t.Errorf("unexpected encoding: %x", ssz)
+	}
+
+	decoded := &slug_VersionedStruct{Version: 2}
+	if err := dynssz.UnmarshalVersioned(decoded, ssz); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, obj) {
+		t.Errorf("unexpected decoding result: %+v", decoded)
+	}
+
+	if _, err := dynssz.MarshalVersioned(&slug_VersionedStruct{Version: 1}); err == nil {
+		t.Errorf("expected error for nil versioned object")
+	}
+	if _, err := dynssz.MarshalVersioned(&slug_VersionedStruct{Version: 0}); err == nil {
+		t.Errorf("expected error for unknown version")
+	}
+	if err := dynssz.UnmarshalVersioned(&slug_DynStruct1{}, ssz); err == nil {
+		t.Errorf("expected error for type without Version field")
+	}
+}
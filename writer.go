@@ -0,0 +1,341 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"reflect"
+)
+
+// defaultStreamBufferSize is the output buffer size used for streaming if DynSsz.StreamBufferSize is not set.
+const defaultStreamBufferSize = 4096
+
+// MarshalSSZWriter serializes the given source into its SSZ representation and writes it to w in a single pass.
+// Unlike MarshalSSZ, the encoding of lists and containers with dynamic fields is never buffered as a whole. The offsets
+// of dynamic fields and list items are derived from the pre-calculated value sizes, so the output can be streamed
+// sequentially to non-seekable writers like network connections or compressors. Only static size values (and types
+// handled by fastssz) are encoded into a temporary buffer before being written.
+// The output is buffered with DynSsz.StreamBufferSize. Values that do not fit into the buffer are written together with
+// the buffered data in a single vectored write (writev on network connections) to reduce the number of syscalls.
+// Returns an error if serialization or writing to w fails.
+func (d *DynSsz) MarshalSSZWriter(source any, w io.Writer) error {
+	sourceType := reflect.TypeOf(source)
+	sourceValue := reflect.ValueOf(source)
+
+	if err := d.runMarshalHooks(sourceType, sourceValue); err != nil {
+		return err
+	}
+
+	var hasher hash.Hash
+	if d.DetectConcurrentModification {
+		hasher = sha256.New()
+		w = io.MultiWriter(w, hasher)
+	}
+
+	writer := &sszStreamWriter{
+		dynssz: d,
+		writer: newSszBufferedWriter(w, d.getStreamBufferSize()),
+	}
+
+	if err := writer.marshalType(sourceType, sourceValue, []sszSizeHint{}); err != nil {
+		return err
+	}
+
+	if err := writer.writer.Flush(); err != nil {
+		return err
+	}
+
+	if hasher != nil {
+		return d.checkUnmodified(sourceType, sourceValue, [32]byte(hasher.Sum(nil)))
+	}
+
+	return nil
+}
+
+type sszStreamWriter struct {
+	dynssz  *DynSsz
+	writer  *sszBufferedWriter
+	scratch []byte
+}
+
+// getStreamBufferSize returns the configured output buffer size for streaming.
+func (d *DynSsz) getStreamBufferSize() int {
+	if d.StreamBufferSize > 0 {
+		return d.StreamBufferSize
+	}
+	return defaultStreamBufferSize
+}
+
+// sszBufferedWriter buffers small writes like bufio.Writer, but batches the buffered data and large writes that do
+// not fit into the buffer into a single vectored write.
+type sszBufferedWriter struct {
+	writer io.Writer
+	buf    []byte
+}
+
+func newSszBufferedWriter(w io.Writer, size int) *sszBufferedWriter {
+	return &sszBufferedWriter{
+		writer: w,
+		buf:    make([]byte, 0, size),
+	}
+}
+
+func (b *sszBufferedWriter) Write(p []byte) (int, error) {
+	if len(b.buf)+len(p) <= cap(b.buf) {
+		b.buf = append(b.buf, p...)
+		return len(p), nil
+	}
+
+	buffers := net.Buffers{b.buf, p}
+	_, err := buffers.WriteTo(b.writer)
+	b.buf = b.buf[:0]
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func (b *sszBufferedWriter) WriteByte(c byte) error {
+	if len(b.buf) == cap(b.buf) {
+		if err := b.Flush(); err != nil {
+			return err
+		}
+	}
+
+	b.buf = append(b.buf, c)
+	return nil
+}
+
+// Flush writes the buffered data to the underlying writer.
+func (b *sszBufferedWriter) Flush() error {
+	if len(b.buf) == 0 {
+		return nil
+	}
+
+	_, err := b.writer.Write(b.buf)
+	b.buf = b.buf[:0]
+	return err
+}
+
+// writeStatic encodes a static size value into the scratch buffer and writes it to the output.
+func (s *sszStreamWriter) writeStatic(sourceType reflect.Type, sourceValue reflect.Value, sizeHints []sszSizeHint) error {
+	buf, err := s.dynssz.marshalType(sourceType, sourceValue, s.scratch[:0], sizeHints, 0)
+	if err != nil {
+		return err
+	}
+	s.scratch = buf
+
+	_, err = s.writer.Write(buf)
+	return err
+}
+
+// marshalType writes the SSZ encoding of a value to the output, streaming lists and dynamic containers element by element.
+func (s *sszStreamWriter) marshalType(sourceType reflect.Type, sourceValue reflect.Value, sizeHints []sszSizeHint) error {
+	if sourceType.Kind() == reflect.Ptr {
+		sourceType = sourceType.Elem()
+
+		if sourceValue.IsNil() {
+			sourceValue = reflect.New(sourceType).Elem()
+		} else {
+			sourceValue = sourceValue.Elem()
+		}
+	}
+
+	fastsszCompat, err := s.dynssz.getFastsszCompatibility(sourceType, sizeHints)
+	if err != nil {
+		return fmt.Errorf("failed checking fastssz compatibility: %v", err)
+	}
+	if !s.dynssz.NoFastSsz && fastsszCompat.isMarshaler && !fastsszCompat.hasDynamicSpecValues {
+		// types handled by fastssz can only be encoded as a whole
+		return s.writeStatic(sourceType, sourceValue, sizeHints)
+	}
+
+	switch sourceType.Kind() {
+	case reflect.Struct:
+		size, _, err := s.dynssz.getSszSize(sourceType, sizeHints)
+		if err != nil {
+			return err
+		}
+		if size >= 0 {
+			return s.writeStatic(sourceType, sourceValue, sizeHints)
+		}
+		return s.marshalStruct(sourceType, sourceValue)
+	case reflect.Array, reflect.Slice:
+		return s.marshalList(sourceType, sourceValue, sizeHints)
+	default:
+		return s.writeStatic(sourceType, sourceValue, sizeHints)
+	}
+}
+
+// marshalStruct writes a container with dynamic fields. The fixed part is written first, with the offsets derived from
+// the value sizes of the dynamic fields, followed by the streamed dynamic fields.
+func (s *sszStreamWriter) marshalStruct(sourceType reflect.Type, sourceValue reflect.Value) error {
+	fieldCount := sourceType.NumField()
+	fieldSizeHints := make([][]sszSizeHint, fieldCount)
+	dynamicFields := []int{}
+	dynamicSizes := []int{}
+	fixedSize := 0
+
+	for i := 0; i < fieldCount; i++ {
+		field := s.dynssz.getStructField(sourceType, i)
+
+		fieldSize, _, sizeHints, err := s.dynssz.getSszFieldSize(&field)
+		if err != nil {
+			return err
+		}
+		fieldSizeHints[i] = sizeHints
+
+		if fieldSize < 0 {
+			valueSize, err := s.dynssz.getSszValueSize(field.Type, sourceValue.Field(i), sizeHints)
+			if err != nil {
+				return fmt.Errorf("failed sizing field %v: %v", field.Name, err)
+			}
+
+			dynamicFields = append(dynamicFields, i)
+			dynamicSizes = append(dynamicSizes, valueSize)
+			fixedSize += 4
+		} else {
+			fixedSize += fieldSize
+		}
+	}
+
+	// write fixed part
+	offset := fixedSize
+	dynamicIdx := 0
+	for i := 0; i < fieldCount; i++ {
+		field := s.dynssz.getStructField(sourceType, i)
+
+		if dynamicIdx < len(dynamicFields) && dynamicFields[dynamicIdx] == i {
+			if uint64(offset) > maxOffset {
+				return ErrOffsetOverflow
+			}
+			s.scratch = writeOffset(s.scratch[:0], offset)
+			if _, err := s.writer.Write(s.scratch); err != nil {
+				return err
+			}
+
+			offset += dynamicSizes[dynamicIdx]
+			dynamicIdx++
+			continue
+		}
+
+		if err := s.writeStatic(field.Type, sourceValue.Field(i), fieldSizeHints[i]); err != nil {
+			return fmt.Errorf("failed encoding field %v: %v", field.Name, err)
+		}
+	}
+
+	// stream dynamic fields
+	for _, i := range dynamicFields {
+		field := s.dynssz.getStructField(sourceType, i)
+
+		if err := s.marshalType(field.Type, sourceValue.Field(i), fieldSizeHints[i]); err != nil {
+			return fmt.Errorf("failed encoding field %v: %v", field.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// marshalList writes arrays and slices item by item. Lists with dynamic size items get their offsets written first,
+// derived from the value sizes of all items.
+func (s *sszStreamWriter) marshalList(sourceType reflect.Type, sourceValue reflect.Value, sizeHints []sszSizeHint) error {
+	childSizeHints := []sszSizeHint{}
+	if len(sizeHints) > 1 {
+		childSizeHints = sizeHints[1:]
+	}
+
+	fieldType := sourceType.Elem()
+	fieldIsPtr := fieldType.Kind() == reflect.Ptr
+	if fieldIsPtr {
+		fieldType = fieldType.Elem()
+	}
+
+	itemCount := sourceValue.Len()
+	appendZero := 0
+	if sourceType.Kind() == reflect.Slice && len(sizeHints) > 0 && !sizeHints[0].dynamic {
+		if uint64(itemCount) > sizeHints[0].size {
+			return ErrListTooBig
+		}
+		if uint64(itemCount) < sizeHints[0].size {
+			if s.dynssz.StrictVectorLength || sizeHints[0].raw {
+				return ErrVectorLength
+			}
+			appendZero = int(sizeHints[0].size - uint64(itemCount))
+		}
+	}
+
+	getItem := func(i int) reflect.Value {
+		if i >= itemCount {
+			return reflect.New(fieldType).Elem()
+		}
+		itemVal := sourceValue.Index(i)
+		if fieldIsPtr {
+			if itemVal.IsNil() {
+				return reflect.New(fieldType).Elem()
+			}
+			return itemVal.Elem()
+		}
+		return itemVal
+	}
+
+	if !fieldIsPtr && isByteType(fieldType) {
+		if sourceType.Kind() == reflect.Array && !sourceValue.CanAddr() {
+			// workaround for unaddressable static arrays
+			sourceValPtr := reflect.New(sourceType)
+			sourceValPtr.Elem().Set(sourceValue)
+			sourceValue = sourceValPtr.Elem()
+		}
+		if _, err := s.writer.Write(sourceValue.Bytes()); err != nil {
+			return err
+		}
+		for i := 0; i < appendZero; i++ {
+			if err := s.writer.WriteByte(0); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	isDynamicItem := len(sizeHints) > 1 && sizeHints[1].dynamic
+	if !isDynamicItem {
+		itemSize, _, err := s.dynssz.getSszSize(fieldType, childSizeHints)
+		if err != nil {
+			return err
+		}
+		isDynamicItem = itemSize < 0
+	}
+
+	totalCount := itemCount + appendZero
+	if isDynamicItem {
+		offset := 4 * totalCount
+		for i := 0; i < totalCount; i++ {
+			if uint64(offset) > maxOffset {
+				return ErrOffsetOverflow
+			}
+			s.scratch = writeOffset(s.scratch[:0], offset)
+			if _, err := s.writer.Write(s.scratch); err != nil {
+				return err
+			}
+
+			itemSize, err := s.dynssz.getSszValueSize(fieldType, getItem(i), childSizeHints)
+			if err != nil {
+				return err
+			}
+			offset += itemSize
+		}
+	}
+
+	for i := 0; i < totalCount; i++ {
+		if err := s.marshalType(fieldType, getItem(i), childSizeHints); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
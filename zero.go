@@ -0,0 +1,185 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// IsZero checks if the given value is the SSZ zero value of its type, without encoding it.
+// A value is SSZ-zero if all its basic values (booleans and unsigned integers) are zero and all its lists are empty.
+// Vectors are zero if all their items are zero, so short slices for fixed size vectors (which are zero padded when
+// encoded) and nil pointers are considered zero as well.
+// Returns an error if the value contains types that are not supported by SSZ.
+func (d *DynSsz) IsZero(source any) (bool, error) {
+	nonZeroLeaves, hasListItems, err := d.countNonZeroLeaves(reflect.TypeOf(source), reflect.ValueOf(source), []sszSizeHint{})
+	if err != nil {
+		return false, err
+	}
+
+	return nonZeroLeaves == 0 && !hasListItems, nil
+}
+
+// CountNonZeroLeaves counts the basic values (booleans and unsigned integers) in the given value that are not zero.
+// Each byte of a byte vector or list counts as a separate leaf.
+// Returns an error if the value contains types that are not supported by SSZ.
+func (d *DynSsz) CountNonZeroLeaves(source any) (uint64, error) {
+	nonZeroLeaves, _, err := d.countNonZeroLeaves(reflect.TypeOf(source), reflect.ValueOf(source), []sszSizeHint{})
+	return nonZeroLeaves, err
+}
+
+// countNonZeroLeaves recursively counts the non-zero basic values of a value and checks if it contains non-empty lists.
+func (d *DynSsz) countNonZeroLeaves(sourceType reflect.Type, sourceValue reflect.Value, sizeHints []sszSizeHint) (uint64, bool, error) {
+	if sourceType.Kind() == reflect.Ptr {
+		if sourceValue.IsNil() {
+			return 0, false, nil
+		}
+		sourceType = sourceType.Elem()
+		sourceValue = sourceValue.Elem()
+	}
+
+	childSizeHints := []sszSizeHint{}
+	if len(sizeHints) > 1 {
+		childSizeHints = sizeHints[1:]
+	}
+
+	nonZeroLeaves := uint64(0)
+	hasListItems := false
+
+	switch sourceType.Kind() {
+	case reflect.Struct:
+		for i := 0; i < sourceType.NumField(); i++ {
+			field := d.getStructField(sourceType, i)
+
+			fieldSizeHints, err := d.getSszSizeTag(&field)
+			if err != nil {
+				return 0, false, err
+			}
+
+			fieldLeaves, fieldHasListItems, err := d.countNonZeroLeaves(field.Type, sourceValue.Field(i), fieldSizeHints)
+			if err != nil {
+				return 0, false, fmt.Errorf("failed checking field %v: %v", field.Name, err)
+			}
+			nonZeroLeaves += fieldLeaves
+			hasListItems = hasListItems || fieldHasListItems
+		}
+	case reflect.Array, reflect.Slice:
+		itemCount := sourceValue.Len()
+		if sourceType.Kind() == reflect.Slice && itemCount > 0 && (len(sizeHints) == 0 || sizeHints[0].dynamic) {
+			hasListItems = true
+		}
+
+		itemType := sourceType.Elem()
+		if isByteType(itemType) {
+			// shortcut for performance: check byte arrays & slices directly
+			for i := 0; i < itemCount; i++ {
+				if sourceValue.Index(i).Uint() != 0 {
+					nonZeroLeaves++
+				}
+			}
+			break
+		}
+
+		for i := 0; i < itemCount; i++ {
+			itemLeaves, itemHasListItems, err := d.countNonZeroLeaves(itemType, sourceValue.Index(i), childSizeHints)
+			if err != nil {
+				return 0, false, err
+			}
+			nonZeroLeaves += itemLeaves
+			hasListItems = hasListItems || itemHasListItems
+		}
+	case reflect.Bool:
+		if sourceValue.Bool() {
+			nonZeroLeaves = 1
+		}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if sourceValue.Uint() != 0 {
+			nonZeroLeaves = 1
+		}
+	default:
+		return 0, false, fmt.Errorf("unknown type: %v", sourceType)
+	}
+
+	return nonZeroLeaves, hasListItems, nil
+}
+
+// NewZero allocates a new value of the given type with all fixed size parts pre-allocated: nil pointers are replaced
+// with zero values and slices with a fixed size (vectors annotated via 'ssz-size' or 'dynssz-size') are allocated with
+// their full length. Lists are left empty. This allows filling and marshaling a freshly created object without
+// running into nil fields or short vectors.
+// Returns a pointer to the new value (or the new value itself if 't' is a pointer type), or an error if the size of
+// the type can not be resolved.
+func (d *DynSsz) NewZero(t reflect.Type) (any, error) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if _, _, err := d.getSszSize(t, []sszSizeHint{}); err != nil {
+		return nil, err
+	}
+
+	value := reflect.New(t)
+	if err := d.initZeroValue(t, value.Elem(), []sszSizeHint{}); err != nil {
+		return nil, err
+	}
+
+	return value.Interface(), nil
+}
+
+// initZeroValue recursively allocates the nil pointers and fixed size slices of a value.
+func (d *DynSsz) initZeroValue(targetType reflect.Type, targetValue reflect.Value, sizeHints []sszSizeHint) error {
+	if targetType.Kind() == reflect.Ptr {
+		targetType = targetType.Elem()
+		if targetValue.IsNil() {
+			targetValue.Set(reflect.New(targetType))
+		}
+		targetValue = targetValue.Elem()
+	}
+
+	childSizeHints := []sszSizeHint{}
+	if len(sizeHints) > 1 {
+		childSizeHints = sizeHints[1:]
+	}
+
+	switch targetType.Kind() {
+	case reflect.Struct:
+		for i := 0; i < targetType.NumField(); i++ {
+			field := d.getStructField(targetType, i)
+
+			fieldSizeHints, err := d.getSszSizeTag(&field)
+			if err != nil {
+				return err
+			}
+
+			if err := d.initZeroValue(field.Type, targetValue.Field(i), fieldSizeHints); err != nil {
+				return fmt.Errorf("failed initializing field %v: %v", field.Name, err)
+			}
+		}
+	case reflect.Array, reflect.Slice:
+		if targetType.Kind() == reflect.Slice {
+			if len(sizeHints) == 0 || sizeHints[0].dynamic {
+				// lists are left empty
+				break
+			}
+			if targetValue.Len() < int(sizeHints[0].size) {
+				newValue := reflect.MakeSlice(targetType, int(sizeHints[0].size), int(sizeHints[0].size))
+				reflect.Copy(newValue, targetValue)
+				targetValue.Set(newValue)
+			}
+		}
+
+		if isByteType(targetType.Elem()) {
+			break
+		}
+
+		for i := 0; i < targetValue.Len(); i++ {
+			if err := d.initZeroValue(targetType.Elem(), targetValue.Index(i), childSizeHints); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
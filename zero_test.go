@@ -0,0 +1,110 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_ZeroStruct1 struct {
+	F1 uint64
+	F2 []uint16 `ssz-size:"4"`
+	F3 []uint8
+	F4 *slug_ZeroStruct2
+}
+
+type slug_ZeroStruct2 struct {
+	F1 bool
+	F2 [4]uint8
+}
+
+func TestIsZero(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+
+	testMatrix := []struct {
+		payload  any
+		isZero   bool
+		nonZeros uint64
+	}{
+		{slug_ZeroStruct1{}, true, 0},
+		{slug_ZeroStruct1{F2: []uint16{0, 0}, F4: &slug_ZeroStruct2{}}, true, 0},
+		{slug_ZeroStruct1{F1: 1}, false, 1},
+		{slug_ZeroStruct1{F2: []uint16{0, 2, 3}}, false, 2},
+		{slug_ZeroStruct1{F3: []uint8{0, 0}}, false, 0},
+		{slug_ZeroStruct1{F3: []uint8{1, 0, 1}}, false, 2},
+		{&slug_ZeroStruct1{F4: &slug_ZeroStruct2{F1: true, F2: [4]uint8{0, 1, 1, 1}}}, false, 4},
+	}
+
+	for idx, test := range testMatrix {
+		isZero, err := dynssz.IsZero(test.payload)
+		if err != nil {
+			t.Errorf("test %v: unexpected error: %v", idx, err)
+			continue
+		}
+		if isZero != test.isZero {
+			t.Errorf("test %v: unexpected zero result: %v, wanted %v", idx, isZero, test.isZero)
+		}
+
+		nonZeros, err := dynssz.CountNonZeroLeaves(test.payload)
+		if err != nil {
+			t.Errorf("test %v: unexpected error: %v", idx, err)
+			continue
+		}
+		if nonZeros != test.nonZeros {
+			t.Errorf("test %v: unexpected non-zero leaf count: %v, wanted %v", idx, nonZeros, test.nonZeros)
+		}
+	}
+}
+
+type slug_NewZeroStruct struct {
+	F1 *slug_ZeroStruct2
+	F2 []*slug_ZeroStruct2 `ssz-size:"2"`
+	F3 [][]uint8           `ssz-size:"2,4"`
+	F4 []*slug_ZeroStruct2
+	F5 []uint16 `ssz-size:"2" dynssz-size:"ZERO_VECTOR_SIZE"`
+}
+
+func TestNewZero(t *testing.T) {
+	dynssz := NewDynSsz(map[string]any{
+		"ZERO_VECTOR_SIZE": uint64(3),
+	})
+
+	obj, err := dynssz.NewZero(reflect.TypeOf(slug_NewZeroStruct{}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	zeroObj, ok := obj.(*slug_NewZeroStruct)
+	if !ok {
+		t.Fatalf("unexpected result type: %T", obj)
+	}
+	if zeroObj.F1 == nil || len(zeroObj.F2) != 2 || zeroObj.F2[1] == nil || len(zeroObj.F3) != 2 || len(zeroObj.F3[1]) != 4 {
+		t.Errorf("fixed size fields not allocated: %+v", zeroObj)
+	}
+	if zeroObj.F4 != nil {
+		t.Errorf("unexpected list allocation: %v", zeroObj.F4)
+	}
+	if len(zeroObj.F5) != 3 {
+		t.Errorf("unexpected vector length with spec value: %v, wanted 3", len(zeroObj.F5))
+	}
+
+	ssz, err := dynssz.MarshalSSZ(zeroObj)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	size, err := dynssz.SizeSSZ(zeroObj)
+	if err != nil {
+		t.Fatalf("unexpected size error: %v", err)
+	}
+	if len(ssz) != size {
+		t.Errorf("unexpected ssz length: %v, wanted %v", len(ssz), size)
+	}
+
+	if _, err := dynssz.NewZero(reflect.TypeOf(&slug_EmptyContainer{})); err == nil {
+		t.Errorf("expected error for empty container")
+	}
+}
@@ -0,0 +1,58 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Detach replaces all byte slices in the given object with copies, so the object no longer aliases the input buffer
+// it was decoded from with ZeroCopyDecode enabled. The 'target' parameter must be a pointer to the object.
+// Returns an error if the target is not a pointer.
+func Detach(target any) error {
+	targetValue := reflect.ValueOf(target)
+	if targetValue.Kind() != reflect.Ptr {
+		return fmt.Errorf("target must be a pointer, got %v", targetValue.Kind())
+	}
+
+	detachValue(targetValue)
+	return nil
+}
+
+// detachValue recursively replaces the byte slices in the given value with copies.
+func detachValue(targetValue reflect.Value) {
+	switch targetValue.Kind() {
+	case reflect.Ptr:
+		if !targetValue.IsNil() {
+			detachValue(targetValue.Elem())
+		}
+	case reflect.Struct:
+		for i := 0; i < targetValue.NumField(); i++ {
+			if field := targetValue.Field(i); field.CanSet() {
+				detachValue(field)
+			}
+		}
+	case reflect.Slice:
+		if targetValue.IsNil() {
+			return
+		}
+		if isByteType(targetValue.Type().Elem()) {
+			newValue := reflect.MakeSlice(targetValue.Type(), targetValue.Len(), targetValue.Len())
+			reflect.Copy(newValue, targetValue)
+			targetValue.Set(newValue)
+			return
+		}
+		for i := 0; i < targetValue.Len(); i++ {
+			detachValue(targetValue.Index(i))
+		}
+	case reflect.Array:
+		if isByteType(targetValue.Type().Elem()) {
+			return
+		}
+		for i := 0; i < targetValue.Len(); i++ {
+			detachValue(targetValue.Index(i))
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// dynssz: Dynamic SSZ encoding/decoding for Ethereum with fastssz efficiency.
+// This file is part of the dynssz package.
+// Copyright (c) 2024 by pk910. Refer to LICENSE for more information.
+package dynssz_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/pk910/dynamic-ssz"
+)
+
+type slug_ZeroCopyStruct struct {
+	F1 uint16
+	F2 []uint8   `ssz-size:"?" dynssz-size:"?"`
+	F3 [][]uint8 `ssz-size:"?,?" dynssz-size:"?,?"`
+}
+
+func TestZeroCopyDecode(t *testing.T) {
+	dynssz := NewDynSsz(nil)
+	dynssz.ZeroCopyDecode = true
+
+	ssz, err := dynssz.MarshalSSZ(&slug_ZeroCopyStruct{F1: 1, F2: []uint8{2, 3}, F3: [][]uint8{{4}, {5, 6}}})
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+
+	obj := &slug_ZeroCopyStruct{}
+	if err := dynssz.UnmarshalSSZ(obj, ssz); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+	if !bytes.Equal(obj.F2, []uint8{2, 3}) || !bytes.Equal(obj.F3[1], []uint8{5, 6}) {
+		t.Fatalf("unexpected decoding result: %+v", obj)
+	}
+
+	// appending must not overwrite the input buffer
+	if cap(obj.F2) != len(obj.F2) {
+		t.Errorf("unexpected capacity of aliased slice: %v", cap(obj.F2))
+	}
+
+	// decoded byte lists alias the input buffer until detached
+	ssz[len(ssz)-1] = 0xff
+	if obj.F3[1][1] != 0xff {
+		t.Errorf("expected byte list to alias the input buffer")
+	}
+
+	if err := Detach(obj); err != nil {
+		t.Fatalf("unexpected detach error: %v", err)
+	}
+	ssz[len(ssz)-1] = 0x06
+	if obj.F3[1][1] != 0xff {
+		t.Errorf("expected detached byte list to be independent of the input buffer")
+	}
+
+	if err := Detach(*obj); err == nil {
+		t.Errorf("expected error for non-pointer target")
+	}
+}